@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"github.com/dekanayake/acoustic-content-sync/pkg/acoustic/csv"
+	"github.com/dekanayake/acoustic-content-sync/pkg/env"
+	"github.com/spf13/cobra"
+)
+
+var categoryCmd = &cobra.Command{
+	Use:   "category",
+	Short: "Manage the categories",
+}
+
+var categoryCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create the categories of the feed under a root category",
+	RunE: reported(func(cmd *cobra.Command, report *csv.RunReport) error {
+		if err := requireAcoustic(cmd); err != nil {
+			return err
+		}
+		catService := csv.NewCategoryService(env.AcousticAPIUrl())
+		return catService.Create(getFlagStringValue(cmd, "categoryName"), getFlagStringValue(cmd, "feedLocation"), getFlagStringValue(cmd, "configLocation"))
+	}),
+}
+
+func init() {
+	addConfigFlag(categoryCreateCmd, true)
+	addFeedFlag(categoryCreateCmd, true)
+	categoryCreateCmd.Flags().String("categoryName", "", "Root category name to add new categories")
+	categoryCreateCmd.MarkFlagRequired("categoryName")
+	categoryCmd.AddCommand(categoryCreateCmd)
+	rootCmd.AddCommand(categoryCmd)
+}
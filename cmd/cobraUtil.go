@@ -1,7 +1,20 @@
 package cmd
 
-import "github.com/spf13/cobra"
-import log "github.com/sirupsen/logrus"
+import (
+	"errors"
+	"fmt"
+	"github.com/bgentry/speakeasy"
+	"github.com/dekanayake/acoustic-content-sync/pkg/acoustic/csv"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"os"
+	"strings"
+)
+
+// envAnnotation is the flag annotation holding the env variable of the flag , the env package reads the settings
+// from the env variables so a flag given in the command line is set to its env variable before the command runs
+const envAnnotation = "acoustic_content_sync_env"
 
 func getFlagStringValue(cmd *cobra.Command, flagName string) string {
 	value, err := cmd.Flags().GetString(flagName)
@@ -18,3 +31,142 @@ func getFlagBoolValue(cmd *cobra.Command, flagName string) bool {
 	}
 	return value
 }
+
+// bindEnv binds the flag to the env variable , the flag wins over the env variable which wins over the .env file
+func bindEnv(flags *pflag.FlagSet, flagName string, envName string) {
+	flag := flags.Lookup(flagName)
+	if flag == nil {
+		log.Panic("No flag to bind to the env variable :" + flagName)
+	}
+	flag.Usage = flag.Usage + " (env " + envName + ")"
+	if err := flags.SetAnnotation(flagName, envAnnotation, []string{envName}); err != nil {
+		log.Panic("Error occurred while binding the flag to the env variable :"+flagName, err)
+	}
+}
+
+// setEnvFromFlags sets the env variables of the flags given in the command line
+func setEnvFromFlags(cmd *cobra.Command) {
+	cmd.Flags().Visit(func(flag *pflag.Flag) {
+		if envNames, ok := flag.Annotations[envAnnotation]; ok {
+			os.Setenv(envNames[0], flag.Value.String())
+		}
+	})
+}
+
+// requireEnvFlags checks the env bound flags have a value , from the flag , the env variable or the .env file
+func requireEnvFlags(cmd *cobra.Command, flagNames ...string) error {
+	missing := make([]string, 0)
+	for _, flagName := range flagNames {
+		flag := cmd.Flags().Lookup(flagName)
+		if flag == nil {
+			return errors.New("No flag found :" + flagName)
+		}
+		envNames, ok := flag.Annotations[envAnnotation]
+		if !ok {
+			return errors.New("No env variable bound to the flag :" + flagName)
+		}
+		if strings.TrimSpace(os.Getenv(envNames[0])) == "" {
+			missing = append(missing, "\""+flagName+"\" (env "+envNames[0]+")")
+		}
+	}
+	if len(missing) > 0 {
+		return errors.New("required flag(s) " + strings.Join(missing, ", ") + " not set")
+	}
+	return nil
+}
+
+// requireAcoustic checks the acoustic api url and the authentication is available for the commands calling acoustic ,
+// the password of the user name authentication is asked when it is not in the env
+func requireAcoustic(cmd *cobra.Command, flagNames ...string) error {
+	if err := requireEnvFlags(cmd, append([]string{"AcousticAPIURL"}, flagNames...)...); err != nil {
+		return err
+	}
+	if os.Getenv("AcousticAPIKey") != "" {
+		return nil
+	}
+	authUserName := os.Getenv("AcousticAuthUserName")
+	if authUserName == "" {
+		return errors.New("No Acoustic API key or user name provided, please provide the AcousticAPIKey or the AcousticAuthUserName")
+	}
+	if os.Getenv("AcousticAuthPassword") == "" {
+		password, err := speakeasy.Ask(fmt.Sprintf("Password of %s: ", authUserName))
+		if err != nil {
+			return err
+		}
+		if password == "" {
+			return errors.New("Please provide the password")
+		}
+		os.Setenv("AcousticAuthPassword", password)
+	}
+	return nil
+}
+
+// completeContentTypes completes the content types of the content type and site mappings of the given config
+func completeContentTypes(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	configLocation, err := cmd.Flags().GetString("configLocation")
+	if err != nil || configLocation == "" {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	contentTypes, err := csv.ConfigContentTypes(configLocation)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	return contentTypes, cobra.ShellCompDirectiveNoFileComp
+}
+
+func addConfigFlag(cmd *cobra.Command, required bool) {
+	cmd.Flags().String("configLocation", "", "File path of the config")
+	cmd.MarkFlagFilename("configLocation", "yaml", "yml")
+	if required {
+		cmd.MarkFlagRequired("configLocation")
+	}
+}
+
+func addFeedFlag(cmd *cobra.Command, required bool) {
+	cmd.Flags().String("feedLocation", "", "File path of the feed")
+	cmd.MarkFlagFilename("feedLocation", "csv", "json", "jsonl", "ndjson", "xlsx")
+	if required {
+		cmd.MarkFlagRequired("feedLocation")
+	}
+}
+
+func addContentTypeFlag(cmd *cobra.Command, required bool) {
+	cmd.Flags().String("contentTypeID", "", "Content Type ID")
+	cmd.RegisterFlagCompletionFunc("contentTypeID", completeContentTypes)
+	if required {
+		cmd.MarkFlagRequired("contentTypeID")
+	}
+}
+
+func addLibraryFlag(cmd *cobra.Command) {
+	cmd.Flags().String("libraryID", "", "Acoustic Library ID")
+	bindEnv(cmd.Flags(), "libraryID", "LibraryID")
+}
+
+// addRunFlags adds the flags of the runs going through the records of a feed
+func addRunFlags(cmd *cobra.Command) {
+	cmd.Flags().Int("workers", 0, "Number of records processed concurrently, overrides the workers of the content type config")
+	bindEnv(cmd.Flags(), "workers", "WorkerCount")
+	cmd.Flags().Float64("maxRequestsPerSecond", 0, "Maximum number of requests per second sent to acoustic across all workers, 0 for no limit")
+	bindEnv(cmd.Flags(), "maxRequestsPerSecond", "MaxRequestsPerSecond")
+	cmd.Flags().Bool("resume", false, "Skip the records completed in the previous run of the same feed and config")
+	bindEnv(cmd.Flags(), "resume", "ResumeRun")
+	cmd.Flags().String("journalLocation", "journal", "Folder of the run journals used to resume a run")
+	bindEnv(cmd.Flags(), "journalLocation", "JournalLocation")
+	cmd.Flags().String("include", "", "Only the rows satisfying the condition are read from the feed , added to the include conditions of the config")
+	bindEnv(cmd.Flags(), "include", "RecordInclude")
+	cmd.Flags().String("exclude", "", "The rows satisfying the condition are left out , added to the exclude conditions of the config")
+	bindEnv(cmd.Flags(), "exclude", "RecordExclude")
+	cmd.Flags().Int("fromRow", 0, "The rows of the feed before the row are left out")
+	bindEnv(cmd.Flags(), "fromRow", "FromRow")
+	cmd.Flags().Int("limit", 0, "Maximum number of records read from the feed , 0 for no limit")
+	bindEnv(cmd.Flags(), "limit", "RecordLimit")
+	cmd.Flags().Float64("sample", 0, "Rate of the records picked at random from the feed , between 0 and 1")
+	bindEnv(cmd.Flags(), "sample", "SampleRate")
+	cmd.Flags().Int64("sampleSeed", 0, "Seed of the random sample , a random seed when 0")
+	bindEnv(cmd.Flags(), "sampleSeed", "SampleSeed")
+	cmd.Flags().String("keys", "", "Comma separated record key values , only the records with the keys are read from the feed")
+	bindEnv(cmd.Flags(), "keys", "RecordKeys")
+	cmd.Flags().Bool("keyIndex", false, "Look up the existing contents in an index of the contents of the type loaded once for the run , instead of a search per record")
+	bindEnv(cmd.Flags(), "keyIndex", "KeyIndex")
+}
@@ -0,0 +1,188 @@
+package cmd
+
+import (
+	"github.com/dekanayake/acoustic-content-sync/pkg/acoustic/csv"
+	"github.com/dekanayake/acoustic-content-sync/pkg/env"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"strconv"
+	"time"
+)
+
+var contentCmd = &cobra.Command{
+	Use:   "content",
+	Short: "Create, update, export, clone and dedupe the contents of a content type",
+}
+
+var contentCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create the contents of the records of the feed",
+	Long: `Create the contents of the records of the feed with the content type mapping of the config.
+With --dryRun the would be contents are written to the dry run output location instead of calling acoustic.`,
+	RunE: reported(func(cmd *cobra.Command, report *csv.RunReport) error {
+		if err := requireAcoustic(cmd, "libraryID"); err != nil {
+			return err
+		}
+		return createOrUpdateContents(cmd, report)
+	}),
+}
+
+var contentUpdateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "Update the contents of the records of the feed",
+	Long: `Update the contents of the records of the feed , the existing contents are searched with the search settings of the
+content type mapping and the update and createNonExistingItems settings of the mapping are applied.`,
+	RunE: reported(func(cmd *cobra.Command, report *csv.RunReport) error {
+		if err := requireAcoustic(cmd, "libraryID"); err != nil {
+			return err
+		}
+		return createOrUpdateContents(cmd, report)
+	}),
+}
+
+var contentExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export the contents of a content type to a CSV file",
+	RunE: reported(func(cmd *cobra.Command, report *csv.RunReport) error {
+		if err := requireAcoustic(cmd, "libraryID"); err != nil {
+			return err
+		}
+		contentService := csv.NewContentUseCase(env.AcousticAPIUrl(), env.LibraryID())
+		return contentService.ReadBatch(getFlagStringValue(cmd, "contentTypeID"), getFlagStringValue(cmd, "outputLocation"), getFlagStringValue(cmd, "configLocation"))
+	}),
+}
+
+var contentCloneCmd = &cobra.Command{
+	Use:   "clone",
+	Short: "Clone a content with its references",
+	RunE: reported(func(cmd *cobra.Command, report *csv.RunReport) error {
+		if err := requireAcoustic(cmd); err != nil {
+			return err
+		}
+		copyUseCase := csv.NewContentCopyUserCase(env.AcousticAPIUrl())
+		id := getFlagStringValue(cmd, "id")
+		started := time.Now()
+		status, err := copyUseCase.CopyContent(id, "_CL:"+started.Format(time.ANSIC))
+		if err != nil {
+			report.AddContentCreationStatus(csv.ContentCreationStatus{
+				Failed: []csv.ContentCreationFailedStatus{{CSVIDKey: "id", CSVIDValue: id, Error: err, Duration: time.Since(started)}},
+			})
+			return err
+		}
+		report.AddContentCreationStatus(*status)
+		return nil
+	}),
+}
+
+var contentDedupeCmd = &cobra.Command{
+	Use:   "dedupe",
+	Short: "Retire the duplicate contents of a content type",
+	Long: `Retire the contents of the content type sharing the values of the search keys of the content type mapping. A content
+of each key is kept , the oldest or the newest with --keep , and with --merge the elements missing in the kept content are
+copied from the duplicates before they are retired.`,
+	RunE: reported(func(cmd *cobra.Command, report *csv.RunReport) error {
+		if err := requireAcoustic(cmd, "libraryID"); err != nil {
+			return err
+		}
+		deleteService := csv.NewDeleteService(env.AcousticAPIUrl())
+		status, err := deleteService.Dedupe(getFlagStringValue(cmd, "contentTypeID"), getFlagStringValue(cmd, "configLocation"), getFlagStringValue(cmd, "keep"), getFlagBoolValue(cmd, "merge"))
+		report.AddContentDeletionStatus(status)
+		if err != nil {
+			return err
+		}
+		log.Info(" retired duplicate count  :" + strconv.Itoa(len(status.Success)))
+		log.Info(" skipped duplicate count  :" + strconv.Itoa(len(status.Skipped)))
+		if status.FailuresExist() {
+			log.Error("There are " + strconv.Itoa(len(status.Failed)) + " failures in retiring the duplicates , please check the log in " + env.ErrorLogFileLocation())
+			status.PrintFailed()
+		}
+		return nil
+	}),
+}
+
+func createOrUpdateContents(cmd *cobra.Command, report *csv.RunReport) error {
+	contentService := csv.NewContentUseCase(env.AcousticAPIUrl(), env.LibraryID())
+	status, err := contentService.CreateBatch(getFlagStringValue(cmd, "contentTypeID"), getFlagStringValue(cmd, "feedLocation"), getFlagStringValue(cmd, "configLocation"))
+	report.AddContentCreationStatus(status)
+	log.Info(" total records :" + strconv.Itoa(status.TotalCount()))
+	log.Info(" success created record count  :" + strconv.Itoa(len(status.Success)))
+	log.Info(" skipped record count  :" + strconv.Itoa(len(status.Skipped)))
+	if status.Filtered > 0 {
+		log.Info(" filtered record count  :" + strconv.Itoa(status.Filtered))
+	}
+	for _, unresolved := range status.UnresolvedReferences {
+		entry := log.WithField(unresolved.CSVIDKey, unresolved.CSVIDValue).WithField("element", unresolved.Element)
+		if unresolved.Error != nil {
+			entry.WithError(unresolved.Error).Error("Failed in linking the references")
+		} else {
+			entry.WithField("recordKeys", unresolved.RecordKeys).Error("Unresolved references , the record keys are not synced")
+		}
+	}
+	if len(status.AmbiguousKeys) > 0 {
+		log.Warn(" records with duplicate contents :" + strconv.Itoa(len(status.AmbiguousKeys)) + " , the candidates are in the run report")
+	}
+	if status.FailuresExist() {
+		log.Error("There are " + strconv.Itoa(len(status.Failed)) + " failures in creating contents , please check the log in " + env.ErrorLogFileLocation())
+		status.PrintFailed()
+		status.Disappeared.PrintFailed()
+	}
+	if status.Disappeared.TotalCount() > 0 {
+		log.Info(" removed disappeared record count  :" + strconv.Itoa(len(status.Disappeared.Success)))
+	}
+	return err
+}
+
+func addContentRunFlags(cmd *cobra.Command) {
+	addConfigFlag(cmd, true)
+	addFeedFlag(cmd, true)
+	addContentTypeFlag(cmd, true)
+	addLibraryFlag(cmd)
+	addRunFlags(cmd)
+	cmd.Flags().String("contentStatus", "", "Status of the created contents , ready or draft")
+	bindEnv(cmd.Flags(), "contentStatus", "ContentStatus")
+	cmd.RegisterFlagCompletionFunc("contentStatus", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"ready", "draft"}, cobra.ShellCompDirectiveNoFileComp
+	})
+	cmd.Flags().Bool("dryRun", false, "Run the mapping without calling acoustic, the would be contents are written to the dry run output location")
+	bindEnv(cmd.Flags(), "dryRun", "DryRun")
+	cmd.Flags().String("dryRunOutputLocation", "dry_run", "Output folder of the dry run contents")
+	bindEnv(cmd.Flags(), "dryRunOutputLocation", "DryRunOutputLocation")
+	cmd.Flags().Bool("delta", false, "Only process the records added or changed since the snapshot of the previous delta run")
+	bindEnv(cmd.Flags(), "delta", "DeltaRun")
+	cmd.Flags().String("snapshotLocation", "snapshot", "Folder of the snapshots of the delta runs")
+	bindEnv(cmd.Flags(), "snapshotLocation", "SnapshotLocation")
+	cmd.Flags().String("deltaDeleteMapping", "", "Delete mapping used to delete or retire the contents of the records disappeared from the feed of a delta run")
+	bindEnv(cmd.Flags(), "deltaDeleteMapping", "DeltaDeleteMapping")
+	cmd.Flags().Duration("searchWaitTimeout", 0, "How long the search is polled until a created content is searchable , 0 for no polling")
+	bindEnv(cmd.Flags(), "searchWaitTimeout", "SearchWaitTimeout")
+	cmd.Flags().Duration("searchWaitInterval", time.Second, "Interval of the polls of the search for a created content")
+	bindEnv(cmd.Flags(), "searchWaitInterval", "SearchWaitInterval")
+}
+
+func init() {
+	addContentRunFlags(contentCreateCmd)
+	addContentRunFlags(contentUpdateCmd)
+
+	addConfigFlag(contentExportCmd, true)
+	addContentTypeFlag(contentExportCmd, true)
+	addLibraryFlag(contentExportCmd)
+	contentExportCmd.Flags().String("outputLocation", "", "File path of the exported CSV")
+	contentExportCmd.MarkFlagRequired("outputLocation")
+
+	contentCloneCmd.Flags().String("id", "", "ID of the content to clone")
+	contentCloneCmd.MarkFlagRequired("id")
+
+	addConfigFlag(contentDedupeCmd, true)
+	addContentTypeFlag(contentDedupeCmd, true)
+	addLibraryFlag(contentDedupeCmd)
+	contentDedupeCmd.Flags().String("keep", csv.DEDUPE_KEEP_OLDEST, "The duplicate kept for each key , oldest or newest")
+	contentDedupeCmd.RegisterFlagCompletionFunc("keep", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{csv.DEDUPE_KEEP_OLDEST, csv.DEDUPE_KEEP_NEWEST}, cobra.ShellCompDirectiveNoFileComp
+	})
+	contentDedupeCmd.Flags().Bool("merge", false, "Copy the elements missing in the kept content from the duplicates before they are retired")
+	contentDedupeCmd.Flags().Bool("dryRun", false, "Report the duplicates without retiring them")
+	bindEnv(contentDedupeCmd.Flags(), "dryRun", "DryRun")
+
+	contentCmd.AddCommand(contentCreateCmd, contentUpdateCmd, contentExportCmd, contentCloneCmd, contentDedupeCmd)
+	rootCmd.AddCommand(contentCmd)
+}
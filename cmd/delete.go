@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"errors"
+	"github.com/dekanayake/acoustic-content-sync/pkg/acoustic/csv"
+	"github.com/dekanayake/acoustic-content-sync/pkg/env"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"strconv"
+)
+
+var deleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Delete the contents or assets of a delete mapping",
+	Long: `Delete the contents or assets searched by the delete mapping of the config in a library ,
+or with --byFeed the contents of the records of the feed.`,
+	RunE: reported(func(cmd *cobra.Command, report *csv.RunReport) error {
+		if err := requireDeleteFlags(cmd); err != nil {
+			return err
+		}
+		deleteService := csv.NewDeleteService(env.AcousticAPIUrl())
+		deleteMappingName := getFlagStringValue(cmd, "deleteMappingName")
+		configLocation := getFlagStringValue(cmd, "configLocation")
+		if !getFlagBoolValue(cmd, "byFeed") {
+			return deleteService.Delete(env.LibraryID(), deleteMappingName, configLocation)
+		}
+		status, err := deleteService.DeleteByFeed(deleteMappingName, getFlagStringValue(cmd, "contentTypeID"), getFlagStringValue(cmd, "feedLocation"), configLocation)
+		report.AddContentDeletionStatus(status)
+		if err != nil {
+			return err
+		}
+		log.Info(" total records :" + strconv.Itoa(status.TotalCount()))
+		log.Info(" success deleted record count  :" + strconv.Itoa(len(status.Success)))
+		log.Info(" skipped record count  :" + strconv.Itoa(len(status.Skipped)))
+		if status.FailuresExist() {
+			log.Error("There are " + strconv.Itoa(len(status.Failed)) + " failures in creating contents , please check the log in " + env.ErrorLogFileLocation())
+			status.PrintFailed()
+		}
+		return nil
+	}),
+}
+
+func requireDeleteFlags(cmd *cobra.Command) error {
+	if !getFlagBoolValue(cmd, "byFeed") {
+		return requireAcoustic(cmd, "libraryID")
+	}
+	if getFlagStringValue(cmd, "feedLocation") == "" || getFlagStringValue(cmd, "contentTypeID") == "" {
+		return errors.New("feedLocation and contentTypeID are required to delete by feed")
+	}
+	return requireAcoustic(cmd)
+}
+
+func init() {
+	addConfigFlag(deleteCmd, true)
+	addFeedFlag(deleteCmd, false)
+	addContentTypeFlag(deleteCmd, false)
+	addLibraryFlag(deleteCmd)
+	addRunFlags(deleteCmd)
+	deleteCmd.Flags().String("deleteMappingName", "", "Delete Mapping Name")
+	deleteCmd.MarkFlagRequired("deleteMappingName")
+	deleteCmd.Flags().Bool("byFeed", false, "Delete the contents of the records of the feed")
+	rootCmd.AddCommand(deleteCmd)
+}
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"github.com/dekanayake/acoustic-content-sync/pkg/acoustic/csv"
+	"github.com/dekanayake/acoustic-content-sync/pkg/env"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"strconv"
+)
+
+var jobCmd = &cobra.Command{
+	Use:   "job",
+	Short: "Run the steps of a job manifest",
+}
+
+var jobRunCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Run the categories , contents , site pages and deletes of a job manifest in order",
+	Long: `Run the steps of the job manifest in order with a combined run report. The job stops at the first failed step ,
+unless continueOnFailure is set in the manifest.`,
+	RunE: reported(func(cmd *cobra.Command, report *csv.RunReport) error {
+		if err := requireAcoustic(cmd); err != nil {
+			return err
+		}
+		err := csv.NewJobRunner(env.AcousticAPIUrl()).Run(getFlagStringValue(cmd, "manifestLocation"), report)
+		for _, step := range report.Steps {
+			log.Info(" step " + step.Name + " " + string(step.Status) + " , total records :" + strconv.Itoa(step.Totals.Total) + " , failed records :" + strconv.Itoa(step.Totals.Failed))
+		}
+		return err
+	}),
+}
+
+func init() {
+	jobRunCmd.Flags().String("manifestLocation", "", "File path of the job manifest")
+	jobRunCmd.MarkFlagFilename("manifestLocation", "yaml", "yml")
+	jobRunCmd.MarkFlagRequired("manifestLocation")
+	addLibraryFlag(jobRunCmd)
+	jobRunCmd.Flags().Bool("dryRun", false, "Run the content steps without calling acoustic, the other steps are skipped")
+	bindEnv(jobRunCmd.Flags(), "dryRun", "DryRun")
+	jobRunCmd.Flags().String("dryRunOutputLocation", "dry_run", "Output folder of the dry run contents")
+	bindEnv(jobRunCmd.Flags(), "dryRunOutputLocation", "DryRunOutputLocation")
+	jobCmd.AddCommand(jobRunCmd)
+	rootCmd.AddCommand(jobCmd)
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"github.com/dekanayake/acoustic-content-sync/pkg/acoustic/csv"
+	"github.com/dekanayake/acoustic-content-sync/pkg/env"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"strings"
+)
+
+type reportedRun func(cmd *cobra.Command, report *csv.RunReport) error
+
+// reported runs the command with a run report , the report is written at the end of the command even when it fails
+// so a pipeline always has the outcome of the run
+func reported(run reportedRun) func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		report := csv.NewRunReport(strings.TrimPrefix(cmd.CommandPath(), rootCmd.Name()+" "))
+		err := run(cmd, report)
+		report.Finish(err)
+		writeRunReport(report)
+		return err
+	}
+}
+
+func writeRunReport(report *csv.RunReport) {
+	if reportLocation := env.ReportLocation(); reportLocation != "" {
+		if err := report.WriteJSON(reportLocation); err != nil {
+			log.WithError(err).Error("Failed in writing the run report :" + reportLocation)
+		} else {
+			log.Info("Run report written to :" + reportLocation)
+		}
+	}
+	if junitReportLocation := env.JUnitReportLocation(); junitReportLocation != "" {
+		if err := report.WriteJUnit(junitReportLocation); err != nil {
+			log.WithError(err).Error("Failed in writing the JUnit run report :" + junitReportLocation)
+		} else {
+			log.Info("JUnit run report written to :" + junitReportLocation)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"github.com/dekanayake/acoustic-content-sync/pkg/acoustic/csv"
+	"github.com/dekanayake/acoustic-content-sync/pkg/env"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"io"
+	"os"
+)
+
+var scaffoldCmd = &cobra.Command{
+	Use:   "scaffold",
+	Short: "Generate a starter config and CSV template from a content type in acoustic",
+	Long: `Generate a starter config of the content type to --configLocation , and a header only CSV template of the columns of the
+config to --feedLocation when it is given. Existing files are not overwritten.`,
+	RunE: reported(func(cmd *cobra.Command, report *csv.RunReport) error {
+		if err := requireAcoustic(cmd); err != nil {
+			return err
+		}
+		contentType := getFlagStringValue(cmd, "contentTypeID")
+		configLocation := getFlagStringValue(cmd, "configLocation")
+		configFile, err := os.OpenFile(configLocation, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err != nil {
+			return err
+		}
+		defer configFile.Close()
+		var csvTemplate io.Writer
+		if feedLocation := getFlagStringValue(cmd, "feedLocation"); feedLocation != "" {
+			csvTemplateFile, err := os.OpenFile(feedLocation, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+			if err != nil {
+				return err
+			}
+			defer csvTemplateFile.Close()
+			csvTemplate = csvTemplateFile
+		}
+		if err := csv.NewConfigScaffolder(env.AcousticAPIUrl()).Scaffold(contentType, configFile, csvTemplate); err != nil {
+			return err
+		}
+		log.Info("Scaffolded the config of the content type :" + contentType + " to :" + configLocation)
+		return nil
+	}),
+}
+
+func init() {
+	scaffoldCmd.Flags().String("contentTypeID", "", "Content Type ID")
+	scaffoldCmd.MarkFlagRequired("contentTypeID")
+	scaffoldCmd.Flags().String("configLocation", "", "File path of the generated config")
+	scaffoldCmd.MarkFlagRequired("configLocation")
+	scaffoldCmd.Flags().String("feedLocation", "", "File path of the generated CSV template")
+	rootCmd.AddCommand(scaffoldCmd)
+}
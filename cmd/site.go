@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"github.com/dekanayake/acoustic-content-sync/pkg/acoustic/csv"
+	"github.com/dekanayake/acoustic-content-sync/pkg/env"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"os"
+	"strconv"
+	"time"
+)
+
+var siteCmd = &cobra.Command{
+	Use:   "site",
+	Short: "Create the site pages of the contents",
+}
+
+var sitePagesCmd = &cobra.Command{
+	Use:   "pages",
+	Short: "Create a site page with its content for each record of the feed",
+	RunE: reported(func(cmd *cobra.Command, report *csv.RunReport) error {
+		if err := requireAcoustic(cmd); err != nil {
+			return err
+		}
+		contentType := getFlagStringValue(cmd, "contentTypeID")
+		os.Setenv("ParentPageContentTypeID", contentType)
+		siteUseCase := csv.NewSiteUseCase(env.AcousticAPIUrl())
+		status, err := siteUseCase.CreatePages(getFlagStringValue(cmd, "siteID"), getFlagStringValue(cmd, "parentPageID"), contentType, getFlagStringValue(cmd, "feedLocation"), getFlagStringValue(cmd, "configLocation"))
+		report.AddContentCreationStatus(status)
+		log.Info(" total records :" + strconv.Itoa(status.TotalCount()))
+		log.Info(" success created pages count  :" + strconv.Itoa(len(status.Success)))
+		log.Info(" skipped pages count  :" + strconv.Itoa(len(status.Skipped)))
+		if status.Filtered > 0 {
+			log.Info(" filtered record count  :" + strconv.Itoa(status.Filtered))
+		}
+		if status.FailuresExist() {
+			log.Error("There are " + strconv.Itoa(len(status.Failed)) + " failures in creating pages , please check the log in " + env.ErrorLogFileLocation())
+			status.PrintFailed()
+		}
+		return err
+	}),
+}
+
+var sitePageForContentCmd = &cobra.Command{
+	Use:   "page-for-content",
+	Short: "Create a site page for an existing content",
+	RunE: reported(func(cmd *cobra.Command, report *csv.RunReport) error {
+		if err := requireAcoustic(cmd); err != nil {
+			return err
+		}
+		os.Setenv("ParentPageContentTypeID", getFlagStringValue(cmd, "contentTypeID"))
+		siteUseCase := csv.NewSiteUseCase(env.AcousticAPIUrl())
+		contentID := getFlagStringValue(cmd, "contentID")
+		started := time.Now()
+		createdPageID, err := siteUseCase.CreatePageForContent(getFlagStringValue(cmd, "siteID"), getFlagStringValue(cmd, "parentPageID"), contentID, getFlagStringValue(cmd, "relativeUrl"))
+		if err != nil {
+			report.AddContentCreationStatus(csv.ContentCreationStatus{
+				Failed: []csv.ContentCreationFailedStatus{{CSVIDKey: "contentID", CSVIDValue: contentID, Error: err, Duration: time.Since(started)}},
+			})
+			return err
+		}
+		report.AddContentCreationStatus(csv.ContentCreationStatus{
+			Success: []csv.ContentCreationSuccessStatus{{CSVIDKey: "contentID", CSVIDValue: contentID, ContentID: createdPageID, Duration: time.Since(started)}},
+		})
+		log.Info("Page created with ID :" + createdPageID)
+		return nil
+	}),
+}
+
+func addSiteFlags(cmd *cobra.Command) {
+	cmd.Flags().String("siteID", "", "Site ID")
+	cmd.MarkFlagRequired("siteID")
+	cmd.Flags().String("parentPageID", "", "Parent page ID")
+	cmd.MarkFlagRequired("parentPageID")
+	addContentTypeFlag(cmd, true)
+}
+
+func init() {
+	addSiteFlags(sitePagesCmd)
+	addConfigFlag(sitePagesCmd, true)
+	addFeedFlag(sitePagesCmd, true)
+	addRunFlags(sitePagesCmd)
+
+	addSiteFlags(sitePageForContentCmd)
+	sitePageForContentCmd.Flags().String("contentID", "", "Content ID to create page")
+	sitePageForContentCmd.MarkFlagRequired("contentID")
+	sitePageForContentCmd.Flags().String("relativeUrl", "", "Relative URL of the page")
+	sitePageForContentCmd.MarkFlagRequired("relativeUrl")
+
+	siteCmd.AddCommand(sitePagesCmd, sitePageForContentCmd)
+	rootCmd.AddCommand(siteCmd)
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"errors"
+	"github.com/dekanayake/acoustic-content-sync/pkg/acoustic/csv"
+	"github.com/dekanayake/acoustic-content-sync/pkg/env"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"sort"
+	"strconv"
+)
+
+var validateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check a config and report the problems with the line number of the config",
+	Long: `Check a config before it is used in a run. With --feedLocation the columns used by the mappings are checked against the
+header of the sample feed , with --checkContentType the field mappings are checked against the content types in acoustic.`,
+	RunE: reported(func(cmd *cobra.Command, report *csv.RunReport) error {
+		configLocation := getFlagStringValue(cmd, "configLocation")
+		contentType := getFlagStringValue(cmd, "contentTypeID")
+		problems, err := csv.NewConfigValidator().Validate(configLocation, getFlagStringValue(cmd, "feedLocation"), contentType)
+		if err != nil {
+			return err
+		}
+		if getFlagBoolValue(cmd, "checkContentType") {
+			if err := requireAcoustic(cmd); err != nil {
+				return err
+			}
+			contentTypeProblems, err := csv.NewContentTypeChecker(env.AcousticAPIUrl()).Check(configLocation, contentType)
+			if err != nil {
+				return err
+			}
+			problems = append(problems, contentTypeProblems...)
+			sort.SliceStable(problems, func(i, j int) bool {
+				return problems[i].Line < problems[j].Line
+			})
+		}
+		if len(problems) == 0 {
+			log.Info("No problems found in the config :" + configLocation)
+			return nil
+		}
+		report.AddConfigProblems(problems)
+		for _, problem := range problems {
+			log.Error(problem.String())
+		}
+		return errors.New("There are " + strconv.Itoa(len(problems)) + " problems in the config :" + configLocation)
+	}),
+}
+
+func init() {
+	addConfigFlag(validateCmd, true)
+	addFeedFlag(validateCmd, false)
+	addContentTypeFlag(validateCmd, false)
+	validateCmd.Flags().Bool("checkContentType", false, "Check the field mappings against the content type definitions in acoustic")
+	rootCmd.AddCommand(validateCmd)
+}
@@ -7,30 +7,31 @@ require (
 	github.com/cenkalti/backoff/v4 v4.1.0
 	github.com/dimchansky/utfbom v1.1.0
 	github.com/goccy/go-yaml v1.8.0
+	github.com/golang-collections/collections v0.0.0-20130729185459-604e922904d3
 	github.com/jinzhu/copier v0.3.2
+	github.com/joho/godotenv v1.4.0
 	github.com/monmohan/xferspdy v0.0.0-20201203013110-78545d09007d
 	github.com/patrickmn/go-cache v2.1.0+incompatible
 	github.com/pkg/errors v0.9.1
 	github.com/sirupsen/logrus v1.6.0
 	github.com/spf13/cobra v1.1.0
+	github.com/spf13/pflag v1.0.5
 	github.com/thoas/go-funk v0.9.2
 	github.com/wesovilabs/koazee v0.0.5
 	golang.org/x/exp v0.0.0-20220722155223-a9213eeb770e
+	golang.org/x/text v0.3.8
 	gopkg.in/gographics/imagick.v3 v3.3.0
 	gopkg.in/resty.v1 v1.12.0
 )
 
 require (
 	github.com/fatih/color v1.7.0 // indirect
-	github.com/golang-collections/collections v0.0.0-20130729185459-604e922904d3 // indirect
 	github.com/golang/glog v0.0.0-20160126235308-23def4e6c14b // indirect
 	github.com/inconshreveable/mousetrap v1.0.0 // indirect
-	github.com/joho/godotenv v1.4.0 // indirect
 	github.com/konsorten/go-windows-terminal-sequences v1.0.3 // indirect
 	github.com/mattn/go-colorable v0.1.4 // indirect
 	github.com/mattn/go-isatty v0.0.10 // indirect
-	github.com/spf13/pflag v1.0.5 // indirect
 	golang.org/x/net v0.0.0-20190620200207-3b0461eec859 // indirect
-	golang.org/x/sys v0.0.0-20220520151302-bc2c85ada10a // indirect
+	golang.org/x/sys v0.0.0-20220722155257-8c9f86f7a55f // indirect
 	golang.org/x/xerrors v0.0.0-20200804184101-5ec99f83aff1 // indirect
 )
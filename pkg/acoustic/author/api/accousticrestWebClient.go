@@ -4,13 +4,19 @@ import (
 	"github.com/dekanayake/acoustic-content-sync/pkg/env"
 	log "github.com/sirupsen/logrus"
 	"gopkg.in/resty.v1"
+	"strconv"
 	"sync"
+	"time"
 )
 
 var once sync.Once
 
 var instance *resty.Client
 
+var requestRateLimiterOnce sync.Once
+
+var requestRateLimiter <-chan time.Time
+
 func Connect() *resty.Client {
 	if env.AlwaysCreateNewAcousticRestAPIConnection() {
 		log.Info("AlwaysCreateNewAcousticRestAPIConnection : enabled , creating a new connection ")
@@ -23,6 +29,22 @@ func Connect() *resty.Client {
 	}
 }
 
+// waitForRequestSlot blocks until a request is allowed under the MaxRequestsPerSecond ceiling.
+// The ceiling is shared by all the clients so it applies to the whole run regardless of the number of workers
+func waitForRequestSlot(c *resty.Client, r *resty.Request) error {
+	requestRateLimiterOnce.Do(func() {
+		maxRequestsPerSecond := env.MaxRequestsPerSecond()
+		if maxRequestsPerSecond > 0 {
+			log.Info("Limiting the acoustic api requests per second to :" + strconv.FormatFloat(maxRequestsPerSecond, 'f', -1, 64))
+			requestRateLimiter = time.NewTicker(time.Duration(float64(time.Second) / maxRequestsPerSecond)).C
+		}
+	})
+	if requestRateLimiter != nil {
+		<-requestRateLimiter
+	}
+	return nil
+}
+
 func connect() *resty.Client {
 	authUserName := env.AcousticAuthUserName()
 	password := env.AcousticAuthPassword()
@@ -35,10 +57,10 @@ func connect() *resty.Client {
 			log.Panic("Password not provided for acoustic user auth for user name :" + authUserName)
 		}
 		log.WithField("User name", authUserName).Info("Setting the user name as basic auth")
-		return resty.New().SetBasicAuth(authUserName, password).SetDebug(env.IsDebugEnabled())
+		return resty.New().SetBasicAuth(authUserName, password).SetDebug(env.IsDebugEnabled()).OnBeforeRequest(waitForRequestSlot)
 	} else if apiKey != "" {
 		log.WithField("APIKey", apiKey).Info("Setting the api key as basic auth")
-		return resty.New().SetBasicAuth("AcousticAPIKey", apiKey).SetDebug(env.IsDebugEnabled())
+		return resty.New().SetBasicAuth("AcousticAPIKey", apiKey).SetDebug(env.IsDebugEnabled()).OnBeforeRequest(waitForRequestSlot)
 	}
 	return nil
 }
@@ -232,6 +232,27 @@ var fileElementConverter = acousticElementConvertor{
 	}),
 }
 
+var videoElementConverter = acousticElementConvertor{
+	elementFactMatcher: elementMatcherFunc(func(fieldType AcousticFieldType) bool {
+		return fieldType == AcousticFieldType(AcousticFieldVideo)
+	}),
+	isMultiMatcher: isMultiMatcherFunc(func() bool {
+		return false
+	}),
+	convert: convertFunc(func(acousticElement map[string]interface{}) (Element, error) {
+		jsonString, err := json.Marshal(acousticElement)
+		if err != nil {
+			return nil, err
+		}
+		element := VideoElement{}
+		err = json.Unmarshal(jsonString, &element)
+		if err != nil {
+			return nil, err
+		}
+		return element, nil
+	}),
+}
+
 var imageElementConverter = acousticElementConvertor{
 	elementFactMatcher: elementMatcherFunc(func(fieldType AcousticFieldType) bool {
 		return fieldType == AcousticFieldType(AcousticFieldImage)
@@ -400,6 +421,7 @@ func init() {
 		multiGroupElementConverter,
 		booleanElementConverter,
 		fileElementConverter,
+		videoElementConverter,
 		imageElementConverter,
 		multiImageElementConverter,
 		categoryElementConverter,
@@ -7,7 +7,7 @@ import (
 	"time"
 )
 
-var cacheOnce sync.Once
+var cacheInstanceMux sync.Mutex
 
 var cacheInstanceMap map[CacheType]*cache.Cache = make(map[CacheType]*cache.Cache)
 
@@ -66,6 +66,9 @@ func NewCacheRepository() CacheRepository {
 }
 
 func getCache(cacheType CacheType) (*cache.Cache, error) {
+	// GetCache only holds the read lock, so the lazy creation of the cache needs its own lock
+	cacheInstanceMux.Lock()
+	defer cacheInstanceMux.Unlock()
 	var cacheInstance *cache.Cache = nil
 	if cacheRepo, ok := cacheInstanceMap[cacheType]; ok {
 		cacheInstance = cacheRepo
@@ -13,6 +13,7 @@ var cachedCategoryClientInstance *cachedCategoryClient
 type cachedCategoryClient struct {
 	categoryClient CategoryClient
 	cache          *cache.Cache
+	mux            *sync.Mutex
 }
 
 func NewCachedCategoryClient(acousticApiUrl string) CategoryClient {
@@ -20,6 +21,7 @@ func NewCachedCategoryClient(acousticApiUrl string) CategoryClient {
 		cachedCategoryClientInstance = &cachedCategoryClient{
 			categoryClient: NewCategoryClient(acousticApiUrl),
 			cache:          cache.New(1*time.Hour, 2*time.Hour),
+			mux:            &sync.Mutex{},
 		}
 	})
 	return cachedCategoryClientInstance
@@ -27,6 +29,13 @@ func NewCachedCategoryClient(acousticApiUrl string) CategoryClient {
 
 func (c cachedCategoryClient) Categories(categoryName string) ([]CategoryItem, error) {
 	cached, found := c.cache.Get(categoryName)
+	if found {
+		return cached.([]CategoryItem), nil
+	}
+	// concurrent workers missing the cache at the same time should load the categories only once
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	cached, found = c.cache.Get(categoryName)
 	if found {
 		return cached.([]CategoryItem), nil
 	} else {
@@ -121,6 +121,15 @@ func (element FileElement) Clone() (Element, error) {
 	return clonedElement, nil
 }
 
+func (element VideoElement) Clone() (Element, error) {
+	clonedElement := VideoElement{}
+	clonedElement.ElementType = element.ElementType
+	clonedElement.Asset = Asset{
+		ID: element.Asset.ID,
+	}
+	return clonedElement, nil
+}
+
 func (element GroupElement) Clone() (Element, error) {
 	clonedElement := GroupElement{}
 	clonedElement.ElementType = element.ElementType
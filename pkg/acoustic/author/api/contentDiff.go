@@ -0,0 +1,77 @@
+package api
+
+import (
+	"encoding/json"
+	"github.com/dekanayake/acoustic-content-sync/pkg/errors"
+	"reflect"
+	"sort"
+)
+
+// elementsOf is the JSON of each element of the content , taken before the content is merged as the merge converts the
+// existing elements in place
+func elementsOf(content *Content) (map[string]json.RawMessage, error) {
+	elements := make(map[string]json.RawMessage, len(content.Elements))
+	for key, element := range content.Elements {
+		elementJSON, err := json.Marshal(element)
+		if err != nil {
+			return nil, errors.ErrorWithStack(err)
+		}
+		elements[key] = elementJSON
+	}
+	return elements, nil
+}
+
+// changedElements compares the elements of the updated content with the existing elements , the elements are compared
+// after both are read through the element converters so the attributes acoustic adds to an element ( like the url of an
+// asset ) do not count as a change
+func changedElements(existingElements map[string]json.RawMessage, updated Content) ([]string, error) {
+	changed := make([]string, 0)
+	for key, updatedElement := range updated.Elements {
+		existingJSON, ok := existingElements[key]
+		if !ok {
+			changed = append(changed, key)
+			continue
+		}
+		updatedJSON, err := json.Marshal(updatedElement)
+		if err != nil {
+			return nil, errors.ErrorWithStack(err)
+		}
+		existingValue, err := normalizedElement(existingJSON)
+		if err != nil {
+			return nil, err
+		}
+		updatedValue, err := normalizedElement(updatedJSON)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(existingValue, updatedValue) {
+			changed = append(changed, key)
+		}
+	}
+	for key := range existingElements {
+		if _, ok := updated.Elements[key]; !ok {
+			changed = append(changed, key)
+		}
+	}
+	sort.Strings(changed)
+	return changed, nil
+}
+
+func normalizedElement(elementJSON json.RawMessage) (interface{}, error) {
+	var elementData map[string]interface{}
+	if err := json.Unmarshal(elementJSON, &elementData); err != nil {
+		return nil, errors.ErrorWithStack(err)
+	}
+	if element, err := Convert(elementData); err == nil && element != nil {
+		convertedJSON, err := json.Marshal(element)
+		if err != nil {
+			return nil, errors.ErrorWithStack(err)
+		}
+		elementJSON = convertedJSON
+	}
+	var normalized interface{}
+	if err := json.Unmarshal(elementJSON, &normalized); err != nil {
+		return nil, errors.ErrorWithStack(err)
+	}
+	return normalized, nil
+}
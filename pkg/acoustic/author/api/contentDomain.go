@@ -15,6 +15,16 @@ const (
 	DEFAULT_OPERATION Operation = "-"
 )
 
+type ContentAction string
+
+const (
+	CONTENT_CREATED ContentAction = "created"
+	CONTENT_UPDATED ContentAction = "updated"
+	CONTENT_SKIPPED ContentAction = "skipped"
+	// the existing content has the values of the record , the update is not sent
+	CONTENT_SKIPPED_UNCHANGED ContentAction = "skipped-unchanged"
+)
+
 type AssetType string
 
 const (
@@ -36,6 +46,7 @@ const (
 	Boolean              FieldType = "toggle"
 	Link                 FieldType = "link"
 	Date                 FieldType = "date"
+	DateTime             FieldType = "datetime"
 	Category             FieldType = "category"
 	CategoryPart         FieldType = "category-part"
 	File                 FieldType = "file"
@@ -64,8 +75,9 @@ func (ft FieldType) Convert() (AcousticFieldType, error) {
 		return AcousticFieldType(AcousticFieldBoolean), nil
 	case Link:
 		return AcousticFieldType(AcousticFieldLink), nil
-	case Date:
-		return AcousticFieldType(AcousticFieldDate), nil
+	// acoustic has no date element , a date is a datetime element holding the day
+	case Date, DateTime:
+		return AcousticFieldType(AcousticFieldDateTime), nil
 	case Category, CategoryPart:
 		return AcousticFieldType(AcousticFieldCategory), nil
 	case File:
@@ -331,6 +343,11 @@ type FileElement struct {
 	element
 }
 
+type VideoElement struct {
+	Asset Asset `json:"asset"`
+	element
+}
+
 type GroupElement struct {
 	TypeRef map[string]string      `json:"typeRef"`
 	Value   map[string]interface{} `json:"value,omitempty"`
@@ -399,11 +416,18 @@ type Asset struct {
 }
 
 type ContentAutheringResponse struct {
-	Id     string `json:"id"`
-	Rev    string `json:"rev"`
-	Name   string `json:"name"`
-	TypeId string `json:"typeId"`
-	Type   string `json:"type"`
+	Id     string        `json:"id"`
+	Rev    string        `json:"rev"`
+	Name   string        `json:"name"`
+	TypeId string        `json:"typeId"`
+	Type   string        `json:"type"`
+	Action ContentAction `json:"-"`
+	// the elements changed by an update
+	ChangedElements []string `json:"-"`
+	// the IDs of the contents found for the key of the record when there are more than one , and the IDs of the contents
+	// updated out of them
+	Candidates []string `json:"-"`
+	UpdatedIDs []string `json:"-"`
 }
 
 type ContentUpdateResponse struct {
@@ -483,6 +507,10 @@ func Build(fieldType string) (Element, error) {
 		element := DateElement{}
 		element.ElementType = acousticFieldType
 		return element, nil
+	case DateTime:
+		element := DateTimeElement{}
+		element.ElementType = acousticFieldType
+		return element, nil
 	case Category:
 		element := CategoryElement{}
 		element.ElementType = acousticFieldType
@@ -503,6 +531,10 @@ func Build(fieldType string) (Element, error) {
 		element := FileElement{}
 		element.ElementType = acousticFieldType
 		return element, nil
+	case Video:
+		element := VideoElement{}
+		element.ElementType = acousticFieldType
+		return element, nil
 	case Group:
 		element := GroupElement{}
 		element.ElementType = acousticFieldType
@@ -532,9 +564,26 @@ func Build(fieldType string) (Element, error) {
 	}
 }
 
+var fieldTypes = []FieldType{Text, MultiText, FormattedText, Number, MultiNumber, Float, Boolean, Link, Date, DateTime, Category, CategoryPart,
+	File, Video, Image, MultiImage, Group, MultiGroup, Reference, MultiReference, OptionSelection, MultiOptionSelection}
+
+// SupportedFieldTypes returns the property types which can be used in a field mapping , the types with an element to build
+func SupportedFieldTypes() []FieldType {
+	supportedFieldTypes := make([]FieldType, 0, len(fieldTypes))
+	for _, fieldType := range fieldTypes {
+		if _, err := Build(string(fieldType)); err == nil {
+			supportedFieldTypes = append(supportedFieldTypes, fieldType)
+		}
+	}
+	return supportedFieldTypes
+}
+
 type FeedType string
 
 const (
 	CSV      FeedType = "CSV"
 	ACOUSTIC FeedType = "Acoustic"
+	JSON     FeedType = "JSON"
+	JSONL    FeedType = "JSONL"
+	XLSX     FeedType = "XLSX"
 )
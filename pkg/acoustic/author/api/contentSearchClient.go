@@ -25,6 +25,7 @@ type Document struct {
 	Name     string                 `json:"name"`
 	Status   string                 `json:"status"`
 	Elements map[string]interface{} `json:"elements"`
+	Created  AcousticTime           `json:"created"`
 }
 
 type SearchRequest struct {
@@ -10,12 +10,15 @@ import (
 
 type ContentService interface {
 	CreateOrUpdateContentWithRetry(record AcousticDataRecord, contentType string) (*ContentAutheringResponse, error)
+	UpdateContentElementsWithRetry(contentID string, record AcousticDataRecord) (*ContentAutheringResponse, error)
 }
 
 type contentService struct {
 	acousticAuthApiUrl string
 	acousticContentLib string
 	contentClient      ContentClient
+	// the contents created in the run , found before the search finds them
+	created *createdContents
 }
 
 func NewContentService(acousticAuthApiUrl string, acousticContentLib string) ContentService {
@@ -23,6 +26,7 @@ func NewContentService(acousticAuthApiUrl string, acousticContentLib string) Con
 		acousticAuthApiUrl: acousticAuthApiUrl,
 		acousticContentLib: acousticContentLib,
 		contentClient:      NewContentClient(acousticAuthApiUrl),
+		created:            newCreatedContents(),
 	}
 }
 
@@ -51,6 +55,30 @@ func (service *contentService) CreateOrUpdateContentWithRetry(record AcousticDat
 	return response, err
 }
 
+// UpdateContentElementsWithRetry updates the content with the elements of the record , the other elements of the content
+// are kept as they are
+func (service *contentService) UpdateContentElementsWithRetry(contentID string, record AcousticDataRecord) (*ContentAutheringResponse, error) {
+	response, err := service.updateElements(contentID, record)
+	if err != nil && errors.IsRetryableError(err) {
+		ticker := backoff.NewTicker(backoff.NewExponentialBackOff())
+		times := 1
+		for range ticker.C {
+			if times == 3 {
+				ticker.Stop()
+				return response, err
+			}
+			response, err = service.updateElements(contentID, record)
+			if err != nil && errors.IsRetryableError(err) {
+				times++
+				continue
+			}
+			ticker.Stop()
+			break
+		}
+	}
+	return response, err
+}
+
 func handlePreContentCreateFunctionsOnElement(element Element) (Element, error) {
 	if element.ChildElements() != nil {
 		childElements := element.ChildElements()
@@ -167,8 +195,23 @@ func handlePreContentUpdateFunctions(content Content) (Content, []PostContentUpd
 	return content, totalPostContentUpdateFuncs, nil
 }
 
-func (service *contentService) createOrUpdate(record AcousticDataRecord, contentType string) (*ContentAutheringResponse, error) {
-	acousticContentDataOut := koazee.StreamOf(record.Values).
+func buildContent(record AcousticDataRecord, contentType string, libraryID string) (Content, error) {
+	acousticContentData, err := buildElements(record.Values)
+	if err != nil {
+		return Content{}, err
+	}
+	return Content{
+		Name:      record.Name(),
+		TypeId:    contentType,
+		Status:    env.ContentStatus(),
+		LibraryID: libraryID,
+		Elements:  acousticContentData,
+		Tags:      record.Tags,
+	}, nil
+}
+
+func buildElements(values []GenericData) (map[string]interface{}, error) {
+	acousticContentDataOut := koazee.StreamOf(values).
 		Reduce(func(acc map[string]interface{}, columnData GenericData) (map[string]interface{}, error) {
 			if columnData.Ignore {
 				return acc, nil
@@ -196,26 +239,149 @@ func (service *contentService) createOrUpdate(record AcousticDataRecord, content
 	if err != nil {
 		return nil, err
 	}
-	acousticContentData := acousticContentDataOut.Val().(map[string]interface{})
-	content := Content{
-		Name:      record.Name(),
-		TypeId:    contentType,
-		Status:    env.ContentStatus(),
-		LibraryID: service.acousticContentLib,
-		Elements:  acousticContentData,
-		Tags:      record.Tags,
+	return acousticContentDataOut.Val().(map[string]interface{}), nil
+}
+
+func searchExistingContent(record AcousticDataRecord) (map[string]string, SearchResponse, error) {
+	query, err := record.SearchQuerytoGetTheContent()
+	if err != nil {
+		return nil, SearchResponse{}, err
 	}
-	if !record.Update && record.CreateNonExistingItems {
-		query, err := record.SearchQuerytoGetTheContent()
+	if searchResponse, found := record.KeyIndex.Lookup(record); found {
+		return query, searchResponse, nil
+	}
+	searchResponse, err := searchContents(record, query)
+	if err != nil {
+		return nil, SearchResponse{}, err
+	}
+	// a content missed by the index , like a content created after the index is loaded
+	record.KeyIndex.addFound(record, searchResponse.Documents)
+	return query, searchResponse, nil
+}
+
+// searchContents searches the contents of the record with the query , all the contents found are returned
+func searchContents(record AcousticDataRecord, query map[string]string) (SearchResponse, error) {
+	searchRequest := SearchRequest{
+		Terms:          query,
+		ContentTypes:   []string{record.SearchType},
+		Classification: "content",
+	}
+	searchResponse, err := NewSearchClient(env.AcousticAPIUrl()).Search(env.LibraryID(), record.SearchOnLibrary, record.SearchOnDeliveryAPI, searchRequest, Pagination{Start: 0, Rows: 1})
+	if err != nil {
+		return SearchResponse{}, err
+	}
+	if searchResponse.Count > len(searchResponse.Documents) && searchResponse.Count > 1 {
+		// the duplicate contents of the key are all needed by the duplicate policy
+		searchResponse, err = NewSearchClient(env.AcousticAPIUrl()).Search(env.LibraryID(), record.SearchOnLibrary, record.SearchOnDeliveryAPI, searchRequest, Pagination{Start: 0, Rows: searchResponse.Count})
 		if err != nil {
-			return nil, err
+			return SearchResponse{}, err
+		}
+	}
+	return searchResponse, nil
+}
+
+func noExistingItemsError(query map[string]string, record AcousticDataRecord) error {
+	queryStrings := ""
+	for key, query := range query {
+		queryStrings += key + ":" + query
+	}
+	return errors.ErrorMessageWithStack("No existing items found for query :" + queryStrings + " search type :" + record.SearchType)
+}
+
+func mergeContent(existingContent *Content, content Content) (Content, error) {
+	updatedContent := Content{}
+	copier.Copy(&updatedContent, existingContent)
+	for newContentElementKey, newElement := range content.Elements {
+		existingContentElement := updatedContent.Elements[newContentElementKey]
+		if existingContentElement == nil {
+			updatedContent.Elements[newContentElementKey] = newElement
+		} else {
+			existingElement, err := Convert(existingContentElement.(map[string]interface{}))
+			existingContent.Elements[newContentElementKey] = existingElement
+			if err != nil {
+				return Content{}, err
+			}
+			updatedElement, err := existingElement.Update(newElement.(Element))
+			if err != nil {
+				return Content{}, err
+			}
+			if updatedElement != nil {
+				updatedContent.Elements[newContentElementKey] = updatedElement
+			}
 		}
-		searchRequest := SearchRequest{
-			Terms:          query,
-			ContentTypes:   []string{record.SearchType},
-			Classification: "content",
+	}
+	return updatedContent, nil
+}
+
+func (service *contentService) updateElements(contentID string, record AcousticDataRecord) (*ContentAutheringResponse, error) {
+	elements, err := buildElements(record.Values)
+	if err != nil {
+		return nil, err
+	}
+	return service.updateContent(contentID, Content{Elements: elements})
+}
+
+// updateContent merges the elements of the content in to the existing content , the update is not sent when no element
+// changes
+func (service *contentService) updateContent(contentID string, content Content) (*ContentAutheringResponse, error) {
+	existingContent, err := service.contentClient.Get(contentID)
+	if err != nil {
+		return nil, err
+	}
+	existingElements, err := elementsOf(existingContent)
+	if err != nil {
+		return nil, err
+	}
+	updatedContent, err := mergeContent(existingContent, content)
+	if err != nil {
+		return nil, err
+	}
+	content, postUpdateContentFuncs, err := handlePreContentUpdateFunctions(updatedContent)
+	if err != nil {
+		return nil, err
+	}
+	changed, err := changedElements(existingElements, content)
+	if err != nil {
+		return nil, err
+	}
+	if len(changed) == 0 {
+		// the content is not written , so the revision is not bumped and the content is not published again
+		return &ContentAutheringResponse{
+			Id:     content.ID,
+			Rev:    content.REV,
+			Name:   content.Name,
+			TypeId: content.TypeId,
+			Action: CONTENT_SKIPPED_UNCHANGED,
+		}, nil
+	}
+	defer func() {
+		for _, postUpdateFunc := range postUpdateContentFuncs {
+			postUpdateFunc()
 		}
-		searchResponse, err := NewSearchClient(env.AcousticAPIUrl()).Search(env.LibraryID(), record.SearchOnLibrary, record.SearchOnDeliveryAPI, searchRequest, Pagination{Start: 0, Rows: 1})
+	}()
+	response, err := service.contentClient.Update(content)
+	if err != nil {
+		return nil, err
+	}
+	response.Action = CONTENT_UPDATED
+	response.ChangedElements = changed
+	return response, nil
+}
+
+func (service *contentService) createOrUpdate(record AcousticDataRecord, contentType string) (*ContentAutheringResponse, error) {
+	content, err := buildContent(record, contentType, service.acousticContentLib)
+	if err != nil {
+		return nil, err
+	}
+	if record.Update || record.CreateNonExistingItems {
+		if record.KeyIndex == nil {
+			record.KeyIndex = service.created.index(record)
+		}
+		unlock := service.created.lock(record)
+		defer unlock()
+	}
+	if !record.Update && record.CreateNonExistingItems {
+		_, searchResponse, err := searchExistingContent(record)
 		if err != nil {
 			return nil, err
 		}
@@ -228,76 +394,51 @@ func (service *contentService) createOrUpdate(record AcousticDataRecord, content
 			if createErr != nil {
 				return nil, createErr
 			} else {
+				record.KeyIndex.Add(record, response.Id)
+				waitUntilSearchable(record, response.Id)
+				response.Action = CONTENT_CREATED
 				return response, nil
 			}
 		} else {
-			return nil, nil
+			// the existing content is left as is , the ID is returned so the record is tracked like the other records
+			return &ContentAutheringResponse{
+				Id:     searchResponse.Documents[0].Document.ID,
+				Name:   content.Name,
+				TypeId: contentType,
+				Action: CONTENT_SKIPPED,
+			}, nil
 		}
 	}
 	if record.Update {
-		query, err := record.SearchQuerytoGetTheContent()
-		if err != nil {
-			return nil, err
-		}
-		searchRequest := SearchRequest{
-			Terms:          query,
-			ContentTypes:   []string{record.SearchType},
-			Classification: "content",
-		}
-		searchResponse, err := NewSearchClient(env.AcousticAPIUrl()).Search(env.LibraryID(), record.SearchOnLibrary, record.SearchOnDeliveryAPI, searchRequest, Pagination{Start: 0, Rows: 1})
+		query, searchResponse, err := searchExistingContent(record)
 		if err != nil {
 			return nil, err
 		}
 		if searchResponse.Count > 0 {
-			contentId := searchResponse.Documents[0].Document.ID
-			existingContent, err := service.contentClient.Get(contentId)
-			updatedContent := Content{}
-			copier.Copy(&updatedContent, &existingContent)
+			contentIDs, candidates, err := matchedContents(record, searchResponse)
 			if err != nil {
 				return nil, err
 			}
-			for newContentElementKey, newElement := range content.Elements {
-				existingContentElement := updatedContent.Elements[newContentElementKey]
-				if existingContentElement == nil {
-					updatedContent.Elements[newContentElementKey] = newElement
-				} else {
-					existingElement, err := Convert(existingContentElement.(map[string]interface{}))
-					existingContent.Elements[newContentElementKey] = existingElement
-					if err != nil {
-						return nil, err
-					}
-					updatedElement, err := existingElement.Update(newElement.(Element))
-					if err != nil {
-						return nil, err
-					}
-					if updatedElement != nil {
-						updatedContent.Elements[newContentElementKey] = updatedElement
-					}
+			var response *ContentAutheringResponse
+			updatedIDs := make([]string, 0, len(contentIDs))
+			for _, contentID := range contentIDs {
+				contentResponse, err := service.updateContent(contentID, content)
+				if err != nil {
+					return nil, err
 				}
-			}
-			content, postUpdateContentFuncs, err := handlePreContentUpdateFunctions(updatedContent)
-			defer func() {
-				for _, postUpdateFunc := range postUpdateContentFuncs {
-					postUpdateFunc()
+				if contentResponse.Action == CONTENT_UPDATED {
+					updatedIDs = append(updatedIDs, contentID)
+				}
+				if response == nil || (response.Action == CONTENT_SKIPPED_UNCHANGED && contentResponse.Action == CONTENT_UPDATED) {
+					response = contentResponse
 				}
-			}()
-			if err != nil {
-				return nil, err
-			}
-			response, udpateError := service.contentClient.Update(content)
-			if udpateError != nil {
-				return nil, udpateError
-			} else {
-				return response, nil
 			}
-
+			response.Candidates = candidates
+			response.UpdatedIDs = updatedIDs
+			return response, nil
 		} else {
 			if !record.CreateNonExistingItems {
-				queryStrings := ""
-				for key, query := range query {
-					queryStrings += key + ":" + query
-				}
-				return nil, errors.ErrorMessageWithStack("No existing items found for query :" + queryStrings + " search type :" + record.SearchType)
+				return nil, noExistingItemsError(query, record)
 			}
 		}
 	}
@@ -309,6 +450,9 @@ func (service *contentService) createOrUpdate(record AcousticDataRecord, content
 	if createErr != nil {
 		return nil, createErr
 	} else {
+		record.KeyIndex.Add(record, response.Id)
+		waitUntilSearchable(record, response.Id)
+		response.Action = CONTENT_CREATED
 		return response, nil
 	}
 }
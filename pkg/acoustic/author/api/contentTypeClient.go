@@ -0,0 +1,93 @@
+package api
+
+import (
+	"encoding/json"
+	"github.com/dekanayake/acoustic-content-sync/pkg/errors"
+	"gopkg.in/resty.v1"
+)
+
+type ContentTypeDefinition struct {
+	ID       string               `json:"id"`
+	Name     string               `json:"name"`
+	Elements []ContentTypeElement `json:"elements"`
+}
+
+type ContentTypeElement struct {
+	Key                 string                     `json:"key"`
+	Label               string                     `json:"label"`
+	ElementType         string                     `json:"elementType"`
+	FieldType           string                     `json:"fieldType,omitempty"`
+	Required            bool                       `json:"required"`
+	AllowMultipleValues bool                       `json:"allowMultipleValues"`
+	Options             []ContentTypeElementOption `json:"options,omitempty"`
+	TypeRef             *ContentTypeRef            `json:"typeRef,omitempty"`
+	RestrictTypes       []ContentTypeRef           `json:"restrictTypes,omitempty"`
+}
+
+type ContentTypeElementOption struct {
+	Label     string `json:"label"`
+	Selection string `json:"selection"`
+}
+
+type ContentTypeRef struct {
+	ID string `json:"id"`
+}
+
+func (definition ContentTypeDefinition) Element(key string) (ContentTypeElement, bool) {
+	for _, element := range definition.Elements {
+		if element.Key == key {
+			return element, true
+		}
+	}
+	return ContentTypeElement{}, false
+}
+
+func (element ContentTypeElement) HasOption(selection string) bool {
+	for _, option := range element.Options {
+		if option.Selection == selection {
+			return true
+		}
+	}
+	return false
+}
+
+type ContentTypeClient interface {
+	Get(id string) (ContentTypeDefinition, error)
+}
+
+type contentTypeClient struct {
+	c              *resty.Client
+	acousticApiUrl string
+}
+
+func NewContentTypeClient(acousticApiUrl string) ContentTypeClient {
+	return NewContentTypeClientWithRestClient(Connect(), acousticApiUrl)
+}
+
+// NewContentTypeClientWithRestClient returns a content type client sending the requests with the given rest client ,
+// instead of the shared acoustic connection
+func NewContentTypeClientWithRestClient(c *resty.Client, acousticApiUrl string) ContentTypeClient {
+	return &contentTypeClient{
+		c:              c,
+		acousticApiUrl: acousticApiUrl,
+	}
+}
+
+func (contentTypeClient contentTypeClient) Get(id string) (ContentTypeDefinition, error) {
+	req := contentTypeClient.c.NewRequest().
+		SetResult(&ContentTypeDefinition{}).
+		SetError(&ContentAuthoringErrorResponse{})
+	if resp, err := req.Get(contentTypeClient.acousticApiUrl + "/authoring/v1/types/" + id); err != nil {
+		return ContentTypeDefinition{}, errors.ErrorWithStack(err)
+	} else if resp.IsSuccess() {
+		return *resp.Result().(*ContentTypeDefinition), nil
+	} else if resp.IsError() && resp.StatusCode() == 404 {
+		return ContentTypeDefinition{}, errors.ErrorMessageWithStack("content type not found :" + id)
+	} else if resp.IsError() && resp.StatusCode() == 400 {
+		error := resp.Error()
+		errorString, _ := json.MarshalIndent(error, "", "\t")
+		return ContentTypeDefinition{}, errors.ErrorMessageWithStack("error in getting content type : " + resp.Status() + "  " + string(errorString))
+	} else {
+		return ContentTypeDefinition{}, errors.ErrorMessageWithStack("error in getting content type : " + resp.Status())
+	}
+}
@@ -0,0 +1,50 @@
+package api
+
+import (
+	"gopkg.in/resty.v1"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestContentTypeClientGet(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/authoring/v1/types/product":
+			w.Write([]byte(`{"id":"product","name":"Product","elements":[{"key":"sizes","elementType":"text","allowMultipleValues":true,"required":true},{"key":"colour","elementType":"optionselection","options":[{"label":"Red","selection":"red"}]}]}`))
+		case "/authoring/v1/types/missing":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{}`))
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{}`))
+		}
+	}))
+	defer server.Close()
+	client := NewContentTypeClientWithRestClient(resty.New(), server.URL)
+
+	definition, err := client.Get("product")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sizes, ok := definition.Element("sizes")
+	if !ok || sizes.ElementType != "text" || !sizes.AllowMultipleValues || !sizes.Required {
+		t.Errorf("unexpected element %+v", sizes)
+	}
+	colour, _ := definition.Element("colour")
+	if !colour.HasOption("red") || colour.HasOption("blue") {
+		t.Errorf("unexpected options %+v", colour.Options)
+	}
+	if _, ok := definition.Element("price"); ok {
+		t.Error("expected no element price")
+	}
+
+	if _, err := client.Get("missing"); err == nil || !strings.Contains(err.Error(), "content type not found :missing") {
+		t.Errorf("expected the not found error , got %v", err)
+	}
+	if _, err := client.Get("broken"); err == nil || !strings.Contains(err.Error(), "500") {
+		t.Errorf("expected the status in the error , got %v", err)
+	}
+}
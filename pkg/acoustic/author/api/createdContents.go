@@ -0,0 +1,91 @@
+package api
+
+import (
+	"github.com/dekanayake/acoustic-content-sync/pkg/env"
+	log "github.com/sirupsen/logrus"
+	"hash/fnv"
+	"sync"
+	"time"
+)
+
+// the records sharing a lock are searched and created one after the other
+const createdContentLocks = 64
+
+// createdContents is the contents created by a content service in the run , by the search type and the values of the
+// search keys of the records. A record of a key created in the run updates or skips the created content , as the search
+// does not find a content until it is indexed
+type createdContents struct {
+	mux     sync.Mutex
+	indexes map[string]*KeyIndex
+	locks   [createdContentLocks]sync.Mutex
+}
+
+func newCreatedContents() *createdContents {
+	return &createdContents{
+		indexes: make(map[string]*KeyIndex),
+	}
+}
+
+// index is the contents of the search type of the record created in the run , nil when the record has no search keys
+func (created *createdContents) index(record AcousticDataRecord) *KeyIndex {
+	if len(record.SearchKeys) == 0 {
+		return nil
+	}
+	created.mux.Lock()
+	defer created.mux.Unlock()
+	index, ok := created.indexes[record.SearchType]
+	if !ok {
+		index = newKeyIndex(record.SearchType, record.SearchKeys)
+		created.indexes[record.SearchType] = index
+	}
+	return index
+}
+
+// lock keeps the records of the same key from searching and creating the content at the same time with the workers ,
+// the returned function releases the lock
+func (created *createdContents) lock(record AcousticDataRecord) func() {
+	index := created.index(record)
+	if index == nil {
+		return func() {}
+	}
+	key, ok := index.recordKey(record)
+	if !ok {
+		return func() {}
+	}
+	hash := fnv.New32a()
+	hash.Write([]byte(record.SearchType + ":" + key))
+	lock := &created.locks[hash.Sum32()%createdContentLocks]
+	lock.Lock()
+	return lock.Unlock
+}
+
+// waitUntilSearchable polls the search until the content created for the record is found , so a run right after the
+// run finds the content. A content not found before the timeout is logged , the content is created
+func waitUntilSearchable(record AcousticDataRecord, contentID string) {
+	timeout := env.SearchWaitTimeout()
+	if timeout <= 0 || (!record.Update && !record.CreateNonExistingItems) {
+		return
+	}
+	query, err := record.SearchQuerytoGetTheContent()
+	if err != nil {
+		return
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		searchResponse, err := searchContents(record, query)
+		if err != nil {
+			log.WithField(record.CSVRecordKey, record.CSVRecordKeyValue()).WithError(err).Warn("Failed in searching the created content , not waiting for the search")
+			return
+		}
+		for _, document := range searchResponse.Documents {
+			if document.Document.ID == contentID {
+				return
+			}
+		}
+		if time.Now().After(deadline) {
+			log.WithField(record.CSVRecordKey, record.CSVRecordKeyValue()).WithField("id", contentID).Warn("The created content is not searchable after " + timeout.String())
+			return
+		}
+		time.Sleep(env.SearchWaitInterval())
+	}
+}
@@ -0,0 +1,297 @@
+package api
+
+import (
+	"encoding/json"
+	"github.com/dekanayake/acoustic-content-sync/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type DryRunAsset struct {
+	Element      string `json:"element"`
+	Source       string `json:"source"`
+	AcousticPath string `json:"acousticPath"`
+}
+
+type DryRunResult struct {
+	CSVRecordKey      string        `json:"csvRecordKey"`
+	CSVRecordKeyValue string        `json:"csvRecordKeyValue"`
+	Action            ContentAction `json:"action"`
+	ExistingContentID string        `json:"existingContentId,omitempty"`
+	ChangedElements   []string      `json:"changedElements,omitempty"`
+	// the contents found for the key of the record when there are more than one , and the contents the duplicate policy
+	// would update
+	CandidateContentIDs []string      `json:"candidateContentIds,omitempty"`
+	UpdatedContentIDs   []string      `json:"updatedContentIds,omitempty"`
+	Content             Content       `json:"content"`
+	Assets              []DryRunAsset `json:"assets,omitempty"`
+}
+
+// DRY_RUN_CONTENT_ID_PREFIX is the prefix of the ID handed out for the content a dry run would create , the ID is the
+// prefix followed by the record key value
+const DRY_RUN_CONTENT_ID_PREFIX = "dry-run:"
+
+type dryRunContentService struct {
+	acousticAuthApiUrl string
+	acousticContentLib string
+	outputLocation     string
+	contentClient      ContentClient
+	mux                *sync.Mutex
+	writtenFiles       map[string]int
+	// the contents the run would create , found by the records of the same key
+	created *createdContents
+}
+
+// NewDryRunContentService returns a content service which runs the full mapping pipeline but, instead of
+// calling the create or update APIs, writes the would be content of each record as a json file in to the output location.
+// Search , category and existing content reads are still done against Acoustic
+func NewDryRunContentService(acousticAuthApiUrl string, acousticContentLib string, outputLocation string) ContentService {
+	return &dryRunContentService{
+		acousticAuthApiUrl: acousticAuthApiUrl,
+		acousticContentLib: acousticContentLib,
+		outputLocation:     outputLocation,
+		contentClient:      NewContentClient(acousticAuthApiUrl),
+		mux:                &sync.Mutex{},
+		writtenFiles:       make(map[string]int),
+		created:            newCreatedContents(),
+	}
+}
+
+func (service *dryRunContentService) CreateOrUpdateContentWithRetry(record AcousticDataRecord, contentType string) (*ContentAutheringResponse, error) {
+	content, err := buildContent(record, contentType, service.acousticContentLib)
+	if err != nil {
+		return nil, err
+	}
+	result := DryRunResult{
+		CSVRecordKey:      record.CSVRecordKey,
+		CSVRecordKeyValue: record.CSVRecordKeyValue(),
+		Action:            CONTENT_CREATED,
+		Content:           content,
+		Assets:            plannedAssets("", record.Values),
+	}
+	if record.Update || record.CreateNonExistingItems {
+		if record.KeyIndex == nil {
+			record.KeyIndex = service.created.index(record)
+		}
+		unlock := service.created.lock(record)
+		defer unlock()
+	}
+	if !record.Update && record.CreateNonExistingItems {
+		_, searchResponse, err := searchExistingContent(record)
+		if err != nil {
+			return nil, err
+		}
+		if searchResponse.Count > 0 {
+			result.Action = CONTENT_SKIPPED
+			result.ExistingContentID = searchResponse.Documents[0].Document.ID
+		}
+	} else if record.Update {
+		query, searchResponse, err := searchExistingContent(record)
+		if err != nil {
+			return nil, err
+		}
+		if searchResponse.Count > 0 {
+			contentIDs, candidates, err := matchedContents(record, searchResponse)
+			if err != nil {
+				return nil, err
+			}
+			if len(candidates) > 0 {
+				result.CandidateContentIDs = candidates
+				result.UpdatedContentIDs = contentIDs
+			}
+			if strings.HasPrefix(contentIDs[0], DRY_RUN_CONTENT_ID_PREFIX) {
+				// the content is created by a record of the run , it is not in acoustic to compare with
+				result.Action = CONTENT_UPDATED
+				result.ExistingContentID = contentIDs[0]
+			} else {
+				// the contents updated by the duplicate policy are written with the changes of the first content
+				existingContent, err := service.contentClient.Get(contentIDs[0])
+				if err != nil {
+					return nil, err
+				}
+				existingElements, err := elementsOf(existingContent)
+				if err != nil {
+					return nil, err
+				}
+				updatedContent, err := mergeContent(existingContent, content)
+				if err != nil {
+					return nil, err
+				}
+				// the assets are not uploaded in a dry run , a changed asset source is compared with the existing asset as is
+				changed, err := changedElements(existingElements, updatedContent)
+				if err != nil {
+					return nil, err
+				}
+				result.Action = CONTENT_UPDATED
+				if len(changed) == 0 {
+					result.Action = CONTENT_SKIPPED_UNCHANGED
+				}
+				result.ChangedElements = changed
+				result.ExistingContentID = existingContent.ID
+				result.Content = updatedContent
+			}
+		} else if !record.CreateNonExistingItems {
+			return nil, noExistingItemsError(query, record)
+		}
+	}
+	if err := service.write(result); err != nil {
+		return nil, err
+	}
+	log.WithField(record.CSVRecordKey, result.CSVRecordKeyValue).WithField("action", result.Action).Info("Dry run , content not sent to acoustic")
+	contentID := result.ExistingContentID
+	if result.Action == CONTENT_CREATED {
+		contentID = dryRunContentID(result, contentType)
+		record.KeyIndex.Add(record, contentID)
+	}
+	return &ContentAutheringResponse{
+		Id:              contentID,
+		Name:            content.Name,
+		TypeId:          contentType,
+		Action:          result.Action,
+		ChangedElements: result.ChangedElements,
+		Candidates:      result.CandidateContentIDs,
+		UpdatedIDs:      result.UpdatedContentIDs,
+	}, nil
+}
+
+// dryRunContentID is the placeholder ID of a content the dry run would create , so the references to the content show the
+// linked content in the dry run output. A referenced content created with the record has no record key , it is named by
+// the content type and the name of the content
+func dryRunContentID(result DryRunResult, contentType string) string {
+	if result.CSVRecordKeyValue != "" {
+		return DRY_RUN_CONTENT_ID_PREFIX + result.CSVRecordKeyValue
+	}
+	return DRY_RUN_CONTENT_ID_PREFIX + "<would create " + contentType + " : " + result.Content.Name + ">"
+}
+
+// UpdateContentElementsWithRetry writes the elements the update would set , the content a dry run would create is not
+// read from acoustic
+func (service *dryRunContentService) UpdateContentElementsWithRetry(contentID string, record AcousticDataRecord) (*ContentAutheringResponse, error) {
+	elements, err := buildElements(record.Values)
+	if err != nil {
+		return nil, err
+	}
+	result := DryRunResult{
+		CSVRecordKey:      record.CSVRecordKey,
+		CSVRecordKeyValue: record.CSVRecordKeyValue() + "_links",
+		Action:            CONTENT_UPDATED,
+		ExistingContentID: contentID,
+		Content:           Content{ID: contentID, Elements: elements},
+	}
+	if !strings.HasPrefix(contentID, DRY_RUN_CONTENT_ID_PREFIX) {
+		existingContent, err := service.contentClient.Get(contentID)
+		if err != nil {
+			return nil, err
+		}
+		existingElements, err := elementsOf(existingContent)
+		if err != nil {
+			return nil, err
+		}
+		updatedContent, err := mergeContent(existingContent, Content{Elements: elements})
+		if err != nil {
+			return nil, err
+		}
+		changed, err := changedElements(existingElements, updatedContent)
+		if err != nil {
+			return nil, err
+		}
+		if len(changed) == 0 {
+			result.Action = CONTENT_SKIPPED_UNCHANGED
+		}
+		result.ChangedElements = changed
+		result.Content = updatedContent
+	}
+	if err := service.write(result); err != nil {
+		return nil, err
+	}
+	log.WithField(record.CSVRecordKey, record.CSVRecordKeyValue()).WithField("action", result.Action).Info("Dry run , links not sent to acoustic")
+	return &ContentAutheringResponse{
+		Id:              contentID,
+		Name:            result.Content.Name,
+		TypeId:          result.Content.TypeId,
+		Action:          result.Action,
+		ChangedElements: result.ChangedElements,
+	}, nil
+}
+
+var dryRunFileNameRegx = regexp.MustCompile(`[^\w.-]+`)
+var dryRunAssetQueryRegx = regexp.MustCompile("(\\?){1}.*")
+
+func (service *dryRunContentService) write(result DryRunResult) error {
+	if err := os.MkdirAll(service.outputLocation, 0755); err != nil {
+		return errors.ErrorWithStack(err)
+	}
+	fileName := dryRunFileNameRegx.ReplaceAllString(result.CSVRecordKeyValue, "_")
+	if fileName == "" {
+		fileName = dryRunFileNameRegx.ReplaceAllString(result.Content.Name, "_")
+	}
+	if fileName == "" {
+		fileName = "record"
+	}
+	service.mux.Lock()
+	service.writtenFiles[fileName]++
+	if count := service.writtenFiles[fileName]; count > 1 {
+		fileName = fileName + "_" + strconv.Itoa(count)
+	}
+	service.mux.Unlock()
+	resultJson, err := json.MarshalIndent(result, "", "\t")
+	if err != nil {
+		return errors.ErrorWithStack(err)
+	}
+	if err := os.WriteFile(filepath.Join(service.outputLocation, fileName+".json"), resultJson, 0644); err != nil {
+		return errors.ErrorWithStack(err)
+	}
+	return nil
+}
+
+func plannedAsset(elementName string, asset AcousticFileAsset) DryRunAsset {
+	source := asset.Value
+	if !asset.IsWebUrl {
+		source = asset.AssetLocation + "/" + asset.Value
+	}
+	assetName, err := getAssetName(asset)
+	if err != nil {
+		assetName = asset.Value
+	}
+	assetExtension := dryRunAssetQueryRegx.ReplaceAllString(filepath.Ext(asset.Value), "")
+	return DryRunAsset{
+		Element:      elementName,
+		Source:       source,
+		AcousticPath: asset.AcousticAssetBasePath + "/" + assetName + assetExtension,
+	}
+}
+
+func plannedAssets(parentName string, values []GenericData) []DryRunAsset {
+	assets := make([]DryRunAsset, 0)
+	for _, data := range values {
+		if data.Ignore || data.Value == nil {
+			continue
+		}
+		elementName := data.Name
+		if parentName != "" {
+			elementName = parentName + "." + data.Name
+		}
+		switch value := data.Value.(type) {
+		case AcousticFileAsset:
+			assets = append(assets, plannedAsset(elementName, value))
+		case AcousticImageAsset:
+			assets = append(assets, plannedAsset(elementName, value.AcousticFileAsset))
+		case AcousticMultiImageAsset:
+			for _, imageAsset := range value.Assets {
+				assets = append(assets, plannedAsset(elementName, imageAsset.AcousticFileAsset))
+			}
+		case AcousticGroup:
+			assets = append(assets, plannedAssets(elementName, value.Data)...)
+		case AcousticMultiGroup:
+			for _, groupData := range value.Data {
+				assets = append(assets, plannedAssets(elementName, groupData)...)
+			}
+		}
+	}
+	return assets
+}
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func setDryRunEnv(t *testing.T) string {
+	outputLocation := t.TempDir()
+	t.Setenv("DryRun", "true")
+	t.Setenv("DryRunOutputLocation", outputLocation)
+	t.Setenv("AcousticAuthURL", "http://localhost:1")
+	t.Setenv("AcousticAPIKey", "key")
+	t.Setenv("LibraryID", "library")
+	t.Setenv("ContentStatus", "draft")
+	t.Setenv("AlwaysCreateNewAcousticRestAPIConnection", "false")
+	return outputLocation
+}
+
+func TestDryRunReferenceCreatedWithRecordHasPlaceholderID(t *testing.T) {
+	outputLocation := setDryRunEnv(t)
+	reference := GenericData{
+		Name: "brand",
+		Type: "reference",
+		Value: AcousticReference{
+			Type:       "brand",
+			AlwaysNew:  true,
+			NameFields: []string{"title"},
+			Data: []GenericData{
+				{Name: "title", Type: "text", Value: AcousticValue{Value: "Acme"}},
+			},
+		},
+	}
+	element, err := ReferenceElement{}.Convert(reference)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := element.(ReferenceElement).Value.ID
+	if id != DRY_RUN_CONTENT_ID_PREFIX+"<would create brand : Acme>" {
+		t.Errorf("unexpected reference ID %q", id)
+	}
+	multiElement, err := MultiReferenceElement{}.Convert(GenericData{
+		Name:  "brands",
+		Type:  "reference",
+		Value: AcousticMultiReference{References: []AcousticReference{reference.Value.(AcousticReference)}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := multiElement.(MultiReferenceElement).Values; len(ids) != 1 || ids[0].ID != id {
+		t.Errorf("unexpected multi reference IDs %v", ids)
+	}
+	written, err := filepath.Glob(filepath.Join(outputLocation, "references", "*.json"))
+	if err != nil || len(written) != 2 || filepath.Base(written[0]) != "Acme.json" {
+		t.Fatalf("expected the dry run output of the referenced contents , found %v", written)
+	}
+	content, err := ioutil.ReadFile(written[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := DryRunResult{}
+	if err := json.Unmarshal(content, &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Action != CONTENT_CREATED || result.Content.Name != "Acme" {
+		t.Errorf("unexpected dry run result %+v", result)
+	}
+}
+
+func TestDryRunContentID(t *testing.T) {
+	tests := []struct {
+		name     string
+		result   DryRunResult
+		expected string
+	}{
+		{"record key", DryRunResult{CSVRecordKeyValue: "SKU-1", Content: Content{Name: "Shoe"}}, "dry-run:SKU-1"},
+		{"referenced content", DryRunResult{Content: Content{Name: "Acme"}}, "dry-run:<would create brand : Acme>"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if id := dryRunContentID(test.result, "brand"); id != test.expected {
+				t.Errorf("expected %q , got %q", test.expected, id)
+			}
+		})
+	}
+}
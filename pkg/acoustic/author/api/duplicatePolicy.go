@@ -0,0 +1,99 @@
+package api
+
+import (
+	stderrors "errors"
+	"github.com/dekanayake/acoustic-content-sync/pkg/errors"
+	"sort"
+	"strings"
+)
+
+// DuplicatePolicy picks the contents updated by a record when more than a content is found for the search keys of the
+// record
+type DuplicatePolicy string
+
+const (
+	// the first content found is updated , the policy when no policy is set
+	DUPLICATE_UPDATE_FIRST  DuplicatePolicy = "updateFirst"
+	DUPLICATE_FAIL          DuplicatePolicy = "fail"
+	DUPLICATE_UPDATE_ALL    DuplicatePolicy = "updateAll"
+	DUPLICATE_UPDATE_NEWEST DuplicatePolicy = "updateNewest"
+	DUPLICATE_UPDATE_OLDEST DuplicatePolicy = "updateOldest"
+)
+
+// the duplicates retired by a dedupe are not candidates of the update , unless all the contents found are retired
+const retiredStatus = "retired"
+
+var DuplicatePolicies = []DuplicatePolicy{DUPLICATE_UPDATE_FIRST, DUPLICATE_FAIL, DUPLICATE_UPDATE_ALL, DUPLICATE_UPDATE_NEWEST, DUPLICATE_UPDATE_OLDEST}
+
+// DuplicateContentError is the error of a record with more than a content found for the search keys , when the
+// duplicate policy fails the record
+type DuplicateContentError struct {
+	Key        string
+	Candidates []string
+}
+
+func (err *DuplicateContentError) Error() string {
+	return "Duplicate contents found for the key " + err.Key + " : " + strings.Join(err.Candidates, ", ")
+}
+
+// DuplicateCandidates gives the IDs of the contents found for the key of a record failed by the duplicate policy
+func DuplicateCandidates(err error) ([]string, bool) {
+	var duplicateErr *DuplicateContentError
+	if stderrors.As(err, &duplicateErr) {
+		return duplicateErr.Candidates, true
+	}
+	return nil, false
+}
+
+// SortByCreated sorts the documents from the oldest to the newest
+func SortByCreated(documents []Document) {
+	sort.SliceStable(documents, func(i, j int) bool {
+		return documents[i].Created.Before(documents[j].Created.Time)
+	})
+}
+
+func documentIDs(documents []Document) []string {
+	ids := make([]string, 0, len(documents))
+	for _, document := range documents {
+		ids = append(ids, document.ID)
+	}
+	return ids
+}
+
+// matchedContents is the IDs of the contents updated by the record with the duplicate policy of the record , the
+// candidates are the IDs of all the contents found when there are more than one
+func matchedContents(record AcousticDataRecord, searchResponse SearchResponse) ([]string, []string, error) {
+	documents := make([]Document, 0, len(searchResponse.Documents))
+	for _, document := range searchResponse.Documents {
+		if document.Document.Status != retiredStatus {
+			documents = append(documents, document.Document)
+		}
+	}
+	if len(documents) == 0 {
+		for _, document := range searchResponse.Documents {
+			documents = append(documents, document.Document)
+		}
+	}
+	if len(documents) <= 1 {
+		return documentIDs(documents), nil, nil
+	}
+	candidates := documentIDs(documents)
+	switch record.DuplicatePolicy {
+	case DUPLICATE_FAIL:
+		key := make([]string, 0, len(record.SearchKeys))
+		for _, searchKey := range record.SearchKeys {
+			key = append(key, record.SearchValues[searchKey])
+		}
+		return nil, candidates, errors.ErrorWithStack(&DuplicateContentError{Key: strings.Join(key, ", "), Candidates: candidates})
+	case DUPLICATE_UPDATE_ALL:
+		return candidates, candidates, nil
+	case DUPLICATE_UPDATE_NEWEST:
+		SortByCreated(documents)
+		return []string{documents[len(documents)-1].ID}, candidates, nil
+	case DUPLICATE_UPDATE_OLDEST:
+		SortByCreated(documents)
+		return []string{documents[0].ID}, candidates, nil
+	default:
+		return candidates[:1], candidates, nil
+	}
+}
@@ -0,0 +1,123 @@
+package api
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func duplicateDocument(id string, status string, created string) DocumentItem {
+	createdTime, err := time.Parse(time.RFC3339, created)
+	if err != nil {
+		panic(err)
+	}
+	return DocumentItem{Document: Document{ID: id, Status: status, Created: AcousticTime{createdTime}}}
+}
+
+func TestMatchedContents(t *testing.T) {
+	documents := []DocumentItem{
+		duplicateDocument("middle", "ready", "2022-02-01T00:00:00Z"),
+		duplicateDocument("newest", "ready", "2022-03-01T00:00:00Z"),
+		duplicateDocument("oldest", "ready", "2022-01-01T00:00:00Z"),
+	}
+	tests := []struct {
+		name       string
+		policy     DuplicatePolicy
+		documents  []DocumentItem
+		matched    []string
+		candidates []string
+	}{
+		{
+			name:      "single content",
+			documents: documents[:1],
+			matched:   []string{"middle"},
+		},
+		{
+			name:       "first by default",
+			documents:  documents,
+			matched:    []string{"middle"},
+			candidates: []string{"middle", "newest", "oldest"},
+		},
+		{
+			name:       "all",
+			policy:     DUPLICATE_UPDATE_ALL,
+			documents:  documents,
+			matched:    []string{"middle", "newest", "oldest"},
+			candidates: []string{"middle", "newest", "oldest"},
+		},
+		{
+			name:       "newest by created",
+			policy:     DUPLICATE_UPDATE_NEWEST,
+			documents:  documents,
+			matched:    []string{"newest"},
+			candidates: []string{"middle", "newest", "oldest"},
+		},
+		{
+			name:       "oldest by created",
+			policy:     DUPLICATE_UPDATE_OLDEST,
+			documents:  documents,
+			matched:    []string{"oldest"},
+			candidates: []string{"middle", "newest", "oldest"},
+		},
+		{
+			name:   "retired duplicates left out",
+			policy: DUPLICATE_FAIL,
+			documents: []DocumentItem{
+				duplicateDocument("retired", retiredStatus, "2022-01-01T00:00:00Z"),
+				duplicateDocument("active", "ready", "2022-02-01T00:00:00Z"),
+			},
+			matched: []string{"active"},
+		},
+		{
+			name:   "all retired fall back to all the contents",
+			policy: DUPLICATE_UPDATE_NEWEST,
+			documents: []DocumentItem{
+				duplicateDocument("retired-newest", retiredStatus, "2022-02-01T00:00:00Z"),
+				duplicateDocument("retired-oldest", retiredStatus, "2022-01-01T00:00:00Z"),
+			},
+			matched:    []string{"retired-newest"},
+			candidates: []string{"retired-newest", "retired-oldest"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// the documents are sorted by the policy , each case gets its own copy
+			searchDocuments := append([]DocumentItem{}, test.documents...)
+			record := AcousticDataRecord{DuplicatePolicy: test.policy}
+			matched, candidates, err := matchedContents(record, SearchResponse{Count: len(searchDocuments), Documents: searchDocuments})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(matched, test.matched) {
+				t.Errorf("expected the matched contents %v , got %v", test.matched, matched)
+			}
+			if !reflect.DeepEqual(candidates, test.candidates) {
+				t.Errorf("expected the candidates %v , got %v", test.candidates, candidates)
+			}
+		})
+	}
+}
+
+func TestMatchedContentsFailsTheDuplicates(t *testing.T) {
+	record := AcousticDataRecord{
+		DuplicatePolicy: DUPLICATE_FAIL,
+		SearchKeys:      []string{"sku"},
+		SearchValues:    map[string]string{"sku": "A"},
+	}
+	searchResponse := SearchResponse{Count: 2, Documents: []DocumentItem{
+		duplicateDocument("first", "ready", "2022-01-01T00:00:00Z"),
+		duplicateDocument("second", "ready", "2022-02-01T00:00:00Z"),
+	}}
+	matched, _, err := matchedContents(record, searchResponse)
+	if err == nil || matched != nil {
+		t.Fatalf("expected the duplicates failed , got %v %v", matched, err)
+	}
+	candidates, ok := DuplicateCandidates(err)
+	if !ok || !reflect.DeepEqual(candidates, []string{"first", "second"}) {
+		t.Errorf("expected the candidates of the wrapped duplicate error , got %v %v", candidates, ok)
+	}
+	if _, ok := DuplicateCandidates(errors.New("other error")); ok {
+		t.Error("expected no candidates for an other error")
+	}
+}
@@ -45,7 +45,9 @@ func (element BooleanElement) ToCSV(childFields map[string]interface{}) (CSVValu
 }
 
 func (element DateElement) ToCSV(childFields map[string]interface{}) (CSVValues, error) {
-	return CSVValues{}, errors.ErrorMessageWithStack("to csv not implemented")
+	return CSVValues{
+		Value: element.Value,
+	}, nil
 }
 
 func (element CategoryElement) ToCSV(childFields map[string]interface{}) (CSVValues, error) {
@@ -87,6 +89,16 @@ func (element FileElement) ToCSV(childFields map[string]interface{}) (CSVValues,
 	return CSVValues{}, errors.ErrorMessageWithStack("to csv not implemented")
 }
 
+func (element VideoElement) ToCSV(childFields map[string]interface{}) (CSVValues, error) {
+	response, err := NewAssetClient(env.AcousticAPIUrl()).Get(element.Asset.ID)
+	if err != nil {
+		return CSVValues{}, errors.ErrorWithStack(err)
+	}
+	return CSVValues{
+		Value: env.AcousticDomain() + response.Path,
+	}, nil
+}
+
 func (element GroupElement) ToCSV(childFields map[string]interface{}) (CSVValues, error) {
 	csvValues := make(map[string]CSVValues, 0)
 	values := element.Value
@@ -138,7 +150,8 @@ func (m MultiNumberElement) ToCSV(childFields map[string]interface{}) (CSVValues
 	panic("implement me")
 }
 
-func (d DateTimeElement) ToCSV(childFields map[string]interface{}) (CSVValues, error) {
-	//TODO implement me
-	panic("implement me")
+func (element DateTimeElement) ToCSV(childFields map[string]interface{}) (CSVValues, error) {
+	return CSVValues{
+		Value: element.Value,
+	}, nil
 }
@@ -20,6 +20,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -51,6 +52,13 @@ type AcousticDataRecord struct {
 	FilterColumns      []string
 	FilterFileLocation string
 	SiteConfig         SiteConfig
+	// set when the row of the record is not processed , the record has only the record key value
+	SkipReason string
+	// the contents of the search type indexed by the search keys , the existing content is looked up in the index
+	// before it is searched
+	KeyIndex *KeyIndex
+	// the contents updated when more than a content is found for the search keys
+	DuplicatePolicy DuplicatePolicy
 }
 
 type GenericData struct {
@@ -90,6 +98,10 @@ type AcousticReference struct {
 	NameFields          []string
 	Tags                []string
 	Operation           Operation
+	// the record key of the referenced record , for a reference linked to a record of the run
+	RecordKey string
+	// the ID of the referenced content when it is known , the content is not searched
+	ID string
 }
 
 type AcousticMultiReference struct {
@@ -127,6 +139,14 @@ type AcousticValue struct {
 	LoadFromFile bool
 }
 
+// AcousticDateTime is a date or a date time value of the feed , parsed with the layouts in the time zone when the value
+// has no zone of its own
+type AcousticDateTime struct {
+	Value    string
+	Layouts  []string
+	TimeZone string
+}
+
 type AcousticMultiImageAsset struct {
 	Assets []AcousticImageAsset
 }
@@ -212,22 +232,21 @@ func (acousticDataRecord AcousticDataRecord) Name() string {
 		}).String()
 }
 
+// CSVRecordKeyValue is the value of the record key , empty for a record without a record key like the record of a
+// referenced content
 func (acousticDataRecord AcousticDataRecord) CSVRecordKeyValue() string {
-	return koazee.StreamOf(acousticDataRecord.Values).
-		Filter(func(columnValue GenericData) bool {
-			return columnValue.Name == acousticDataRecord.CSVRecordKey
-		}).Map(func(columnValue GenericData) string {
-		var value string
-		switch columnValue.Value.(type) {
-		default:
-			value = columnValue.Value.(string)
-		case string:
-			value = columnValue.Value.(string)
+	for _, columnValue := range acousticDataRecord.Values {
+		if columnValue.Name != acousticDataRecord.CSVRecordKey {
+			continue
+		}
+		switch value := columnValue.Value.(type) {
 		case AcousticValue:
-			value = columnValue.Value.(AcousticValue).Value
+			return value.Value
+		default:
+			return columnValue.Value.(string)
 		}
-		return value
-	}).First().String()
+	}
+	return ""
 }
 
 func (acousticDataRecord AcousticDataRecord) GetValue(columnName string) interface{} {
@@ -1058,6 +1077,102 @@ func (element FileElement) Convert(data interface{}) (Element, error) {
 	return element, nil
 }
 
+// DefaultDateTimeLayouts are the layouts a date time value is parsed with when the field mapping has no layouts
+var DefaultDateTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+const acousticDateTimeLayout = "2006-01-02T15:04:05Z"
+
+func parseDateTime(dateTime AcousticDateTime) (time.Time, error) {
+	location := time.UTC
+	if dateTime.TimeZone != "" {
+		timeZone, err := time.LoadLocation(dateTime.TimeZone)
+		if err != nil {
+			return time.Time{}, errors.ErrorWithStack(err)
+		}
+		location = timeZone
+	}
+	layouts := dateTime.Layouts
+	if len(layouts) == 0 {
+		layouts = DefaultDateTimeLayouts
+	}
+	value := strings.TrimSpace(dateTime.Value)
+	for _, layout := range layouts {
+		if parsed, err := time.ParseInLocation(layout, value, location); err == nil {
+			return parsed, nil
+		}
+	}
+	return time.Time{}, errors.ErrorMessageWithStack("value :" + value + " is not a date time of the layouts :" + strings.Join(layouts, " , "))
+}
+
+func (element DateElement) Convert(data interface{}) (Element, error) {
+	parsed, err := parseDateTime(data.(GenericData).Value.(AcousticDateTime))
+	if err != nil {
+		return nil, errors.ErrorWithStack(err)
+	}
+	// a date is the calendar day of the value in its time zone , stored as the midnight in UTC
+	element.Value = time.Date(parsed.Year(), parsed.Month(), parsed.Day(), 0, 0, 0, 0, time.UTC).Format(acousticDateTimeLayout)
+	return element, nil
+}
+
+func (element DateTimeElement) Convert(data interface{}) (Element, error) {
+	parsed, err := parseDateTime(data.(GenericData).Value.(AcousticDateTime))
+	if err != nil {
+		return nil, errors.ErrorWithStack(err)
+	}
+	element.Value = parsed.UTC().Format(acousticDateTimeLayout)
+	return element, nil
+}
+
+// Convert of a video uploads the video file as an asset the same way a file is uploaded
+func (element VideoElement) Convert(data interface{}) (Element, error) {
+	fileElement := FileElement{}
+	fileElement.ElementType = element.ElementType
+	convertedFileElement, err := fileElement.Convert(data)
+	if err != nil {
+		return nil, errors.ErrorWithStack(err)
+	}
+	videoElementOf := func(fileElement Element) Element {
+		if fileElement == nil {
+			return nil
+		}
+		element.Asset = fileElement.(FileElement).Asset
+		return element
+	}
+	createFunctions := make([]PreContentCreateFunc, 0)
+	for _, fileCreateFn := range convertedFileElement.PreContentCreateFunctions() {
+		fileCreateFn := fileCreateFn
+		createFunctions = append(createFunctions, func() (Element, error) {
+			createdFileElement, err := fileCreateFn()
+			if err != nil {
+				return nil, err
+			}
+			return videoElementOf(createdFileElement), nil
+		})
+	}
+	updateFunctions := make([]PreContentUpdateFunc, 0)
+	for _, fileUpdateFn := range convertedFileElement.PreContentUpdateFunctions() {
+		fileUpdateFn := fileUpdateFn
+		updateFunctions = append(updateFunctions, func(updatedElement Element) (Element, []PostContentUpdateFunc, error) {
+			updatedFileElement := FileElement{Asset: updatedElement.(VideoElement).Asset}
+			updatedFileElement.ElementType = element.ElementType
+			fileElement, postContentUpdateFuncs, err := fileUpdateFn(updatedFileElement)
+			if err != nil {
+				return nil, nil, err
+			}
+			return videoElementOf(fileElement), postContentUpdateFuncs, nil
+		})
+	}
+	element.PreContentCreateFunctionList = createFunctions
+	element.PreContentUpdateFunctionList = updateFunctions
+	return element, nil
+}
+
 func (element GroupElement) Convert(data interface{}) (Element, error) {
 	groupData := data.(GenericData)
 	groupValue := groupData.Value.(AcousticGroup)
@@ -1118,17 +1233,41 @@ func (element MultiGroupElement) Convert(data interface{}) (Element, error) {
 	return element, nil
 }
 
+// the dry run services of the referenced contents by the output location , shared by the references so the output of a
+// reference does not overwrite the output of another
+var referenceDryRunServices = struct {
+	mux      sync.Mutex
+	services map[string]ContentService
+}{services: make(map[string]ContentService)}
+
+func newReferenceContentService() ContentService {
+	if env.IsDryRunEnabled() {
+		outputLocation := env.DryRunOutputLocation() + "/references"
+		referenceDryRunServices.mux.Lock()
+		defer referenceDryRunServices.mux.Unlock()
+		service, ok := referenceDryRunServices.services[outputLocation]
+		if !ok {
+			service = NewDryRunContentService(env.AcousticAuthUrl(), env.LibraryID(), outputLocation)
+			referenceDryRunServices.services[outputLocation] = service
+		}
+		return service
+	}
+	return NewContentService(env.AcousticAuthUrl(), env.LibraryID())
+}
+
 func (element ReferenceElement) Convert(data interface{}) (Element, error) {
 	referenceData := data.(GenericData)
 	referenceValue := referenceData.Value.(AcousticReference)
 	value := ReferenceValue{}
-	if referenceValue.AlwaysNew {
+	if referenceValue.ID != "" {
+		value.ID = referenceValue.ID
+	} else if referenceValue.AlwaysNew {
 		acousticDataRecord := AcousticDataRecord{
 			Values:     referenceValue.Data,
 			NameFields: referenceValue.NameFields,
 			Tags:       referenceValue.Tags,
 		}
-		contentCreateResponse, err := NewContentService(env.AcousticAuthUrl(), env.LibraryID()).CreateOrUpdateContentWithRetry(acousticDataRecord, referenceValue.Type)
+		contentCreateResponse, err := newReferenceContentService().CreateOrUpdateContentWithRetry(acousticDataRecord, referenceValue.Type)
 		if err != nil {
 			return nil, errors.ErrorWithStack(err)
 		}
@@ -1164,13 +1303,15 @@ func (element MultiReferenceElement) Convert(data interface{}) (Element, error)
 	values := make([]ReferenceValue, 0)
 	for _, referenceValue := range acousticMultiReference.References {
 		value := ReferenceValue{}
-		if referenceValue.AlwaysNew {
+		if referenceValue.ID != "" {
+			value.ID = referenceValue.ID
+		} else if referenceValue.AlwaysNew {
 			acousticDataRecord := AcousticDataRecord{
 				Values:     referenceValue.Data,
 				NameFields: referenceValue.NameFields,
 				Tags:       referenceValue.Tags,
 			}
-			contentCreateResponse, err := NewContentService(env.AcousticAuthUrl(), env.LibraryID()).CreateOrUpdateContentWithRetry(acousticDataRecord, referenceValue.Type)
+			contentCreateResponse, err := newReferenceContentService().CreateOrUpdateContentWithRetry(acousticDataRecord, referenceValue.Type)
 			if err != nil {
 				return nil, errors.ErrorWithStack(err)
 			}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"fmt"
 	"github.com/dekanayake/acoustic-content-sync/pkg/errors"
 	"github.com/thoas/go-funk"
 	"golang.org/x/exp/slices"
@@ -40,7 +41,14 @@ func (element FormattedTextElement) Update(new Element) (Element, error) {
 }
 
 func (element DateElement) Update(new Element) (Element, error) {
-	return nil, errors.ErrorMessageWithStack("not implemented")
+	oldValue := element.Value
+	newValue := new.(DateElement).Value
+	if oldValue != newValue {
+		element.Value = newValue
+		return element, nil
+	} else {
+		return nil, nil
+	}
 }
 
 func (element BooleanElement) Update(new Element) (Element, error) {
@@ -131,6 +139,12 @@ func (element FileElement) Update(new Element) (Element, error) {
 	return newElement, nil
 }
 
+func (element VideoElement) Update(new Element) (Element, error) {
+	newElement := new.(VideoElement)
+	newElement.Asset.ID = element.Asset.ID
+	return newElement, nil
+}
+
 func (element GroupElement) Update(new Element) (Element, error) {
 	newValue := new.(GroupElement)
 	for k, v := range element.Value {
@@ -198,9 +212,24 @@ func (m MultiNumberElement) Update(new Element) (Element, error) {
 	panic("implement me")
 }
 
-func (d DateTimeElement) Update(new Element) (Element, error) {
-	//TODO implement me
-	panic("implement me")
+// Update of a datetime element takes the value of a date too , as a date is stored in a datetime element of acoustic
+func (element DateTimeElement) Update(new Element) (Element, error) {
+	oldValue := element.Value
+	var newValue string
+	switch newElement := new.(type) {
+	case DateTimeElement:
+		newValue = newElement.Value
+	case DateElement:
+		newValue = newElement.Value
+	default:
+		return nil, errors.ErrorMessageWithStack(fmt.Sprintf("a datetime element can not be updated with %T", new))
+	}
+	if oldValue != newValue {
+		element.Value = newValue
+		return element, nil
+	} else {
+		return nil, nil
+	}
 }
 
 func (m MultiLinkElement) Update(new Element) (Element, error) {
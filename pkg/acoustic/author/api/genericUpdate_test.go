@@ -0,0 +1,40 @@
+package api
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDateTimeElementUpdate(t *testing.T) {
+	existing := DateTimeElement{Value: "2023-03-15T10:00:00Z"}
+	tests := []struct {
+		name     string
+		new      Element
+		expected Element
+	}{
+		{"datetime", DateTimeElement{Value: "2023-03-16T10:00:00Z"}, DateTimeElement{Value: "2023-03-16T10:00:00Z"}},
+		{"date", DateElement{Value: "2023-03-16T00:00:00Z"}, DateTimeElement{Value: "2023-03-16T00:00:00Z"}},
+		{"unchanged", DateElement{Value: "2023-03-15T10:00:00Z"}, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			updated, err := existing.Update(test.new)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(updated, test.expected) {
+				t.Errorf("expected %v , got %v", test.expected, updated)
+			}
+		})
+	}
+}
+
+func TestDateTimeElementUpdateWithAnotherElement(t *testing.T) {
+	for _, new := range []Element{TextElement{Value: "2023-03-16"}, nil} {
+		updated, err := DateTimeElement{Value: "2023-03-15T10:00:00Z"}.Update(new)
+		if err == nil || !strings.Contains(err.Error(), "a datetime element can not be updated with") || updated != nil {
+			t.Errorf("expected the error of the %T , got %v , %v", new, updated, err)
+		}
+	}
+}
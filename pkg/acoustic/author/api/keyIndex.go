@@ -0,0 +1,177 @@
+package api
+
+import (
+	"github.com/dekanayake/acoustic-content-sync/pkg/env"
+	"github.com/dekanayake/acoustic-content-sync/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// KeyIndex is the IDs of the contents of a content type by the values of their search keys. It is loaded once for a run
+// by paging through all the contents of the type , so the records are looked up without a search request per record
+type KeyIndex struct {
+	searchType string
+	searchKeys []string
+	mux        sync.RWMutex
+	documents  map[string][]Document
+}
+
+// LoadKeyIndex pages through the contents of the search type and indexes them by the text values of the search key
+// elements , a content without the search key elements is left out and looked up with the search
+func LoadKeyIndex(searchType string, searchKeys []string, searchOnLibrary bool, searchOnDeliveryAPI bool, rows int) (*KeyIndex, error) {
+	if len(searchKeys) == 0 {
+		return nil, errors.ErrorMessageWithStack("search keys are required to index the contents of type :" + searchType)
+	}
+	if rows <= 0 {
+		rows = 100
+	}
+	index := newKeyIndex(searchType, searchKeys)
+	searchRequest := SearchRequest{
+		ContentTypes:   []string{searchType},
+		Classification: "content",
+	}
+	searchClient := NewSearchClient(env.AcousticAPIUrl())
+	start := 0
+	indexed := 0
+	for {
+		searchResponse, err := searchClient.Search(env.LibraryID(), searchOnLibrary, searchOnDeliveryAPI, searchRequest, Pagination{Start: start, Rows: rows})
+		if err != nil {
+			return nil, errors.ErrorWithStack(err)
+		}
+		for _, document := range searchResponse.Documents {
+			if key, ok := index.documentKey(document.Document); ok {
+				index.add(key, Document{ID: document.Document.ID, Status: document.Document.Status, Created: document.Document.Created})
+				indexed++
+			}
+		}
+		if !searchResponse.HasNext() {
+			break
+		}
+		start, rows = searchResponse.NextPagination()
+	}
+	log.Info("Indexed " + strconv.Itoa(indexed) + " contents of type " + searchType + " by " + strings.Join(searchKeys, ", "))
+	for key, documents := range index.Duplicates() {
+		log.WithField("key", key).WithField("ids", documentIDs(documents)).Warn("Duplicate contents found for the key of type " + searchType)
+	}
+	return index, nil
+}
+
+func newKeyIndex(searchType string, searchKeys []string) *KeyIndex {
+	return &KeyIndex{
+		searchType: searchType,
+		searchKeys: searchKeys,
+		documents:  make(map[string][]Document),
+	}
+}
+
+func (index *KeyIndex) documentKey(document Document) (string, bool) {
+	values := make([]string, 0, len(index.searchKeys))
+	for _, searchKey := range index.searchKeys {
+		element, ok := document.Elements[searchKey].(map[string]interface{})
+		if !ok {
+			return "", false
+		}
+		var value string
+		switch elementValue := element["value"].(type) {
+		case string:
+			value = elementValue
+		case float64:
+			value = strconv.FormatFloat(elementValue, 'f', -1, 64)
+		case bool:
+			value = strconv.FormatBool(elementValue)
+		default:
+			return "", false
+		}
+		if value == "" {
+			return "", false
+		}
+		values = append(values, value)
+	}
+	return strings.Join(values, ", "), true
+}
+
+func (index *KeyIndex) recordKey(record AcousticDataRecord) (string, bool) {
+	values := make([]string, 0, len(index.searchKeys))
+	for _, searchKey := range index.searchKeys {
+		value := record.SearchValues[searchKey]
+		if value == "" {
+			return "", false
+		}
+		values = append(values, value)
+	}
+	return strings.Join(values, ", "), true
+}
+
+func (index *KeyIndex) add(key string, document Document) {
+	index.mux.Lock()
+	defer index.mux.Unlock()
+	for _, existing := range index.documents[key] {
+		if existing.ID == document.ID {
+			return
+		}
+	}
+	index.documents[key] = append(index.documents[key], document)
+}
+
+// Lookup gives the contents of the record as a search response , it is not found when the key of the record is not in
+// the index
+func (index *KeyIndex) Lookup(record AcousticDataRecord) (SearchResponse, bool) {
+	if index == nil {
+		return SearchResponse{}, false
+	}
+	key, ok := index.recordKey(record)
+	if !ok {
+		return SearchResponse{}, false
+	}
+	index.mux.RLock()
+	defer index.mux.RUnlock()
+	indexed, ok := index.documents[key]
+	if !ok {
+		return SearchResponse{}, false
+	}
+	documents := make([]DocumentItem, 0, len(indexed))
+	for _, document := range indexed {
+		documents = append(documents, DocumentItem{Document: document})
+	}
+	return SearchResponse{Count: len(documents), Rows: len(documents), Documents: documents}, true
+}
+
+// Add indexes the content created for the record in the run
+func (index *KeyIndex) Add(record AcousticDataRecord, id string) {
+	if index == nil || id == "" {
+		return
+	}
+	if key, ok := index.recordKey(record); ok {
+		index.add(key, Document{ID: id, Created: AcousticTime{time.Now()}})
+	}
+}
+
+// addFound indexes the contents found with the search for the record
+func (index *KeyIndex) addFound(record AcousticDataRecord, documents []DocumentItem) {
+	if index == nil {
+		return
+	}
+	if key, ok := index.recordKey(record); ok {
+		for _, document := range documents {
+			index.add(key, Document{ID: document.Document.ID, Status: document.Document.Status, Created: document.Document.Created})
+		}
+	}
+}
+
+// Duplicates is the keys shared by more than a content with the contents , from the oldest to the newest
+func (index *KeyIndex) Duplicates() map[string][]Document {
+	index.mux.RLock()
+	defer index.mux.RUnlock()
+	duplicates := make(map[string][]Document)
+	for key, documents := range index.documents {
+		if len(documents) > 1 {
+			duplicateDocuments := append([]Document{}, documents...)
+			SortByCreated(duplicateDocuments)
+			duplicates[key] = duplicateDocuments
+		}
+	}
+	return duplicates
+}
@@ -232,16 +232,16 @@ func (service *siteService) createPage(siteId string, parentPageId string, recor
 			if acc == nil {
 				acc = make(map[string]string)
 			}
-			var value string
-			switch columnData.Value.(type) {
-			default:
-				value = columnData.Value.(string)
+			// a field left out by its when condition has no value , only the text values are read
+			if columnData.Ignore {
+				return acc, nil
+			}
+			switch value := columnData.Value.(type) {
 			case string:
-				value = columnData.Value.(string)
+				acc[columnData.Name] = value
 			case AcousticValue:
-				value = columnData.Value.(AcousticValue).Value
+				acc[columnData.Name] = value.Value
 			}
-			acc[columnData.Name] = value
 			return acc, nil
 		})
 	err := acousticContentDataOut.Err().UserError()
@@ -0,0 +1,20 @@
+package api
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCreatePageWithIgnoredField(t *testing.T) {
+	service := &siteService{}
+	record := AcousticDataRecord{Values: []GenericData{
+		{Name: "name", Type: "text", Value: AcousticValue{Value: "Shoes"}},
+		// the url left out by its when condition
+		{Name: "url", Type: "text", Ignore: true},
+		{Name: "brand", Type: "reference", Value: AcousticReference{Type: "brand"}},
+	}}
+	_, _, err := service.createPage("site", "parent", record)
+	if err == nil || !strings.Contains(err.Error(), "No value for the url") {
+		t.Errorf("expected the missing url error , got %v", err)
+	}
+}
@@ -56,6 +56,10 @@ func (element FileElement) Type() string {
 	return "FileElement"
 }
 
+func (element VideoElement) Type() string {
+	return "VideoElement"
+}
+
 func (element GroupElement) Type() string {
 	return "GroupElement"
 }
@@ -3,7 +3,9 @@ package csv
 import (
 	"github.com/dekanayake/acoustic-content-sync/pkg/acoustic/author/api"
 	"github.com/dekanayake/acoustic-content-sync/pkg/errors"
+	log "github.com/sirupsen/logrus"
 	"github.com/wesovilabs/koazee"
+	"strconv"
 )
 
 func convert(acousticField string, configTypeMapping *ContentTypeMapping, dataRow DataRow) (api.GenericData, error) {
@@ -11,6 +13,20 @@ func convert(acousticField string, configTypeMapping *ContentTypeMapping, dataRo
 	if err != nil {
 		return api.GenericData{}, errors.ErrorWithStack(err)
 	}
+	// of the alternative mappings of the field the first one satisfied by the row is used
+	for _, fieldMapping := range configTypeMapping.FieldMapping {
+		if fieldMapping.AcousticProperty != acousticField {
+			continue
+		}
+		applies, err := fieldMapping.applies(dataRow)
+		if err != nil {
+			return api.GenericData{}, errors.ErrorWithStack(err)
+		}
+		if applies {
+			acousticFieldMapping = &fieldMapping
+			break
+		}
+	}
 	data, err := acousticFieldMapping.ConvertToGenericData(dataRow, configTypeMapping)
 	if err != nil {
 		return data, errors.ErrorWithStack(err)
@@ -18,126 +34,223 @@ func convert(acousticField string, configTypeMapping *ContentTypeMapping, dataRo
 	return data, nil
 }
 
-func TransformContent(contentType string, dataFeedPath string, configPath string) ([]api.AcousticDataRecord, error) {
-	config, err := InitContentTypeMappingConfig(configPath)
+func toAcousticDataRecord(configTypeMapping *ContentTypeMapping, acousticFields []string, dataRow DataRow) (api.AcousticDataRecord, error) {
+	acousticDataOut := koazee.StreamOf(acousticFields).
+		Map(func(acousticField string) (api.GenericData, error) {
+			return convert(acousticField, configTypeMapping, dataRow)
+		}).Do().Out()
+
+	err := acousticDataOut.Err()
 	if err != nil {
-		return nil, errors.ErrorWithStack(err)
+		return api.AcousticDataRecord{}, errors.ErrorWithStack(err)
 	}
-	configTypeMapping, err := config.GetContentType(contentType)
+	acousticData := acousticDataOut.Val().([]api.GenericData)
+
+	searchValues := make(map[string]string)
+	for _, searchKey := range configTypeMapping.SearchKeys {
+		for _, acousticDataItem := range acousticData {
+			if acousticDataItem.Name != searchKey || acousticDataItem.Ignore {
+				continue
+			}
+			// a search key left out by its when condition or without a value is searched as an empty value
+			if value, ok := acousticDataItem.Value.(api.AcousticValue); ok {
+				searchValues[searchKey] = value.Value
+			}
+		}
+	}
+
+	return api.AcousticDataRecord{
+		Values:                 acousticData,
+		NameFields:             configTypeMapping.Name,
+		Tags:                   configTypeMapping.Tags,
+		Update:                 configTypeMapping.Update,
+		CreateNonExistingItems: configTypeMapping.CreateNonExistingItems,
+		SearchTerm:             configTypeMapping.SearchTerm,
+		SearchTerms:            configTypeMapping.SearchTerms,
+		SearchOnLibrary:        configTypeMapping.SearchOnLibrary,
+		SearchOnDeliveryAPI:    configTypeMapping.SearchOnDeliveryAPI,
+		SearchValues:           searchValues,
+		SearchKeys:             configTypeMapping.SearchKeys,
+		SearchType:             configTypeMapping.SearchType,
+		CSVRecordKey:           configTypeMapping.CsvRecordKey,
+		FilterRecords:          configTypeMapping.FilterRecords,
+		FilterFileLocation:     configTypeMapping.FilterFileLocation,
+		FilterType:             configTypeMapping.FilterType,
+		FilterColumns:          configTypeMapping.FilterColumns,
+		DuplicatePolicy:        configTypeMapping.DuplicatePolicy,
+	}, nil
+}
+
+// skippedRecord is the record of a row which does not satisfy the when condition of the content type , only the record
+// key is read from the row so the skipped row can be reported
+func skippedRecord(configTypeMapping *ContentTypeMapping, dataRow DataRow) (api.AcousticDataRecord, bool, error) {
+	if configTypeMapping.When == "" {
+		return api.AcousticDataRecord{}, false, nil
+	}
+	condition, err := compileCondition(configTypeMapping.When)
 	if err != nil {
-		return nil, errors.ErrorWithStack(err)
+		return api.AcousticDataRecord{}, false, errors.ErrorWithStack(err)
 	}
-	dataFeed, err := LoadCSV(dataFeedPath)
+	matches, err := condition.Matches(dataRow)
 	if err != nil {
-		return nil, errors.ErrorWithStack(err)
+		return api.AcousticDataRecord{}, false, errors.ErrorMessageWithStack("row " + strconv.Itoa(dataRow.RowNumber()) + " : " + err.Error())
 	}
-	acousticFields := configTypeMapping.GetAcousticFields()
-	acousticDataList := make([]api.AcousticDataRecord, 0, dataFeed.RecordCount())
-	for ok := true; ok; ok = dataFeed.HasNext() {
-		dataRow := dataFeed.Next()
-		acousticDataOut := koazee.StreamOf(acousticFields).
-			Map(func(acousticField string) (api.GenericData, error) {
-				return convert(acousticField, configTypeMapping, dataRow)
-			}).Do().Out()
+	if matches {
+		return api.AcousticDataRecord{}, false, nil
+	}
+	key, err := recordKeyValue(configTypeMapping, dataRow)
+	if err != nil {
+		// a skipped row is not expected to have all the values , it is reported without the key
+		key = ""
+	}
+	return api.AcousticDataRecord{
+		CSVRecordKey: configTypeMapping.CsvRecordKey,
+		Values:       []api.GenericData{{Name: configTypeMapping.CsvRecordKey, Value: key}},
+		SkipReason:   SKIPPED_CONDITION + " : " + configTypeMapping.When,
+	}, true, nil
+}
 
-		err := acousticDataOut.Err()
-		if err != nil {
-			return nil, errors.ErrorWithStack(err)
-		}
-		acousticData := acousticDataOut.Val().([]api.GenericData)
+// recordKeyValue is the value of the record key field of the row , read without transforming the other fields
+func recordKeyValue(configTypeMapping *ContentTypeMapping, dataRow DataRow) (string, error) {
+	key, err := convert(configTypeMapping.CsvRecordKey, configTypeMapping, dataRow)
+	if err != nil {
+		return "", errors.ErrorWithStack(err)
+	}
+	if key.Value == nil {
+		return "", nil
+	}
+	record := api.AcousticDataRecord{CSVRecordKey: configTypeMapping.CsvRecordKey, Values: []api.GenericData{key}}
+	return record.CSVRecordKeyValue(), nil
+}
 
-		searchValues := make(map[string]string)
-		for _, searchKey := range configTypeMapping.SearchKeys {
-			for _, acousticDataItem := range acousticData {
-				if acousticDataItem.Name == searchKey {
-					searchValues[searchKey] = acousticDataItem.Value.(api.AcousticValue).Value
-				}
+// transformFeed reads the feed row by row and hands over each transformed record before the next row is read , the rows
+// left out by the record filters are not transformed and the rows which do not satisfy the when condition are handed
+// over as skipped records
+func transformFeed(configTypeMapping *ContentTypeMapping, dataFeedPath string, transform func(dataRow DataRow) (api.AcousticDataRecord, error), handle func(record api.AcousticDataRecord) error) (selection feedSelection, err error) {
+	filter, err := newRecordFilter(configTypeMapping)
+	if err != nil {
+		return selection, errors.ErrorWithStack(err)
+	}
+	dataFeed, err := LoadGroupedFeed(dataFeedPath, configTypeMapping)
+	if err != nil {
+		return selection, errors.ErrorWithStack(err)
+	}
+	defer func() {
+		cerr := dataFeed.Close()
+		if err == nil && cerr != nil {
+			err = errors.ErrorWithStack(cerr)
+		}
+	}()
+	for dataFeed.HasNext() {
+		if filter.limitReached() {
+			log.Info("Stopped reading the feed , the limit of " + strconv.Itoa(filter.limit) + " records is reached")
+			selection.limitReached = true
+			break
+		}
+		dataRow := dataFeed.Next()
+		if selected, err := filter.selects(dataRow); err != nil {
+			return selection, errors.ErrorWithStack(err)
+		} else if !selected {
+			selection.filtered++
+			continue
+		}
+		if record, skipped, err := skippedRecord(configTypeMapping, dataRow); err != nil {
+			return selection, errors.ErrorWithStack(err)
+		} else if skipped {
+			if err := handle(record); err != nil {
+				return selection, errors.ErrorWithStack(err)
 			}
+			continue
+		}
+		record, err := transform(dataRow)
+		if err != nil {
+			return selection, errors.ErrorWithStack(err)
+		}
+		if err := handle(record); err != nil {
+			return selection, errors.ErrorWithStack(err)
 		}
-
-		acousticDataList = append(acousticDataList, api.AcousticDataRecord{
-			Values:                 acousticData,
-			NameFields:             configTypeMapping.Name,
-			Tags:                   configTypeMapping.Tags,
-			Update:                 configTypeMapping.Update,
-			CreateNonExistingItems: configTypeMapping.CreateNonExistingItems,
-			SearchTerm:             configTypeMapping.SearchTerm,
-			SearchTerms:            configTypeMapping.SearchTerms,
-			SearchOnLibrary:        configTypeMapping.SearchOnLibrary,
-			SearchOnDeliveryAPI:    configTypeMapping.SearchOnDeliveryAPI,
-			SearchValues:           searchValues,
-			SearchKeys:             configTypeMapping.SearchKeys,
-			SearchType:             configTypeMapping.SearchType,
-			CSVRecordKey:           configTypeMapping.CsvRecordKey,
-			FilterRecords:          configTypeMapping.FilterRecords,
-			FilterFileLocation:     configTypeMapping.FilterFileLocation,
-			FilterType:             configTypeMapping.FilterType,
-			FilterColumns:          configTypeMapping.FilterColumns,
-		})
 	}
-	return acousticDataList, nil
+	if err := dataFeed.Err(); err != nil {
+		return selection, errors.ErrorWithStack(err)
+	}
+	return selection, nil
 }
 
-func TransformSite(contentType string, dataFeedPath string, configPath string) ([]api.AcousticDataRecord, error) {
+// TransformContentFunc streams the feed , each transformed record is handed over to the handle function as soon as
+// its row is read. Stops at the first error of either the transformation or the handle function
+func TransformContentFunc(contentType string, dataFeedPath string, configPath string, handle func(record api.AcousticDataRecord) error) error {
+	_, err := transformContentFeed(contentType, dataFeedPath, configPath, handle)
+	return err
+}
+
+func transformContentFeed(contentType string, dataFeedPath string, configPath string, handle func(record api.AcousticDataRecord) error) (feedSelection, error) {
 	config, err := InitContentTypeMappingConfig(configPath)
 	if err != nil {
-		return nil, errors.ErrorWithStack(err)
+		return feedSelection{}, errors.ErrorWithStack(err)
 	}
-	siteMapping, err := config.GetSiteMapping(contentType)
+	configTypeMapping, err := config.GetContentType(contentType)
 	if err != nil {
-		return nil, errors.ErrorWithStack(err)
+		return feedSelection{}, errors.ErrorWithStack(err)
 	}
-	dataFeed, err := LoadCSV(dataFeedPath)
+	acousticFields := configTypeMapping.GetAcousticFields()
+	return transformFeed(configTypeMapping, dataFeedPath, func(dataRow DataRow) (api.AcousticDataRecord, error) {
+		return toAcousticDataRecord(configTypeMapping, acousticFields, dataRow)
+	}, handle)
+}
+
+func TransformContent(contentType string, dataFeedPath string, configPath string) ([]api.AcousticDataRecord, error) {
+	acousticDataList := make([]api.AcousticDataRecord, 0)
+	err := TransformContentFunc(contentType, dataFeedPath, configPath, func(record api.AcousticDataRecord) error {
+		if record.SkipReason == "" {
+			acousticDataList = append(acousticDataList, record)
+		}
+		return nil
+	})
 	if err != nil {
 		return nil, errors.ErrorWithStack(err)
 	}
-	acousticFields := siteMapping.GetAcousticFields()
-	acousticDataList := make([]api.AcousticDataRecord, 0, dataFeed.RecordCount())
-	for ok := true; ok; ok = dataFeed.HasNext() {
-		dataRow := dataFeed.Next()
-		acousticDataOut := koazee.StreamOf(acousticFields).
-			Map(func(acousticField string) (api.GenericData, error) {
-				return convert(acousticField, &siteMapping.ContentTypeMapping, dataRow)
-			}).Do().Out()
+	return acousticDataList, nil
+}
+
+// TransformSiteFunc streams the feed of the site pages , same as TransformContentFunc
+func TransformSiteFunc(contentType string, dataFeedPath string, configPath string, handle func(record api.AcousticDataRecord) error) error {
+	_, err := transformSiteFeed(contentType, dataFeedPath, configPath, handle)
+	return err
+}
 
-		err := acousticDataOut.Err()
+func transformSiteFeed(contentType string, dataFeedPath string, configPath string, handle func(record api.AcousticDataRecord) error) (feedSelection, error) {
+	config, err := InitContentTypeMappingConfig(configPath)
+	if err != nil {
+		return feedSelection{}, errors.ErrorWithStack(err)
+	}
+	siteMapping, err := config.GetSiteMapping(contentType)
+	if err != nil {
+		return feedSelection{}, errors.ErrorWithStack(err)
+	}
+	acousticFields := siteMapping.GetAcousticFields()
+	return transformFeed(&siteMapping.ContentTypeMapping, dataFeedPath, func(dataRow DataRow) (api.AcousticDataRecord, error) {
+		record, err := toAcousticDataRecord(&siteMapping.ContentTypeMapping, acousticFields, dataRow)
 		if err != nil {
-			return nil, errors.ErrorWithStack(err)
+			return record, err
 		}
-		acousticData := acousticDataOut.Val().([]api.GenericData)
-
-		searchValues := make(map[string]string)
-		for _, searchKey := range siteMapping.SearchKeys {
-			for _, acousticDataItem := range acousticData {
-				if acousticDataItem.Name == searchKey {
-					searchValues[searchKey] = acousticDataItem.Value.(api.AcousticValue).Value
-				}
-			}
+		record.SiteConfig = api.SiteConfig{
+			DontCreatePageIfExist: siteMapping.DontCreatePageIfExist,
+			UpdatePageIfExists:    siteMapping.UpdatePageIfExist,
 		}
+		return record, nil
+	}, handle)
+}
 
-		acousticDataList = append(acousticDataList, api.AcousticDataRecord{
-			Values:                 acousticData,
-			NameFields:             siteMapping.Name,
-			Tags:                   siteMapping.Tags,
-			Update:                 siteMapping.Update,
-			CreateNonExistingItems: siteMapping.CreateNonExistingItems,
-			SearchTerm:             siteMapping.SearchTerm,
-			SearchTerms:            siteMapping.SearchTerms,
-			SearchOnLibrary:        siteMapping.SearchOnLibrary,
-			SearchOnDeliveryAPI:    siteMapping.SearchOnDeliveryAPI,
-			SearchValues:           searchValues,
-			SearchKeys:             siteMapping.SearchKeys,
-			SearchType:             siteMapping.SearchType,
-			CSVRecordKey:           siteMapping.CsvRecordKey,
-			FilterRecords:          siteMapping.FilterRecords,
-			FilterFileLocation:     siteMapping.FilterFileLocation,
-			FilterType:             siteMapping.FilterType,
-			FilterColumns:          siteMapping.FilterColumns,
-			SiteConfig: api.SiteConfig{
-				DontCreatePageIfExist: siteMapping.DontCreatePageIfExist,
-				UpdatePageIfExists:    siteMapping.UpdatePageIfExist,
-			},
-		})
+func TransformSite(contentType string, dataFeedPath string, configPath string) ([]api.AcousticDataRecord, error) {
+	acousticDataList := make([]api.AcousticDataRecord, 0)
+	err := TransformSiteFunc(contentType, dataFeedPath, configPath, func(record api.AcousticDataRecord) error {
+		if record.SkipReason == "" {
+			acousticDataList = append(acousticDataList, record)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.ErrorWithStack(err)
 	}
 	return acousticDataList, nil
 }
@@ -0,0 +1,76 @@
+package csv
+
+import (
+	"reflect"
+	"testing"
+)
+
+const searchKeyWhenConfig = `
+contentType:
+  - type: product
+    csvRecordKey: id
+    update: true
+    searchType: product
+    searchTerm: "sku:%s"
+    searchKeys:
+      - sku
+    fieldMapping:
+      - csvProperty: id
+        acousticProperty: id
+        propertyType: text
+      - csvProperty: sku
+        acousticProperty: sku
+        propertyType: text
+        when: 'source == "erp"'
+      - csvProperty: legacySku
+        acousticProperty: sku
+        propertyType: text
+        when: 'source == "legacy"'
+site:
+  - type: page
+    csvRecordKey: id
+    update: true
+    searchType: page
+    searchTerm: "sku:%s"
+    searchKeys:
+      - sku
+    fieldMapping:
+      - csvProperty: id
+        acousticProperty: id
+        propertyType: text
+      - csvProperty: sku
+        acousticProperty: sku
+        propertyType: text
+        when: 'source == "erp"'
+`
+
+func TestSearchKeyWithWhen(t *testing.T) {
+	setTestEnv(t, "http://localhost:1")
+	configPath := writeTestFile(t, "config.yaml", searchKeyWhenConfig)
+	feedPath := writeTestFile(t, "feed.csv", "id,source,sku,legacySku\n1,erp,A-1,\n2,legacy,,L-2\n3,manual,C-3,L-3\n")
+	expected := []map[string]string{{"sku": "A-1"}, {"sku": "L-2"}, {}}
+
+	records, err := TransformContent("product", feedPath, configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	searchValues := make([]map[string]string, 0)
+	for _, record := range records {
+		searchValues = append(searchValues, record.SearchValues)
+	}
+	if !reflect.DeepEqual(searchValues, expected) {
+		t.Errorf("expected the search values %v , got %v", expected, searchValues)
+	}
+
+	records, err = TransformSite("page", feedPath, configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	searchValues = make([]map[string]string, 0)
+	for _, record := range records {
+		searchValues = append(searchValues, record.SearchValues)
+	}
+	if expected := []map[string]string{{"sku": "A-1"}, {}, {}}; !reflect.DeepEqual(searchValues, expected) {
+		t.Errorf("expected the search values of the site %v , got %v", expected, searchValues)
+	}
+}
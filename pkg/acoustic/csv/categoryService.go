@@ -125,13 +125,15 @@ func (c categoryService) Create(categoryName string, dataFeedPath string, config
 	if err != nil {
 		return errors.ErrorWithStack(err)
 	}
-	dataFeed, err := LoadCSV(dataFeedPath)
+	dataFeed, err := LoadFeed(dataFeedPath, "", "", CSVDialect{})
 	if err != nil {
 		return errors.ErrorWithStack(err)
 	}
 
-	newCategories := make([]string, 0, dataFeed.RecordCount())
-	for ok := true; ok; ok = dataFeed.HasNext() {
+	defer dataFeed.Close()
+
+	newCategories := make([]string, 0)
+	for dataFeed.HasNext() {
 		dataRow := dataFeed.Next()
 		val, err := dataRow.Get(categoryMapping.Column)
 		if err != nil {
@@ -142,6 +144,9 @@ func (c categoryService) Create(categoryName string, dataFeedPath string, config
 		}
 
 	}
+	if err := dataFeed.Err(); err != nil {
+		return errors.ErrorWithStack(err)
+	}
 
 	existingCategories := koazee.StreamOf(categories).
 		Reduce(func(acc map[string]string, categoryItem api.CategoryItem) map[string]string {
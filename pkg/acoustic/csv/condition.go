@@ -0,0 +1,450 @@
+package csv
+
+import (
+	"github.com/dekanayake/acoustic-content-sync/pkg/errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Condition is a when expression evaluated against a row of the feed. An expression compares the columns of the row
+// with the literals or other columns and combines the comparisons with the boolean operators
+//
+//	onSale == "Y" && price >= 10
+//	productType =~ "^shoe" || empty(${Product Type})
+//	!(status in ["retired", "draft"])
+//
+// A column is a name ( letters , digits , _ , . and - ) or any text in ${} , a column alone is true when it has a value.
+// == and != compare the values as text , the ordering operators compare them as numbers when both are numbers
+type Condition struct {
+	expression string
+	root       conditionNode
+	columns    []string
+}
+
+type conditionNode interface {
+	evaluate(dataRow DataRow) (bool, error)
+}
+
+type conditionOperand interface {
+	value(dataRow DataRow) (string, error)
+}
+
+type notNode struct {
+	node conditionNode
+}
+
+type andNode struct {
+	left, right conditionNode
+}
+
+type orNode struct {
+	left, right conditionNode
+}
+
+type comparisonNode struct {
+	operator string
+	left     conditionOperand
+	right    conditionOperand
+}
+
+type matchNode struct {
+	negated bool
+	left    conditionOperand
+	regx    *regexp.Regexp
+}
+
+type inNode struct {
+	left   conditionOperand
+	values []string
+}
+
+type hasValueNode struct {
+	operand conditionOperand
+}
+
+type columnOperand string
+
+type literalOperand string
+
+type conditionToken struct {
+	kind  string
+	text  string
+	index int
+}
+
+const (
+	TOKEN_COLUMN   = "column"
+	TOKEN_STRING   = "string"
+	TOKEN_NUMBER   = "number"
+	TOKEN_OPERATOR = "operator"
+	TOKEN_END      = "end"
+)
+
+const SKIPPED_CONDITION = "the row does not satisfy the when condition"
+
+var compiledConditions = sync.Map{}
+
+// compileCondition parses the when expression , a parsed expression is kept as the same expression is evaluated for
+// every row of the feed
+func compileCondition(expression string) (*Condition, error) {
+	if compiled, ok := compiledConditions.Load(expression); ok {
+		return compiled.(*Condition), nil
+	}
+	tokens, err := tokenizeCondition(expression)
+	var root conditionNode
+	var parser *conditionParser
+	if err == nil {
+		parser = &conditionParser{tokens: tokens}
+		root, err = parser.parseOr()
+		if err == nil && parser.peek().kind != TOKEN_END {
+			err = parser.unexpected()
+		}
+	}
+	if err != nil {
+		return nil, errors.ErrorMessageWithStack("invalid when " + strconv.Quote(expression) + " : " + err.Error())
+	}
+	condition := &Condition{expression: expression, root: root, columns: parser.columns}
+	compiledConditions.Store(expression, condition)
+	return condition, nil
+}
+
+// Matches evaluates the condition against the row , a column missing in the row is an error
+func (condition *Condition) Matches(dataRow DataRow) (bool, error) {
+	matches, err := condition.root.evaluate(dataRow)
+	if err != nil {
+		return false, errors.ErrorMessageWithStack("Failed in evaluating when " + strconv.Quote(condition.expression) + " : " + err.Error())
+	}
+	return matches, nil
+}
+
+// Columns are the columns of the row read by the condition
+func (condition *Condition) Columns() []string {
+	return condition.columns
+}
+
+func tokenizeCondition(expression string) ([]conditionToken, error) {
+	tokens := make([]conditionToken, 0)
+	runes := []rune(expression)
+	for index := 0; index < len(runes); {
+		char := runes[index]
+		switch {
+		case unicode.IsSpace(char):
+			index++
+		case char == '"' || char == '\'':
+			end := index + 1
+			var text strings.Builder
+			for ; end < len(runes) && runes[end] != char; end++ {
+				// only the quote and the backslash are escaped , the other backslashes are kept for the regular expressions
+				if runes[end] == '\\' && end+1 < len(runes) && (runes[end+1] == char || runes[end+1] == '\\') {
+					end++
+				}
+				text.WriteRune(runes[end])
+			}
+			if end == len(runes) {
+				return nil, errors.ErrorMessageWithStack("string at " + strconv.Itoa(index+1) + " is not closed")
+			}
+			tokens = append(tokens, conditionToken{kind: TOKEN_STRING, text: text.String(), index: index})
+			index = end + 1
+		case char == '$' && index+1 < len(runes) && runes[index+1] == '{':
+			end := index + 2
+			for end < len(runes) && runes[end] != '}' {
+				end++
+			}
+			if end == len(runes) {
+				return nil, errors.ErrorMessageWithStack("column at " + strconv.Itoa(index+1) + " is not closed")
+			}
+			tokens = append(tokens, conditionToken{kind: TOKEN_COLUMN, text: string(runes[index+2 : end]), index: index})
+			index = end + 1
+		case unicode.IsDigit(char) || (char == '-' && index+1 < len(runes) && unicode.IsDigit(runes[index+1])):
+			end := index + 1
+			for end < len(runes) && (unicode.IsDigit(runes[end]) || runes[end] == '.') {
+				end++
+			}
+			tokens = append(tokens, conditionToken{kind: TOKEN_NUMBER, text: string(runes[index:end]), index: index})
+			index = end
+		case unicode.IsLetter(char) || char == '_':
+			end := index + 1
+			for end < len(runes) && (unicode.IsLetter(runes[end]) || unicode.IsDigit(runes[end]) || strings.ContainsRune("_.-", runes[end])) {
+				end++
+			}
+			tokens = append(tokens, conditionToken{kind: TOKEN_COLUMN, text: string(runes[index:end]), index: index})
+			index = end
+		default:
+			operator := ""
+			for _, candidate := range []string{"&&", "||", "==", "!=", "<=", ">=", "=~", "!~", "<", ">", "!", "(", ")", "[", "]", ","} {
+				if strings.HasPrefix(string(runes[index:]), candidate) {
+					operator = candidate
+					break
+				}
+			}
+			if operator == "" {
+				return nil, errors.ErrorMessageWithStack("unexpected " + strconv.QuoteRune(char) + " at " + strconv.Itoa(index+1))
+			}
+			tokens = append(tokens, conditionToken{kind: TOKEN_OPERATOR, text: operator, index: index})
+			index += len(operator)
+		}
+	}
+	return append(tokens, conditionToken{kind: TOKEN_END, index: len(runes)}), nil
+}
+
+type conditionParser struct {
+	tokens   []conditionToken
+	position int
+	columns  []string
+}
+
+func (parser *conditionParser) peek() conditionToken {
+	return parser.tokens[parser.position]
+}
+
+func (parser *conditionParser) next() conditionToken {
+	token := parser.tokens[parser.position]
+	if token.kind != TOKEN_END {
+		parser.position++
+	}
+	return token
+}
+
+// isKeyword checks the next token is the operator or the word ( and , or , not , in ) , a word is not read as a column
+func (parser *conditionParser) isKeyword(operator string, word string) bool {
+	token := parser.peek()
+	return (token.kind == TOKEN_OPERATOR && token.text == operator) || (token.kind == TOKEN_COLUMN && word != "" && strings.EqualFold(token.text, word))
+}
+
+func (parser *conditionParser) expect(operator string) error {
+	if !parser.isKeyword(operator, "") {
+		return errors.ErrorMessageWithStack("expected " + strconv.Quote(operator) + " at " + strconv.Itoa(parser.peek().index+1))
+	}
+	parser.next()
+	return nil
+}
+
+func (parser *conditionParser) unexpected() error {
+	token := parser.peek()
+	if token.kind == TOKEN_END {
+		return errors.ErrorMessageWithStack("unexpected end of the expression")
+	}
+	return errors.ErrorMessageWithStack("unexpected " + strconv.Quote(token.text) + " at " + strconv.Itoa(token.index+1))
+}
+
+func (parser *conditionParser) parseOr() (conditionNode, error) {
+	left, err := parser.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for parser.isKeyword("||", "or") {
+		parser.next()
+		right, err := parser.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (parser *conditionParser) parseAnd() (conditionNode, error) {
+	left, err := parser.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for parser.isKeyword("&&", "and") {
+		parser.next()
+		right, err := parser.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (parser *conditionParser) parseUnary() (conditionNode, error) {
+	if parser.isKeyword("!", "not") {
+		parser.next()
+		node, err := parser.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{node: node}, nil
+	}
+	if parser.isKeyword("(", "") {
+		parser.next()
+		node, err := parser.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return node, parser.expect(")")
+	}
+	if parser.isKeyword("", "empty") && parser.tokens[parser.position+1].text == "(" {
+		parser.next()
+		parser.next()
+		operand, err := parser.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{node: hasValueNode{operand: operand}}, parser.expect(")")
+	}
+	return parser.parseComparison()
+}
+
+func (parser *conditionParser) parseComparison() (conditionNode, error) {
+	left, err := parser.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	token := parser.peek()
+	switch {
+	case token.kind == TOKEN_OPERATOR && (token.text == "==" || token.text == "!=" || token.text == "<" || token.text == "<=" || token.text == ">" || token.text == ">="):
+		parser.next()
+		right, err := parser.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		return comparisonNode{operator: token.text, left: left, right: right}, nil
+	case token.kind == TOKEN_OPERATOR && (token.text == "=~" || token.text == "!~"):
+		parser.next()
+		pattern := parser.next()
+		if pattern.kind != TOKEN_STRING {
+			return nil, errors.ErrorMessageWithStack("the regular expression at " + strconv.Itoa(pattern.index+1) + " should be a string")
+		}
+		regx, err := regexp.Compile(pattern.text)
+		if err != nil {
+			return nil, errors.ErrorMessageWithStack("invalid regular expression " + strconv.Quote(pattern.text) + " : " + err.Error())
+		}
+		return matchNode{negated: token.text == "!~", left: left, regx: regx}, nil
+	case parser.isKeyword("", "in"):
+		parser.next()
+		if err := parser.expect("["); err != nil {
+			return nil, err
+		}
+		values := make([]string, 0)
+		for !parser.isKeyword("]", "") {
+			value := parser.next()
+			if value.kind != TOKEN_STRING && value.kind != TOKEN_NUMBER {
+				return nil, errors.ErrorMessageWithStack("the values of in at " + strconv.Itoa(value.index+1) + " should be strings or numbers")
+			}
+			values = append(values, value.text)
+			if parser.isKeyword(",", "") {
+				parser.next()
+			} else if !parser.isKeyword("]", "") {
+				return nil, parser.unexpected()
+			}
+		}
+		parser.next()
+		return inNode{left: left, values: values}, nil
+	}
+	return hasValueNode{operand: left}, nil
+}
+
+func (parser *conditionParser) parseOperand() (conditionOperand, error) {
+	token := parser.peek()
+	switch token.kind {
+	case TOKEN_STRING, TOKEN_NUMBER:
+		parser.next()
+		return literalOperand(token.text), nil
+	case TOKEN_COLUMN:
+		parser.next()
+		parser.columns = append(parser.columns, token.text)
+		return columnOperand(token.text), nil
+	}
+	return nil, parser.unexpected()
+}
+
+func (node notNode) evaluate(dataRow DataRow) (bool, error) {
+	matches, err := node.node.evaluate(dataRow)
+	return !matches, err
+}
+
+func (node andNode) evaluate(dataRow DataRow) (bool, error) {
+	matches, err := node.left.evaluate(dataRow)
+	if err != nil || !matches {
+		return false, err
+	}
+	return node.right.evaluate(dataRow)
+}
+
+func (node orNode) evaluate(dataRow DataRow) (bool, error) {
+	matches, err := node.left.evaluate(dataRow)
+	if err != nil || matches {
+		return matches, err
+	}
+	return node.right.evaluate(dataRow)
+}
+
+func (node hasValueNode) evaluate(dataRow DataRow) (bool, error) {
+	value, err := node.operand.value(dataRow)
+	return value != "", err
+}
+
+func (node comparisonNode) evaluate(dataRow DataRow) (bool, error) {
+	left, err := node.left.value(dataRow)
+	if err != nil {
+		return false, err
+	}
+	right, err := node.right.value(dataRow)
+	if err != nil {
+		return false, err
+	}
+	switch node.operator {
+	case "==":
+		return left == right, nil
+	case "!=":
+		return left != right, nil
+	}
+	compared := strings.Compare(left, right)
+	leftNumber, leftErr := strconv.ParseFloat(left, 64)
+	rightNumber, rightErr := strconv.ParseFloat(right, 64)
+	if leftErr == nil && rightErr == nil {
+		switch {
+		case leftNumber < rightNumber:
+			compared = -1
+		case leftNumber > rightNumber:
+			compared = 1
+		default:
+			compared = 0
+		}
+	}
+	switch node.operator {
+	case "<":
+		return compared < 0, nil
+	case "<=":
+		return compared <= 0, nil
+	case ">":
+		return compared > 0, nil
+	default:
+		return compared >= 0, nil
+	}
+}
+
+func (node matchNode) evaluate(dataRow DataRow) (bool, error) {
+	value, err := node.left.value(dataRow)
+	if err != nil {
+		return false, err
+	}
+	return node.regx.MatchString(value) != node.negated, nil
+}
+
+func (node inNode) evaluate(dataRow DataRow) (bool, error) {
+	value, err := node.left.value(dataRow)
+	if err != nil {
+		return false, err
+	}
+	for _, candidate := range node.values {
+		if value == candidate {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (column columnOperand) value(dataRow DataRow) (string, error) {
+	return dataRow.Get(string(column))
+}
+
+func (literal literalOperand) value(dataRow DataRow) (string, error) {
+	return string(literal), nil
+}
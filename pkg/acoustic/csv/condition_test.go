@@ -0,0 +1,150 @@
+package csv
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestTokenizeCondition(t *testing.T) {
+	tests := []struct {
+		expression string
+		expected   []conditionToken
+	}{
+		{`sku =~ "^\d+-[A-Z]\.$"`, []conditionToken{{TOKEN_COLUMN, "sku", 0}, {TOKEN_OPERATOR, "=~", 4}, {TOKEN_STRING, `^\d+-[A-Z]\.$`, 7}}},
+		{`name == "say \"hi\""`, []conditionToken{{TOKEN_COLUMN, "name", 0}, {TOKEN_OPERATOR, "==", 5}, {TOKEN_STRING, `say "hi"`, 8}}},
+		{`path == 'C:\\feeds\'s'`, []conditionToken{{TOKEN_COLUMN, "path", 0}, {TOKEN_OPERATOR, "==", 5}, {TOKEN_STRING, `C:\feeds's`, 8}}},
+		{`name == 'a\"b'`, []conditionToken{{TOKEN_COLUMN, "name", 0}, {TOKEN_OPERATOR, "==", 5}, {TOKEN_STRING, `a\"b`, 8}}},
+		{`${Product Type}!=-1.5`, []conditionToken{{TOKEN_COLUMN, "Product Type", 0}, {TOKEN_OPERATOR, "!=", 15}, {TOKEN_NUMBER, "-1.5", 17}}},
+		{`!(a.b_c-d in [1,"x"])`, []conditionToken{{TOKEN_OPERATOR, "!", 0}, {TOKEN_OPERATOR, "(", 1}, {TOKEN_COLUMN, "a.b_c-d", 2}, {TOKEN_COLUMN, "in", 10},
+			{TOKEN_OPERATOR, "[", 13}, {TOKEN_NUMBER, "1", 14}, {TOKEN_OPERATOR, ",", 15}, {TOKEN_STRING, "x", 16}, {TOKEN_OPERATOR, "]", 19}, {TOKEN_OPERATOR, ")", 20}}},
+	}
+	for _, test := range tests {
+		t.Run(test.expression, func(t *testing.T) {
+			tokens, err := tokenizeCondition(test.expression)
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected := append(test.expected, conditionToken{kind: TOKEN_END, index: len([]rune(test.expression))})
+			if !reflect.DeepEqual(tokens, expected) {
+				t.Errorf("expected %v , got %v", expected, tokens)
+			}
+		})
+	}
+}
+
+func TestCondition(t *testing.T) {
+	row := &dataRow{columns: map[string]string{
+		"sku":          "123-A",
+		"status":       "draft",
+		"price":        "9.5",
+		"stock":        "10",
+		"code":         "b",
+		"empty":        " ",
+		"onSale":       "Y",
+		"Product Type": "shoe/trail",
+	}}
+	tests := []struct {
+		expression string
+		expected   bool
+	}{
+		{`sku =~ "^\d+-[A-Z]$"`, true},
+		{`sku !~ "^\d+$"`, true},
+		{`sku =~ "^\\d+-"`, true},
+		{`${Product Type} =~ "^shoe/"`, true},
+		{`status in ["draft", "retired"]`, true},
+		{`status in ["published"]`, false},
+		{`stock in [5, 10]`, true},
+		{`empty(empty)`, true},
+		{`empty(sku)`, false},
+		{`!empty(sku)`, true},
+		{`sku`, true},
+		{`empty`, false},
+		{`price < stock`, true},
+		{`price < "10"`, true},
+		{`"9.5" == price`, true},
+		{`price == 9.50`, false},
+		{`price >= 9.5`, true},
+		{`stock > 9`, true},
+		{`code > "a"`, true},
+		{`code <= "B"`, false},
+		{`stock > "9 items"`, false},
+		{`onSale == "Y" || status == "published" && price > 100`, true},
+		{`(onSale == "Y" || status == "published") && price > 100`, false},
+		{`onSale == "N" or status == "draft" and stock == 10`, true},
+		{`not onSale == "N" and status == "draft"`, true},
+		{`!(onSale == "Y" && status == "draft")`, false},
+		{`NOT empty(sku) AND status IN ["draft"]`, true},
+	}
+	for _, test := range tests {
+		t.Run(test.expression, func(t *testing.T) {
+			condition, err := compileCondition(test.expression)
+			if err != nil {
+				t.Fatal(err)
+			}
+			matches, err := condition.Matches(row)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if matches != test.expected {
+				t.Errorf("expected %v , got %v", test.expected, matches)
+			}
+		})
+	}
+}
+
+func TestConditionColumns(t *testing.T) {
+	condition, err := compileCondition(`status != "draft" && (${Product Type} =~ "^shoe" || empty(sku))`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"status", "Product Type", "sku"}; !reflect.DeepEqual(condition.Columns(), expected) {
+		t.Errorf("expected %v , got %v", expected, condition.Columns())
+	}
+}
+
+func TestConditionMissingColumn(t *testing.T) {
+	condition, err := compileCondition(`onSale == "Y" && missing == "x"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := condition.Matches(&dataRow{columns: map[string]string{"onSale": "N"}}); err != nil {
+		t.Errorf("expected the right side not evaluated , got %v", err)
+	}
+	_, err = condition.Matches(&dataRow{columns: map[string]string{"onSale": "Y"}})
+	if err == nil || !strings.Contains(err.Error(), "No value found for column name :missing") {
+		t.Errorf("expected the missing column error , got %v", err)
+	}
+}
+
+func TestConditionParseErrors(t *testing.T) {
+	tests := []struct {
+		expression string
+		expected   string
+	}{
+		{`name == "open`, "string at 9 is not closed"},
+		{`${Product Type == "x"`, "column at 1 is not closed"},
+		{`price # 1`, "unexpected '#' at 7"},
+		{`price ==`, "unexpected end of the expression"},
+		{`price == 1 1`, `unexpected "1" at 12`},
+		{`(price == 1`, `expected ")" at 12`},
+		{`sku =~ code`, "the regular expression at 8 should be a string"},
+		{`sku =~ "("`, `invalid regular expression "("`},
+		{`status in "draft"`, `expected "[" at 11`},
+		{`status in [draft]`, "the values of in at 12 should be strings or numbers"},
+		{`status in ["a" "b"]`, `unexpected "b" at 16`},
+		{`&& sku`, `unexpected "&&" at 1`},
+	}
+	for _, test := range tests {
+		t.Run(test.expression, func(t *testing.T) {
+			_, err := compileCondition(test.expression)
+			if err == nil || !strings.Contains(err.Error(), test.expected) {
+				t.Errorf("expected the error %q , got %v", test.expected, err)
+			}
+			if err != nil && !strings.Contains(err.Error(), "invalid when "+strconv.Quote(test.expression)) {
+				t.Errorf("expected the error to name the when , got %v", err)
+			}
+		})
+	}
+}
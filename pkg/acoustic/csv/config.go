@@ -27,6 +27,7 @@ type ContentTypesMapping struct {
 	CategoryMapping []CategoryMapping    `yaml:"category"`
 	DeleteMapping   []DeleteMapping      `yaml:"delete"`
 	SiteMapping     []SiteMapping        `yaml:"site"`
+	LookupMapping   []LookupMapping      `yaml:"lookups"`
 }
 
 type ContentTypeMapping struct {
@@ -45,11 +46,36 @@ type ContentTypeMapping struct {
 	SearchType             string                `yaml:"searchType"`
 	SearchOnDeliveryAPI    bool                  `yaml:"searchOnDeliveryAPI"`
 	PaginationRows         int                   `yaml:"paginationRows"`
+	// the existing contents are looked up in an index of the contents of the search type by the search keys , loaded
+	// once for the run , instead of a search per record
+	KeyIndex bool `yaml:"keyIndex"`
+	// the contents updated by a record when more than a content is found for the search keys , the first content is
+	// updated when not set
+	DuplicatePolicy api.DuplicatePolicy `yaml:"duplicatePolicy"`
+	// Number of records processed concurrently , overridden by the workers flag
+	Workers int `yaml:"workers"`
+	// Name of the sheet read from a XLSX feed , the first sheet when not set
+	FeedSheet string `yaml:"feedSheet"`
+	// Delimiter , quoting and encoding of a CSV feed
+	CSVDialect CSVDialect `yaml:"csvDialect"`
+	// Secondary feeds joined to the rows of the feed on a key column
+	Joins []FeedJoin `yaml:"joins"`
+	// Collapses the rows sharing the record key in to one record , consecutive or keyed
+	GroupRows RowGrouping `yaml:"groupRows"`
+	// Only the rows satisfying any of the include conditions and none of the exclude conditions are read from the feed
+	Include []string `yaml:"include"`
+	Exclude []string `yaml:"exclude"`
 	// This config allows to filter records in the data csv
 	FilterRecords      bool     `yaml:"filterRecords"`
 	FilterType         string   `yaml:"filterType"`
 	FilterColumns      []string `yaml:"filterColumns"`
 	FilterFileLocation string   `yaml:"filterFileLocation"`
+	// the dialect of the filter file , the dialect of the feed when not set
+	FilterFileDialect CSVDialect `yaml:"filterFileDialect"`
+	// Only the rows satisfying the condition are processed , the other rows are reported as skipped
+	When string `yaml:"when"`
+	// the lookups of the config , set when the mapping is read from the config
+	lookups lookupTables
 }
 
 type SiteMapping struct {
@@ -67,6 +93,8 @@ type DeleteMapping struct {
 	Name          string        `yaml:"name"`
 	AssetType     api.AssetType `yaml:"assetType"`
 	SearchMapping SearchMapping `yaml:"search"`
+	// retire the contents disappeared from the feed of a delta run instead of deleting them
+	Retire bool `yaml:"retire"`
 }
 
 type SearchMapping struct {
@@ -125,6 +153,9 @@ type ContentFieldMapping struct {
 	SearchOnLibrary       bool               `yaml:"searchOnLibrary"`
 	SearchKeys            []string           `yaml:"searchKeys"`
 	SearchOnDeliveryAPI   bool               `yaml:"searchOnDeliveryAPI"`
+	// the value is the record keys of records of the referenced content type synced in the same process , the reference is
+	// set in a second pass after all the records of the run are created , the search is the fallback for a key not synced
+	LinkByKey bool `yaml:"linkByKey"`
 	// configuration related the column value in
 	ValueAsJSON bool   `yaml:"valueAsJSON"`
 	JSONKey     string `yaml:"JSONKey"`
@@ -134,6 +165,19 @@ type ContentFieldMapping struct {
 	ValueAsJSONList bool
 	// link related data
 
+	// configuration related to date and datetime , the layouts are go time layouts and the time zone is used when
+	// the value has no zone of its own
+	DateTimeLayouts []string `yaml:"dateTimeLayouts"`
+	TimeZone        string   `yaml:"timeZone"`
+	// transforms applied in order to the value read from the feed
+	Transforms []FieldTransform `yaml:"transforms"`
+	// configuration related to lookup , the value is translated through the named lookup of the config
+	Lookup        string              `yaml:"lookup"`
+	LookupMissing LookupMissingPolicy `yaml:"lookupMissing"`
+	LookupDefault string              `yaml:"lookupDefault"`
+	// the mapping is used only for the rows satisfying the condition , a property can have alternative mappings with
+	// different conditions and the first one satisfied by the row is used
+	When string `yaml:"when"`
 }
 
 type LinkMapping struct {
@@ -168,10 +212,19 @@ func (contentFieldMapping ContentFieldMapping) ConvertToGenericData(dataRow Data
 	data.Name = contentFieldMapping.AcousticProperty
 	data.Type = contentFieldMapping.PropertyType
 	data.Ignore = contentFieldMapping.Ignore
-	err := contentFieldMapping.Validate()
+	err := contentFieldMapping.Validate(configTypeMapping.GroupRows != "")
 	if err != nil {
 		return api.GenericData{}, errors.ErrorWithStack(err)
 	}
+	applies, err := contentFieldMapping.applies(dataRow)
+	if err != nil {
+		return api.GenericData{}, errors.ErrorWithStack(err)
+	}
+	if !applies {
+		// the element is left as it is , same as an ignored field
+		data.Ignore = true
+		return data, nil
+	}
 	val, err := contentFieldMapping.Value(dataRow, configTypeMapping)
 	if err != nil {
 		return api.GenericData{}, errors.ErrorWithStack(err)
@@ -188,7 +241,48 @@ func (contentFieldMapping ContentFieldMapping) ConvertToGenericData(dataRow Data
 	return data, nil
 }
 
+// applies checks the row satisfies the when condition of the mapping , a mapping without a condition always applies
+func (contentFieldMapping ContentFieldMapping) applies(dataRow DataRow) (bool, error) {
+	if contentFieldMapping.When == "" {
+		return true, nil
+	}
+	condition, err := compileCondition(contentFieldMapping.When)
+	if err != nil {
+		return false, errors.ErrorWithStack(err)
+	}
+	return condition.Matches(dataRow)
+}
+
+// applicableFieldMappings are the mappings used for the row , the mappings whose condition is not satisfied are left out
+// and of the alternative mappings of a property only the first one satisfied is used
+func applicableFieldMappings(fieldMappings []ContentFieldMapping, dataRow DataRow) ([]ContentFieldMapping, error) {
+	applicable := make([]ContentFieldMapping, 0, len(fieldMappings))
+	mapped := make(map[string]bool, len(fieldMappings))
+	for _, fieldMapping := range fieldMappings {
+		if mapped[fieldMapping.AcousticProperty] {
+			continue
+		}
+		applies, err := fieldMapping.applies(dataRow)
+		if err != nil {
+			return nil, errors.ErrorWithStack(err)
+		}
+		if applies {
+			mapped[fieldMapping.AcousticProperty] = true
+			applicable = append(applicable, fieldMapping)
+		}
+	}
+	return applicable, nil
+}
+
 func (contentFieldMapping ContentFieldMapping) getCsvValueOrStaticValue(dataRow DataRow) (string, error) {
+	value, err := contentFieldMapping.getSourceValue(dataRow)
+	if err != nil {
+		return "", err
+	}
+	return contentFieldMapping.applyTransforms(value, dataRow)
+}
+
+func (contentFieldMapping ContentFieldMapping) getSourceValue(dataRow DataRow) (string, error) {
 	if contentFieldMapping.JoinedValue != "" {
 		variableRegx, _ := regexp.Compile(JOIN_VALUE_VAR_REGX)
 		variableSymbolRegx, _ := regexp.Compile(JOIN_VALUE_VAR_SYMBOL_REGX)
@@ -317,9 +411,17 @@ func toStringValueMap(jsonValue map[string]interface{}) (map[string]string, erro
 	return jsonValueAsString, nil
 }
 
-func (contentFieldMapping ContentFieldMapping) Validate() error {
+// Validate checks the mapping can be converted , the items of a multi group are read from the rows of the record instead
+// of a JSON column when the rows are grouped
+func (contentFieldMapping ContentFieldMapping) Validate(groupedRows bool) error {
 	switch propType := api.FieldType(contentFieldMapping.PropertyType); propType {
 	case api.MultiGroup:
+		if groupedRows && !contentFieldMapping.ValueAsJSON {
+			if len(contentFieldMapping.FieldMapping) == 0 {
+				return errors.ErrorMessageWithStack(string(api.MultiGroup + " should have field mappings"))
+			}
+			return nil
+		}
 		if !contentFieldMapping.ValueAsJSON || contentFieldMapping.CsvProperty == "" {
 			return errors.ErrorMessageWithStack(string(api.MultiGroup + " should use in one single column as a array of json"))
 		}
@@ -341,7 +443,7 @@ func (contentFieldMapping ContentFieldMapping) Value(dataRow DataRow, configType
 	switch propType := api.FieldType(contentFieldMapping.PropertyType); propType {
 	case api.Category, api.CategoryPart:
 		category := api.AcousticCategory{}
-		value, err := contentFieldMapping.getCsvValueOrStaticValue(dataRow)
+		value, err := contentFieldMapping.getMappedValue(dataRow, configTypeMapping)
 		if err != nil {
 			return nil, errors.ErrorWithStack(err)
 		}
@@ -359,8 +461,12 @@ func (contentFieldMapping ContentFieldMapping) Value(dataRow DataRow, configType
 	case api.Group:
 		group := api.AcousticGroup{}
 		group.Type = contentFieldMapping.Type
-		dataList := make([]api.GenericData, 0, len(contentFieldMapping.FieldMapping))
-		for _, fieldMapping := range contentFieldMapping.FieldMapping {
+		fieldMappings, err := applicableFieldMappings(contentFieldMapping.FieldMapping, dataRow)
+		if err != nil {
+			return nil, errors.ErrorWithStack(err)
+		}
+		dataList := make([]api.GenericData, 0, len(fieldMappings))
+		for _, fieldMapping := range fieldMappings {
 			data, err := fieldMapping.ConvertToGenericData(dataRow, configTypeMapping)
 			if err != nil {
 				return nil, errors.ErrorWithStack(err)
@@ -372,6 +478,17 @@ func (contentFieldMapping ContentFieldMapping) Value(dataRow DataRow, configType
 	case api.MultiGroup:
 		multiGroup := api.AcousticMultiGroup{}
 		multiGroup.Type = contentFieldMapping.Type
+		if grouped, ok := dataRow.(groupedRows); ok && !contentFieldMapping.ValueAsJSON {
+			groupDataList, err := contentFieldMapping.groupedMultiGroup(grouped, configTypeMapping)
+			if err != nil {
+				return nil, errors.ErrorWithStack(err)
+			}
+			if len(groupDataList) == 0 {
+				return nil, nil
+			}
+			multiGroup.Data = groupDataList
+			return multiGroup, nil
+		}
 		valueAsJson, err := contentFieldMapping.getJSONACSVColumnValue(dataRow)
 		if err != nil {
 			return nil, errors.ErrorWithStack(err)
@@ -379,10 +496,14 @@ func (contentFieldMapping ContentFieldMapping) Value(dataRow DataRow, configType
 		if valueAsJson == nil {
 			return nil, nil
 		}
+		fieldMappings, err := applicableFieldMappings(contentFieldMapping.FieldMapping, dataRow)
+		if err != nil {
+			return nil, errors.ErrorWithStack(err)
+		}
 		group_data_list := make([][]api.GenericData, 0, len(valueAsJson))
 		for json_index, _ := range valueAsJson {
-			dataList := make([]api.GenericData, 0, len(contentFieldMapping.FieldMapping))
-			for _, fieldMapping := range contentFieldMapping.FieldMapping {
+			dataList := make([]api.GenericData, 0, len(fieldMappings))
+			for _, fieldMapping := range fieldMappings {
 				jSONListIndexableField := fieldMapping.ToJSONListIndexableContentFieldMapping(json_index)
 				jSONListIndexableField.ValueAsJSON = contentFieldMapping.ValueAsJSON
 				jSONListIndexableField.CsvProperty = contentFieldMapping.CsvProperty
@@ -397,8 +518,21 @@ func (contentFieldMapping ContentFieldMapping) Value(dataRow DataRow, configType
 
 		multiGroup.Data = group_data_list
 		return multiGroup, nil
-	case api.File:
-		value, err := contentFieldMapping.getCsvValueOrStaticValue(dataRow)
+	case api.Date, api.DateTime:
+		value, err := contentFieldMapping.getMappedValue(dataRow, configTypeMapping)
+		if err != nil {
+			return nil, errors.ErrorWithStack(err)
+		}
+		if value == "" {
+			return nil, nil
+		}
+		return api.AcousticDateTime{
+			Value:    value,
+			Layouts:  contentFieldMapping.DateTimeLayouts,
+			TimeZone: contentFieldMapping.TimeZone,
+		}, nil
+	case api.File, api.Video:
+		value, err := contentFieldMapping.getMappedValue(dataRow, configTypeMapping)
 		if err != nil {
 			return nil, errors.ErrorWithStack(err)
 		}
@@ -424,7 +558,7 @@ func (contentFieldMapping ContentFieldMapping) Value(dataRow DataRow, configType
 		}
 		return asset, nil
 	case api.Image:
-		value, err := contentFieldMapping.getCsvValueOrStaticValue(dataRow)
+		value, err := contentFieldMapping.getMappedValue(dataRow, configTypeMapping)
 		if err != nil {
 			return nil, errors.ErrorWithStack(err)
 		}
@@ -455,7 +589,7 @@ func (contentFieldMapping ContentFieldMapping) Value(dataRow DataRow, configType
 		image.Value = value
 		return image, nil
 	case api.MultiImage:
-		value, err := contentFieldMapping.getCsvValueOrStaticValue(dataRow)
+		value, err := contentFieldMapping.getMappedValue(dataRow, configTypeMapping)
 		if err != nil {
 			return nil, errors.ErrorWithStack(err)
 		}
@@ -492,7 +626,7 @@ func (contentFieldMapping ContentFieldMapping) Value(dataRow DataRow, configType
 			Assets: convertedImageAssets,
 		}, nil
 	case api.Reference:
-		value, err := contentFieldMapping.getCsvValueOrStaticValue(dataRow)
+		value, err := contentFieldMapping.getMappedValue(dataRow, configTypeMapping)
 		if err != nil {
 			return nil, errors.ErrorWithStack(err)
 		}
@@ -505,7 +639,7 @@ func (contentFieldMapping ContentFieldMapping) Value(dataRow DataRow, configType
 		}
 		return reference, nil
 	case api.MultiReference:
-		value, err := contentFieldMapping.getCsvValueOrStaticValue(dataRow)
+		value, err := contentFieldMapping.getMappedValue(dataRow, configTypeMapping)
 		if err != nil {
 			return nil, errors.ErrorWithStack(err)
 		}
@@ -526,7 +660,7 @@ func (contentFieldMapping ContentFieldMapping) Value(dataRow DataRow, configType
 			Operation:  contentFieldMapping.Operation,
 		}, nil
 	case api.Text:
-		value, err := contentFieldMapping.getCsvValueOrStaticValue(dataRow)
+		value, err := contentFieldMapping.getMappedValue(dataRow, configTypeMapping)
 		if err != nil {
 			return nil, errors.ErrorWithStack(err)
 		}
@@ -547,7 +681,7 @@ func (contentFieldMapping ContentFieldMapping) Value(dataRow DataRow, configType
 			LoadFromFile: contentFieldMapping.LoadFromFile,
 		}, nil
 	default:
-		value, err := contentFieldMapping.getCsvValueOrStaticValue(dataRow)
+		value, err := contentFieldMapping.getMappedValue(dataRow, configTypeMapping)
 		if err != nil {
 			return nil, errors.ErrorWithStack(err)
 		}
@@ -567,6 +701,9 @@ func createAcousticReference(value string, dataRow DataRow, configTypeMapping *C
 	reference.Tags = append(contentFieldMapping.RefContentTypeMapping.Tags, configTypeMapping.Tags...)
 	reference.SearchType = contentFieldMapping.SearchType
 	reference.SearchOnDeliveryAPI = contentFieldMapping.SearchOnDeliveryAPI
+	if contentFieldMapping.LinkByKey {
+		reference.RecordKey = value
+	}
 
 	if !contentFieldMapping.AlwaysNew {
 		reference.SearchValues = make([]string, 0)
@@ -576,8 +713,12 @@ func createAcousticReference(value string, dataRow DataRow, configTypeMapping *C
 		reference.SearchTerm = contentFieldMapping.SearchTerm
 		reference.SearchOnLibrary = contentFieldMapping.SearchOnLibrary
 	} else {
-		dataList := make([]api.GenericData, 0, len(contentFieldMapping.RefContentTypeMapping.FieldMapping))
-		for _, fieldMapping := range contentFieldMapping.FieldMapping {
+		fieldMappings, err := applicableFieldMappings(contentFieldMapping.FieldMapping, dataRow)
+		if err != nil {
+			return api.AcousticReference{}, errors.ErrorWithStack(err)
+		}
+		dataList := make([]api.GenericData, 0, len(fieldMappings))
+		for _, fieldMapping := range fieldMappings {
 			data, err := fieldMapping.ConvertToGenericData(dataRow, configTypeMapping)
 			if err != nil {
 				return api.AcousticReference{}, errors.ErrorWithStack(err)
@@ -670,12 +811,24 @@ func (csvContentTypeMapping *ContentTypeMapping) GetFieldMappingByAcousticField(
 	}
 }
 
+func (csvContentTypeMapping *ContentTypeMapping) filterFileDialect() CSVDialect {
+	if csvContentTypeMapping.FilterFileDialect.isZero() {
+		return csvContentTypeMapping.CSVDialect
+	}
+	return csvContentTypeMapping.FilterFileDialect
+}
+
+// GetAcousticFields are the mapped properties , a property with alternative mappings is listed once
 func (csvContentTypeMapping *ContentTypeMapping) GetAcousticFields() []string {
-	return koazee.StreamOf(csvContentTypeMapping.FieldMapping).
-		Map(func(contentFieldMapping ContentFieldMapping) string {
-			return contentFieldMapping.AcousticProperty
-		}).
-		Out().Val().([]string)
+	acousticFields := make([]string, 0, len(csvContentTypeMapping.FieldMapping))
+	mapped := make(map[string]bool, len(csvContentTypeMapping.FieldMapping))
+	for _, contentFieldMapping := range csvContentTypeMapping.FieldMapping {
+		if !mapped[contentFieldMapping.AcousticProperty] {
+			mapped[contentFieldMapping.AcousticProperty] = true
+			acousticFields = append(acousticFields, contentFieldMapping.AcousticProperty)
+		}
+	}
+	return acousticFields
 }
 
 type CSVToAcousticFieldMapping struct {
@@ -782,6 +935,8 @@ type Config interface {
 
 type config struct {
 	mappings *ContentTypesMapping
+	// the lookups are loaded on the first content type or site mapping read , the other mappings do not use them
+	lookups lookupTables
 }
 
 func InitContentTypeMappingConfig(configPath string) (Config, error) {
@@ -800,6 +955,27 @@ func InitContentTypeMappingConfig(configPath string) (Config, error) {
 	}
 }
 
+// ConfigContentTypes returns the content types of the content type and site mappings of the config , without logging
+// as it is used by the shell completion
+func ConfigContentTypes(configPath string) ([]string, error) {
+	configContent, err := ioutil.ReadFile(configPath)
+	if err != nil {
+		return nil, errors.ErrorWithStack(err)
+	}
+	mappings := &ContentTypesMapping{}
+	if err := yaml.Unmarshal(configContent, mappings); err != nil {
+		return nil, errors.ErrorWithStack(err)
+	}
+	contentTypes := make([]string, 0, len(mappings.ContentType)+len(mappings.SiteMapping))
+	for _, contentTypeMapping := range mappings.ContentType {
+		contentTypes = append(contentTypes, contentTypeMapping.Type)
+	}
+	for _, siteMapping := range mappings.SiteMapping {
+		contentTypes = append(contentTypes, siteMapping.Type)
+	}
+	return funk.UniqString(contentTypes), nil
+}
+
 func (config config) GetCategory(categoryName string) (*CategoryMapping, error) {
 	categoryMapping := koazee.StreamOf(config.mappings.CategoryMapping).
 		Filter(func(mapping CategoryMapping) bool {
@@ -817,6 +993,11 @@ func (config *config) GetContentType(contentType string) (*ContentTypeMapping, e
 		if contentTypeMapping, err := config.mappings.GetContentTypeMapping(contentType); err != nil {
 			return nil, errors.ErrorWithStack(err)
 		} else {
+			lookups, err := config.getLookups()
+			if err != nil {
+				return nil, errors.ErrorWithStack(err)
+			}
+			contentTypeMapping.lookups = lookups
 			return contentTypeMapping, nil
 		}
 	} else {
@@ -836,7 +1017,18 @@ func (config *config) GetDeleteMapping(name string) (*DeleteMapping, error) {
 	return &deleteMapping, nil
 }
 
-func (config config) GetSiteMapping(pageContentType string) (*SiteMapping, error) {
+func (config *config) getLookups() (lookupTables, error) {
+	if config.lookups == nil {
+		lookups, err := loadLookups(config.mappings.LookupMapping)
+		if err != nil {
+			return nil, errors.ErrorWithStack(err)
+		}
+		config.lookups = lookups
+	}
+	return config.lookups, nil
+}
+
+func (config *config) GetSiteMapping(pageContentType string) (*SiteMapping, error) {
 	siteMapping := koazee.StreamOf(config.mappings.SiteMapping).
 		Filter(func(siteMapping SiteMapping) bool {
 			return siteMapping.Type == pageContentType
@@ -845,5 +1037,10 @@ func (config config) GetSiteMapping(pageContentType string) (*SiteMapping, error
 	if &siteMapping == nil {
 		return nil, errors.ErrorMessageWithStack("No site mapping found for provided page content type :" + pageContentType)
 	}
+	lookups, err := config.getLookups()
+	if err != nil {
+		return nil, errors.ErrorWithStack(err)
+	}
+	siteMapping.lookups = lookups
 	return &siteMapping, nil
 }
@@ -0,0 +1,672 @@
+package csv
+
+import (
+	"github.com/dekanayake/acoustic-content-sync/pkg/acoustic/author/api"
+	"github.com/dekanayake/acoustic-content-sync/pkg/errors"
+	"github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/parser"
+	log "github.com/sirupsen/logrus"
+	"io/ioutil"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type ConfigProblem struct {
+	Line    int
+	Path    string
+	Message string
+}
+
+func (problem ConfigProblem) String() string {
+	if problem.Line > 0 {
+		return "line " + strconv.Itoa(problem.Line) + " : " + problem.Path + " : " + problem.Message
+	}
+	return problem.Path + " : " + problem.Message
+}
+
+// ConfigValidator checks a config file up front , instead of the problems surfacing row by row in the middle of a run.
+// All the problems found are reported in one pass with the line number of the config
+type ConfigValidator interface {
+	Validate(configPath string, sampleFeedPath string, contentType string) ([]ConfigProblem, error)
+}
+
+type configValidator struct {
+}
+
+type configValidation struct {
+	lines    map[string]int
+	problems []ConfigProblem
+	// the names of the lookups of the config , the field mappings can only use these
+	lookupNames map[string]bool
+}
+
+type fieldMappingScope struct {
+	headers map[string]bool
+	// the child mappings of a multi group read the json keys of the parent column instead of the feed columns
+	multiGroupChild bool
+	// the rows sharing the record key are collapsed in to a record
+	groupedRows bool
+	// the mappings are the child mappings of a group or a reference
+	nested bool
+}
+
+func (scope fieldMappingScope) child() fieldMappingScope {
+	scope.nested = true
+	return scope
+}
+
+func NewConfigValidator() ConfigValidator {
+	return &configValidator{}
+}
+
+func (c configValidator) Validate(configPath string, sampleFeedPath string, contentType string) ([]ConfigProblem, error) {
+	configContent, err := ioutil.ReadFile(configPath)
+	if err != nil {
+		return nil, errors.ErrorWithStack(err)
+	}
+	validation := &configValidation{
+		lines:    make(map[string]int),
+		problems: make([]ConfigProblem, 0),
+	}
+	configFile, err := parser.ParseBytes(configContent, 0)
+	if err != nil {
+		validation.problems = append(validation.problems, ConfigProblem{Path: configPath, Message: err.Error()})
+		return validation.problems, nil
+	}
+	for _, doc := range configFile.Docs {
+		validation.walk(doc.Body, reflect.TypeOf(ContentTypesMapping{}), "")
+	}
+	mappings := &ContentTypesMapping{}
+	if err := yaml.Unmarshal(configContent, mappings); err != nil {
+		validation.problems = append(validation.problems, ConfigProblem{Path: configPath, Message: err.Error()})
+		return validation.sortedProblems(), nil
+	}
+
+	validation.validateLookups(mappings.LookupMapping)
+	contentTypeFound := contentType == ""
+	for index, contentTypeMapping := range mappings.ContentType {
+		if contentType != "" && contentTypeMapping.Type != contentType {
+			continue
+		}
+		contentTypeFound = true
+		path := "contentType[" + strconv.Itoa(index) + "]"
+		headers, err := validation.sampleHeaders(path, sampleFeedPath, &contentTypeMapping)
+		if err != nil {
+			return nil, errors.ErrorWithStack(err)
+		}
+		validation.validateContentTypeMapping(path, contentTypeMapping, headers)
+	}
+	for index, siteMapping := range mappings.SiteMapping {
+		if contentType != "" && siteMapping.Type != contentType {
+			continue
+		}
+		contentTypeFound = true
+		path := "site[" + strconv.Itoa(index) + "]"
+		headers, err := validation.sampleHeaders(path, sampleFeedPath, &siteMapping.ContentTypeMapping)
+		if err != nil {
+			return nil, errors.ErrorWithStack(err)
+		}
+		validation.validateContentTypeMapping(path, siteMapping.ContentTypeMapping, headers)
+	}
+	if !contentTypeFound {
+		validation.add("contentType", "no mapping found for content type "+strconv.Quote(contentType))
+	}
+	for index, categoryMapping := range mappings.CategoryMapping {
+		path := "category[" + strconv.Itoa(index) + "]"
+		validation.required(path, "parent", categoryMapping.Parent)
+		validation.required(path, "column", categoryMapping.Column)
+	}
+	for index, deleteMapping := range mappings.DeleteMapping {
+		path := "delete[" + strconv.Itoa(index) + "]"
+		validation.required(path, "name", deleteMapping.Name)
+		switch deleteMapping.AssetType {
+		case api.DOCUMENT, api.FILE, api.IMAGE, api.VIDEO:
+		default:
+			validation.add(path+".assetType", "unsupported asset type "+strconv.Quote(string(deleteMapping.AssetType))+" , supported asset types : document, file, image, video")
+		}
+		if deleteMapping.Retire && deleteMapping.AssetType != api.DOCUMENT {
+			validation.add(path+".retire", "only the contents can be retired , the asset type should be document")
+		}
+	}
+	return validation.sortedProblems(), nil
+}
+
+// walk checks the keys of the config against the yaml keys of the config structs , and records the line of each key
+// so the problems found after the config is unmarshalled can be reported with the line number
+func (validation *configValidation) walk(node ast.Node, valueType reflect.Type, path string) {
+	for {
+		switch wrapper := node.(type) {
+		case *ast.AnchorNode:
+			node = wrapper.Value
+			continue
+		case *ast.TagNode:
+			node = wrapper.Value
+			continue
+		}
+		break
+	}
+	if node == nil {
+		return
+	}
+	if _, ok := node.(*ast.NullNode); ok {
+		return
+	}
+	if _, ok := node.(*ast.AliasNode); ok {
+		return
+	}
+	if _, ok := validation.lines[path]; !ok {
+		validation.lines[path] = node.GetToken().Position.Line
+	}
+	for valueType.Kind() == reflect.Ptr {
+		valueType = valueType.Elem()
+	}
+	switch valueType.Kind() {
+	case reflect.Struct:
+		mappingValues, ok := mappingValueNodes(node)
+		if !ok {
+			validation.add(path, "expected a mapping")
+			return
+		}
+		fields := yamlFields(valueType)
+		for _, mappingValue := range mappingValues {
+			key := mappingKey(mappingValue.Key)
+			if key == "<<" {
+				continue
+			}
+			childPath := joinConfigPath(path, key)
+			validation.lines[childPath] = mappingValue.Key.GetToken().Position.Line
+			fieldType, ok := fields[key]
+			if !ok {
+				message := "unknown key " + strconv.Quote(key)
+				for field := range fields {
+					if strings.EqualFold(field, key) {
+						message = message + " , did you mean " + strconv.Quote(field)
+					}
+				}
+				validation.add(childPath, message)
+				continue
+			}
+			validation.walk(mappingValue.Value, fieldType, childPath)
+		}
+	case reflect.Map:
+		mappingValues, ok := mappingValueNodes(node)
+		if !ok {
+			validation.add(path, "expected a mapping")
+			return
+		}
+		for _, mappingValue := range mappingValues {
+			childPath := joinConfigPath(path, mappingKey(mappingValue.Key))
+			validation.lines[childPath] = mappingValue.Key.GetToken().Position.Line
+			validation.walk(mappingValue.Value, valueType.Elem(), childPath)
+		}
+	case reflect.Slice:
+		sequence, ok := node.(*ast.SequenceNode)
+		if !ok {
+			validation.add(path, "expected a list")
+			return
+		}
+		for index, item := range sequence.Values {
+			validation.walk(item, valueType.Elem(), path+"["+strconv.Itoa(index)+"]")
+		}
+	}
+}
+
+func mappingValueNodes(node ast.Node) ([]*ast.MappingValueNode, bool) {
+	switch mapping := node.(type) {
+	case *ast.MappingNode:
+		return mapping.Values, true
+	case *ast.MappingValueNode:
+		return []*ast.MappingValueNode{mapping}, true
+	default:
+		return nil, false
+	}
+}
+
+func mappingKey(node ast.Node) string {
+	if stringNode, ok := node.(*ast.StringNode); ok {
+		return stringNode.Value
+	}
+	return node.GetToken().Value
+}
+
+// yamlFields returns the yaml keys of a config struct , named the same way the yaml decoder does
+func yamlFields(structType reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		tag := field.Tag.Get("yaml")
+		if tag == "-" {
+			continue
+		}
+		options := strings.Split(tag, ",")
+		if len(options) > 1 && options[1] == "inline" {
+			for name, fieldType := range yamlFields(field.Type) {
+				fields[name] = fieldType
+			}
+			continue
+		}
+		name := options[0]
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		fields[name] = field.Type
+	}
+	return fields
+}
+
+func joinConfigPath(path string, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// lineOf returns the line of the path , or of the closest parent in the config when the path itself is not in the config
+func (validation *configValidation) lineOf(path string) int {
+	for path != "" {
+		if line, ok := validation.lines[path]; ok {
+			return line
+		}
+		cut := strings.LastIndexAny(path, ".[")
+		if cut < 0 {
+			break
+		}
+		path = path[:cut]
+	}
+	return 0
+}
+
+func (validation *configValidation) add(path string, message string) {
+	validation.problems = append(validation.problems, ConfigProblem{
+		Line:    validation.lineOf(path),
+		Path:    path,
+		Message: message,
+	})
+}
+
+func (validation *configValidation) required(path string, key string, value string) {
+	if strings.TrimSpace(value) == "" {
+		validation.add(joinConfigPath(path, key), key+" is required")
+	}
+}
+
+func (validation *configValidation) sortedProblems() []ConfigProblem {
+	sort.SliceStable(validation.problems, func(i, j int) bool {
+		return validation.problems[i].Line < validation.problems[j].Line
+	})
+	return validation.problems
+}
+
+func (validation *configValidation) sampleHeaders(path string, sampleFeedPath string, contentTypeMapping *ContentTypeMapping) (map[string]bool, error) {
+	if sampleFeedPath == "" {
+		return nil, nil
+	}
+	feedType := resolveFeedType(sampleFeedPath, contentTypeMapping.FeedType)
+	if feedType == api.JSON || feedType == api.JSONL {
+		log.Info("The attributes of a JSON feed can differ by record , csvProperty is not checked against the sample feed for :" + path)
+		return nil, nil
+	}
+	dataFeed, err := LoadFeed(sampleFeedPath, contentTypeMapping.FeedType, contentTypeMapping.FeedSheet, contentTypeMapping.CSVDialect)
+	if err != nil {
+		return nil, errors.ErrorWithStack(err)
+	}
+	defer dataFeed.Close()
+	headerList, err := dataFeed.Headers()
+	if err != nil {
+		return nil, errors.ErrorWithStack(err)
+	}
+	headers := make(map[string]bool, len(headerList))
+	for _, header := range headerList {
+		headers[header] = true
+	}
+	for _, join := range contentTypeMapping.Joins {
+		loaded, err := loadJoin(join)
+		if err != nil {
+			// the joined feed is reported by the validation of the joins , the columns are not checked without its headers
+			return nil, nil
+		}
+		for _, header := range loaded.prefixedHeaders() {
+			headers[header] = true
+		}
+	}
+	return headers, nil
+}
+
+func (validation *configValidation) validateContentTypeMapping(path string, contentTypeMapping ContentTypeMapping, headers map[string]bool) {
+	validation.required(path, "type", contentTypeMapping.Type)
+	validation.required(path, "csvRecordKey", contentTypeMapping.CsvRecordKey)
+	if len(contentTypeMapping.Name) == 0 {
+		validation.add(path+".name", "name is required")
+	}
+	if len(contentTypeMapping.FieldMapping) == 0 {
+		validation.add(path+".fieldMapping", "fieldMapping is required")
+	}
+	switch api.FeedType(strings.ToUpper(string(contentTypeMapping.FeedType))) {
+	case "", api.CSV, "ACOUSTIC", api.JSON, api.JSONL, api.XLSX:
+	default:
+		validation.add(path+".feedType", "unsupported feed type "+strconv.Quote(string(contentTypeMapping.FeedType))+" , supported feed types : CSV, JSON, JSONL, XLSX")
+	}
+	validation.validateCSVDialect(path+".csvDialect", contentTypeMapping.CSVDialect)
+	validation.validateCSVDialect(path+".filterFileDialect", contentTypeMapping.FilterFileDialect)
+	if contentTypeMapping.Workers < 0 {
+		validation.add(path+".workers", "workers should not be negative")
+	}
+	if contentTypeMapping.FilterRecords {
+		validation.required(path, "filterFileLocation", contentTypeMapping.FilterFileLocation)
+		if len(contentTypeMapping.FilterColumns) == 0 {
+			validation.add(path+".filterColumns", "filterColumns is required when filterRecords is enabled")
+		}
+	}
+
+	acousticProperties := make(map[string]bool)
+	for _, fieldMapping := range contentTypeMapping.FieldMapping {
+		acousticProperties[fieldMapping.AcousticProperty] = true
+	}
+	if contentTypeMapping.CsvRecordKey != "" && !acousticProperties[contentTypeMapping.CsvRecordKey] {
+		validation.add(path+".csvRecordKey", "csvRecordKey "+strconv.Quote(contentTypeMapping.CsvRecordKey)+" is not an acousticProperty of the field mappings")
+	}
+	for index, name := range contentTypeMapping.Name {
+		if !acousticProperties[name] {
+			validation.add(path+".name["+strconv.Itoa(index)+"]", "name field "+strconv.Quote(name)+" is not an acousticProperty of the field mappings")
+		}
+	}
+	for index, searchKey := range contentTypeMapping.SearchKeys {
+		if !acousticProperties[searchKey] {
+			validation.add(path+".searchKeys["+strconv.Itoa(index)+"]", "search key "+strconv.Quote(searchKey)+" is not an acousticProperty of the field mappings")
+		}
+	}
+	if contentTypeMapping.KeyIndex {
+		if len(contentTypeMapping.SearchKeys) == 0 {
+			validation.add(path+".keyIndex", "searchKeys is required to index the contents by key")
+		}
+		validation.required(path, "searchType", contentTypeMapping.SearchType)
+	}
+	if contentTypeMapping.DuplicatePolicy != "" {
+		policies := make([]string, 0, len(api.DuplicatePolicies))
+		supported := false
+		for _, policy := range api.DuplicatePolicies {
+			policies = append(policies, string(policy))
+			supported = supported || policy == contentTypeMapping.DuplicatePolicy
+		}
+		if !supported {
+			validation.add(path+".duplicatePolicy", "unsupported duplicatePolicy "+strconv.Quote(string(contentTypeMapping.DuplicatePolicy))+" , supported values : "+strings.Join(policies, ", "))
+		}
+	}
+	switch contentTypeMapping.GroupRows {
+	case "", GROUP_CONSECUTIVE_ROWS, GROUP_KEYED_ROWS:
+	default:
+		validation.add(path+".groupRows", "unsupported groupRows "+strconv.Quote(string(contentTypeMapping.GroupRows))+" , supported values : consecutive, keyed")
+	}
+	validation.validateCondition(path+".when", contentTypeMapping.When, headers)
+	for index, include := range contentTypeMapping.Include {
+		validation.validateCondition(path+".include["+strconv.Itoa(index)+"]", include, headers)
+	}
+	for index, exclude := range contentTypeMapping.Exclude {
+		validation.validateCondition(path+".exclude["+strconv.Itoa(index)+"]", exclude, headers)
+	}
+	for index, fieldMapping := range contentTypeMapping.FieldMapping {
+		if fieldMapping.When != "" && fieldMapping.AcousticProperty == contentTypeMapping.CsvRecordKey {
+			validation.add(path+".fieldMapping["+strconv.Itoa(index)+"].when", "the mapping of the csvRecordKey can not have a when condition")
+		}
+	}
+	validation.validateJoins(path, contentTypeMapping.Joins, headers)
+	validation.validateFieldMappings(path+".fieldMapping", contentTypeMapping.FieldMapping, fieldMappingScope{headers: headers, groupedRows: contentTypeMapping.GroupRows != ""})
+}
+
+func (validation *configValidation) validateCSVDialect(path string, dialect CSVDialect) {
+	problems := dialect.check()
+	keys := make([]string, 0, len(problems))
+	for key := range problems {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		validation.add(path+"."+key, problems[key].Error())
+	}
+}
+
+// validateCondition checks the when expression can be parsed and the columns it reads are in the header of the sample feed
+func (validation *configValidation) validateCondition(path string, expression string, headers map[string]bool) {
+	if expression == "" {
+		return
+	}
+	condition, err := compileCondition(expression)
+	if err != nil {
+		validation.add(path, err.Error())
+		return
+	}
+	if headers == nil {
+		return
+	}
+	for _, column := range condition.Columns() {
+		if !headers[column] {
+			validation.add(path, "column "+strconv.Quote(column)+" of the when condition is not in the header of the sample feed")
+		}
+	}
+}
+
+// validateJoins checks the joins have a unique name , a key in the sample feed and a joined feed which can be loaded
+func (validation *configValidation) validateJoins(path string, joins []FeedJoin, headers map[string]bool) {
+	names := make(map[string]bool, len(joins))
+	for index, join := range joins {
+		joinPath := path + ".joins[" + strconv.Itoa(index) + "]"
+		validation.required(joinPath, "name", join.Name)
+		validation.required(joinPath, "feed", join.Feed)
+		validation.required(joinPath, "key", join.Key)
+		if names[join.Name] {
+			validation.add(joinPath+".name", "join name "+strconv.Quote(join.Name)+" is used by another join")
+		}
+		names[join.Name] = true
+		switch join.JoinType {
+		case "", INNER_JOIN, LEFT_JOIN:
+		default:
+			validation.add(joinPath+".joinType", "unsupported join type "+strconv.Quote(string(join.JoinType))+" , supported join types : inner, left")
+		}
+		validation.validateCSVDialect(joinPath+".csvDialect", join.CSVDialect)
+		if headers != nil && join.Key != "" && !headers[join.Key] {
+			validation.add(joinPath+".key", "key "+strconv.Quote(join.Key)+" is not a column of the sample feed")
+		}
+		if join.Feed == "" || join.Key == "" {
+			continue
+		}
+		if _, err := loadJoin(join); err != nil {
+			validation.add(joinPath+".feed", err.Error())
+		}
+	}
+}
+
+func (validation *configValidation) validateFieldMappings(path string, fieldMappings []ContentFieldMapping, scope fieldMappingScope) {
+	// the properties mapped without a when condition , an alternative mapping after such a mapping is never used
+	acousticProperties := make(map[string]bool)
+	for index, fieldMapping := range fieldMappings {
+		fieldPath := path + "[" + strconv.Itoa(index) + "]"
+		if fieldMapping.AcousticProperty != "" {
+			if acousticProperties[fieldMapping.AcousticProperty] {
+				validation.add(fieldPath+".acousticProperty", "acousticProperty "+strconv.Quote(fieldMapping.AcousticProperty)+" is mapped more than once , a property can be mapped again only after a mapping with a when condition")
+			}
+			if fieldMapping.When == "" {
+				acousticProperties[fieldMapping.AcousticProperty] = true
+			}
+		}
+		validation.validateFieldMapping(fieldPath, fieldMapping, scope)
+	}
+}
+
+func (validation *configValidation) validateFieldMapping(path string, fieldMapping ContentFieldMapping, scope fieldMappingScope) {
+	validation.required(path, "acousticProperty", fieldMapping.AcousticProperty)
+	propertyType := api.FieldType(fieldMapping.PropertyType)
+	if fieldMapping.PropertyType == "" {
+		validation.add(path+".propertyType", "propertyType is required")
+	} else if _, err := api.Build(fieldMapping.PropertyType); err != nil {
+		supportedFieldTypes := make([]string, 0)
+		for _, fieldType := range api.SupportedFieldTypes() {
+			supportedFieldTypes = append(supportedFieldTypes, string(fieldType))
+		}
+		validation.add(path+".propertyType", "unsupported property type "+strconv.Quote(fieldMapping.PropertyType)+" , supported property types : "+strings.Join(supportedFieldTypes, ", "))
+	}
+	if err := fieldMapping.Validate(scope.groupedRows); err != nil {
+		validation.add(path, err.Error())
+	}
+
+	if fieldMapping.StaticValue != "" && fieldMapping.CsvProperty != "" {
+		validation.add(path+".staticValue", "staticValue and csvProperty are both set , csvProperty should be removed when a static value is used")
+	}
+	if fieldMapping.StaticValue != "" && fieldMapping.JoinedValue != "" {
+		validation.add(path+".joinedValue", "joinedValue and staticValue are both set , only one of them is used")
+	}
+	hasValueSource := fieldMapping.CsvProperty != "" || fieldMapping.StaticValue != "" || fieldMapping.JoinedValue != ""
+	if propertyType == api.MultiGroup && scope.groupedRows && !fieldMapping.ValueAsJSON {
+		// the items are read from the rows of the record
+		hasValueSource = true
+	}
+	if !hasValueSource && !scope.multiGroupChild && propertyType != api.Group && fieldMapping.PropertyType != "" {
+		validation.add(path, "one of csvProperty , staticValue or joinedValue is required")
+	}
+	if scope.multiGroupChild && fieldMapping.JSONKey == "" {
+		validation.add(path+".JSONKey", "JSONKey is required for the field mappings of a "+string(api.MultiGroup))
+	}
+	if !scope.multiGroupChild {
+		validation.validateValueSource(path, fieldMapping, scope.headers)
+	}
+	validation.validateCondition(path+".when", fieldMapping.When, scope.headers)
+	for index, regx := range fieldMapping.Regx {
+		if _, err := regexp.Compile(regx); err != nil {
+			validation.add(path+".regx["+strconv.Itoa(index)+"]", "invalid regx : "+err.Error())
+		}
+	}
+	for index, regx := range fieldMapping.SanitizeConfig.Regx {
+		if _, err := regexp.Compile(regx); err != nil {
+			validation.add(path+".sanitizeConfig.regx["+strconv.Itoa(index)+"]", "invalid regx : "+err.Error())
+		}
+	}
+	if fieldMapping.Lookup != "" && !validation.lookupNames[fieldMapping.Lookup] {
+		validation.add(path+".lookup", "no lookup found with the name "+strconv.Quote(fieldMapping.Lookup))
+	}
+	switch fieldMapping.LookupMissing {
+	case "", LOOKUP_MISSING_FAIL, LOOKUP_MISSING_SKIP, LOOKUP_MISSING_PASS_THROUGH:
+	case LOOKUP_MISSING_DEFAULT:
+		validation.required(path, "lookupDefault", fieldMapping.LookupDefault)
+	default:
+		policies := make([]string, 0, len(lookupMissingPolicies))
+		for _, policy := range lookupMissingPolicies {
+			policies = append(policies, string(policy))
+		}
+		validation.add(path+".lookupMissing", "unsupported lookupMissing "+strconv.Quote(string(fieldMapping.LookupMissing))+" , supported values : "+strings.Join(policies, ", "))
+	}
+	for index, transform := range fieldMapping.Transforms {
+		if err := checkTransform(transform); err != nil {
+			validation.add(path+".transforms["+strconv.Itoa(index)+"]", err.Error())
+		}
+	}
+	switch fieldMapping.Operation {
+	case "", api.DELETE, api.UPDATE, api.CREATE, api.DEFAULT_OPERATION:
+	default:
+		validation.add(path+".operation", "unsupported operation "+strconv.Quote(string(fieldMapping.Operation))+" , supported operations : create, update, delete")
+	}
+
+	switch propertyType {
+	case api.Category, api.CategoryPart:
+		validation.required(path, "categoryName", fieldMapping.CategoryName)
+	case api.Date, api.DateTime:
+		if fieldMapping.TimeZone != "" {
+			if _, err := time.LoadLocation(fieldMapping.TimeZone); err != nil {
+				validation.add(path+".timeZone", "unknown timeZone "+strconv.Quote(fieldMapping.TimeZone))
+			}
+		}
+	case api.File, api.Video, api.Image, api.MultiImage:
+		validation.required(path, "acousticAssetBasePath", fieldMapping.AcousticAssetBasePath)
+		if !fieldMapping.IsWebUrl {
+			validation.required(path, "assetLocation", fieldMapping.AssetLocation)
+		}
+		for index, assetName := range fieldMapping.AssetName.AssetName {
+			assetNamePath := path + ".assetNameConfig.assetName[" + strconv.Itoa(index) + "]"
+			validation.required(assetNamePath, "propertyName", assetName.PropertyName)
+			validation.validateValueSource(assetNamePath, assetName.ContentFieldMapping, scope.headers)
+		}
+	case api.Group:
+		validation.required(path, "type", fieldMapping.Type)
+		if len(fieldMapping.FieldMapping) == 0 {
+			validation.add(path+".fieldMapping", "fieldMapping is required for a "+string(api.Group))
+		}
+		validation.validateFieldMappings(path+".fieldMapping", fieldMapping.FieldMapping, scope.child())
+	case api.MultiGroup:
+		validation.required(path, "type", fieldMapping.Type)
+		if scope.groupedRows && !fieldMapping.ValueAsJSON {
+			validation.validateFieldMappings(path+".fieldMapping", fieldMapping.FieldMapping, scope.child())
+		} else {
+			validation.validateFieldMappings(path+".fieldMapping", fieldMapping.FieldMapping, fieldMappingScope{multiGroupChild: true, nested: true})
+		}
+	case api.Reference, api.MultiReference:
+		if fieldMapping.LinkByKey {
+			validation.required(path+".refContentTypeMapping", "type", fieldMapping.RefContentTypeMapping.Type)
+			if fieldMapping.AlwaysNew {
+				validation.add(path+".linkByKey", "linkByKey and alwaysNew are both set , a linked reference is not created as new")
+			}
+			if scope.nested {
+				validation.add(path+".linkByKey", "linkByKey is supported only for the field mappings of the content type")
+			}
+			if strings.Contains(fieldMapping.SearchTerm, "%") && len(fieldMapping.SearchKeys) == 0 {
+				validation.add(path+".searchKeys", "searchKeys is required to fill the values of the searchTerm")
+			}
+		} else if fieldMapping.AlwaysNew {
+			validation.required(path+".refContentTypeMapping", "type", fieldMapping.RefContentTypeMapping.Type)
+			if len(fieldMapping.FieldMapping) == 0 {
+				validation.add(path+".fieldMapping", "fieldMapping is required for a reference with alwaysNew")
+			}
+			validation.validateFieldMappings(path+".fieldMapping", fieldMapping.FieldMapping, scope.child())
+		} else {
+			validation.required(path, "searchTerm", fieldMapping.SearchTerm)
+			if strings.Contains(fieldMapping.SearchTerm, "%") && len(fieldMapping.SearchKeys) == 0 {
+				validation.add(path+".searchKeys", "searchKeys is required to fill the values of the searchTerm")
+			}
+		}
+	}
+}
+
+// validateLookups checks the lookups have a unique name and their files can be loaded
+func (validation *configValidation) validateLookups(lookups []LookupMapping) {
+	validation.lookupNames = make(map[string]bool, len(lookups))
+	for index, lookup := range lookups {
+		path := "lookups[" + strconv.Itoa(index) + "]"
+		validation.required(path, "name", lookup.Name)
+		validation.required(path, "file", lookup.File)
+		if validation.lookupNames[lookup.Name] {
+			validation.add(path+".name", "lookup name "+strconv.Quote(lookup.Name)+" is used by another lookup")
+		}
+		validation.lookupNames[lookup.Name] = true
+		if lookup.File == "" {
+			continue
+		}
+		if _, err := loadLookup(lookup); err != nil {
+			validation.add(path+".file", err.Error())
+		}
+	}
+}
+
+// validateValueSource checks the feed columns read by the mapping are in the header of the sample feed
+func (validation *configValidation) validateValueSource(path string, fieldMapping ContentFieldMapping, headers map[string]bool) {
+	if headers == nil {
+		return
+	}
+	if fieldMapping.CsvProperty != "" && fieldMapping.StaticValue == "" && fieldMapping.JoinedValue == "" && !headers[fieldMapping.CsvProperty] {
+		validation.add(path+".csvProperty", "column "+strconv.Quote(fieldMapping.CsvProperty)+" is not in the header of the sample feed")
+	}
+	if fieldMapping.JoinedValue != "" {
+		variableRegx := regexp.MustCompile(JOIN_VALUE_VAR_REGX)
+		variableSymbolRegx := regexp.MustCompile(JOIN_VALUE_VAR_SYMBOL_REGX)
+		for _, matchedVariable := range variableRegx.FindAllString(fieldMapping.JoinedValue, -1) {
+			column := variableSymbolRegx.ReplaceAllString(matchedVariable, "")
+			if !headers[column] {
+				validation.add(path+".joinedValue", "column "+strconv.Quote(column)+" of the joined value is not in the header of the sample feed")
+			}
+		}
+	}
+}
@@ -319,7 +319,15 @@ func (c contentCopyUserCase) CopyContent(id string, fileNamePostFix string) (*Co
 		return nil, err
 	}
 	log.Info(" cloned validation status :" + strconv.FormatBool(valid))
-	return nil, nil
+	return &ContentCreationStatus{
+		Success: []ContentCreationSuccessStatus{{
+			CSVIDKey:   "id",
+			CSVIDValue: id,
+			ContentID:  clonedRootContentID,
+			Action:     api.CONTENT_CREATED,
+			Duration:   time.Since(clonedStartedTime),
+		}},
+	}, nil
 }
 
 func (c contentCopyUserCase) verifyCloneContents(id string, cloneStartedTime time.Time, fileNamePostfix string) (bool, error) {
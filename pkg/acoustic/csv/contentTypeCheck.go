@@ -0,0 +1,228 @@
+package csv
+
+import (
+	"github.com/dekanayake/acoustic-content-sync/pkg/acoustic/author/api"
+	"github.com/dekanayake/acoustic-content-sync/pkg/env"
+	"github.com/dekanayake/acoustic-content-sync/pkg/errors"
+	"github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/parser"
+	"io/ioutil"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ContentTypeChecker compares the field mappings of a config with the element definitions of the content types in acoustic ,
+// so a wrong acousticProperty or property type is reported before the ingestion instead of as a 400 from acoustic
+type ContentTypeChecker interface {
+	Check(configPath string, contentType string) ([]ConfigProblem, error)
+}
+
+type contentTypeChecker struct {
+	contentTypeClient api.ContentTypeClient
+}
+
+type contentTypeCheck struct {
+	validation        *configValidation
+	contentTypeClient api.ContentTypeClient
+	definitions       map[string]api.ContentTypeDefinition
+}
+
+func NewContentTypeChecker(acousticApiUrl string) ContentTypeChecker {
+	return NewContentTypeCheckerWithClient(api.NewContentTypeClient(acousticApiUrl))
+}
+
+// NewContentTypeCheckerWithClient returns a content type checker reading the content types with the given client
+func NewContentTypeCheckerWithClient(contentTypeClient api.ContentTypeClient) ContentTypeChecker {
+	return &contentTypeChecker{
+		contentTypeClient: contentTypeClient,
+	}
+}
+
+func (c contentTypeChecker) Check(configPath string, contentType string) ([]ConfigProblem, error) {
+	configContent, err := ioutil.ReadFile(configPath)
+	if err != nil {
+		return nil, errors.ErrorWithStack(err)
+	}
+	configFile, err := parser.ParseBytes(configContent, 0)
+	if err != nil {
+		return nil, errors.ErrorWithStack(err)
+	}
+	mappings := &ContentTypesMapping{}
+	if err := yaml.Unmarshal(configContent, mappings); err != nil {
+		return nil, errors.ErrorWithStack(err)
+	}
+	check := &contentTypeCheck{
+		validation: &configValidation{
+			lines:    make(map[string]int),
+			problems: make([]ConfigProblem, 0),
+		},
+		contentTypeClient: c.contentTypeClient,
+		definitions:       make(map[string]api.ContentTypeDefinition),
+	}
+	// only the lines are needed , the problems of the config keys are reported by the config validator
+	for _, doc := range configFile.Docs {
+		check.validation.walk(doc.Body, reflect.TypeOf(ContentTypesMapping{}), "")
+	}
+	check.validation.problems = make([]ConfigProblem, 0)
+
+	for index, contentTypeMapping := range mappings.ContentType {
+		if contentType != "" && contentTypeMapping.Type != contentType {
+			continue
+		}
+		if err := check.checkContentTypeMapping("contentType["+strconv.Itoa(index)+"]", contentTypeMapping); err != nil {
+			return nil, errors.ErrorWithStack(err)
+		}
+	}
+	for index, siteMapping := range mappings.SiteMapping {
+		if contentType != "" && siteMapping.Type != contentType {
+			continue
+		}
+		if err := check.checkContentTypeMapping("site["+strconv.Itoa(index)+"]", siteMapping.ContentTypeMapping); err != nil {
+			return nil, errors.ErrorWithStack(err)
+		}
+	}
+	return check.validation.sortedProblems(), nil
+}
+
+func (check *contentTypeCheck) definition(id string) (api.ContentTypeDefinition, error) {
+	if definition, ok := check.definitions[id]; ok {
+		return definition, nil
+	}
+	definition, err := check.contentTypeClient.Get(id)
+	if err != nil {
+		return definition, errors.ErrorWithStack(err)
+	}
+	check.definitions[id] = definition
+	return definition, nil
+}
+
+func (check *contentTypeCheck) checkContentTypeMapping(path string, contentTypeMapping ContentTypeMapping) error {
+	if contentTypeMapping.Type == "" {
+		return nil
+	}
+	definition, err := check.definition(contentTypeMapping.Type)
+	if err != nil {
+		return errors.ErrorWithStack(err)
+	}
+	// an update only changes the mapped elements of an existing content , the required elements are already set
+	createsContent := !contentTypeMapping.Update || contentTypeMapping.CreateNonExistingItems
+	return check.checkFieldMappings(path+".fieldMapping", contentTypeMapping.FieldMapping, definition, createsContent)
+}
+
+func (check *contentTypeCheck) checkFieldMappings(path string, fieldMappings []ContentFieldMapping, definition api.ContentTypeDefinition, createsContent bool) error {
+	mapped := make(map[string]bool)
+	for index, fieldMapping := range fieldMappings {
+		mapped[fieldMapping.AcousticProperty] = true
+		if err := check.checkFieldMapping(path+"["+strconv.Itoa(index)+"]", fieldMapping, definition, createsContent); err != nil {
+			return err
+		}
+	}
+	if createsContent {
+		for _, element := range definition.Elements {
+			if element.Required && !mapped[element.Key] {
+				check.validation.add(path, "required element "+strconv.Quote(element.Key)+" of the content type "+definitionName(definition)+" is not mapped")
+			}
+		}
+	}
+	return nil
+}
+
+func (check *contentTypeCheck) checkFieldMapping(path string, fieldMapping ContentFieldMapping, definition api.ContentTypeDefinition, createsContent bool) error {
+	if fieldMapping.AcousticProperty == "" {
+		return nil
+	}
+	element, ok := definition.Element(fieldMapping.AcousticProperty)
+	if !ok {
+		elementKeys := make([]string, 0, len(definition.Elements))
+		for _, element := range definition.Elements {
+			elementKeys = append(elementKeys, element.Key)
+		}
+		check.validation.add(path+".acousticProperty", "acousticProperty "+strconv.Quote(fieldMapping.AcousticProperty)+" is not an element of the content type "+definitionName(definition)+" , elements : "+strings.Join(elementKeys, ", "))
+		return nil
+	}
+	propertyType := api.FieldType(fieldMapping.PropertyType)
+	acousticFieldType, err := propertyType.Convert()
+	if err != nil {
+		// unsupported property types are reported by the config validator
+		return nil
+	}
+	if string(acousticFieldType) != element.ElementType {
+		check.validation.add(path+".propertyType", "propertyType "+strconv.Quote(fieldMapping.PropertyType)+" maps to a "+strconv.Quote(string(acousticFieldType))+" element but "+strconv.Quote(element.Key)+" is a "+strconv.Quote(element.ElementType)+" element")
+		return nil
+	}
+	if propertyType != api.Category && propertyType != api.CategoryPart {
+		if multiple := isMultiFieldType(propertyType); multiple != element.AllowMultipleValues {
+			if multiple {
+				check.validation.add(path+".propertyType", "propertyType "+strconv.Quote(fieldMapping.PropertyType)+" sets multiple values but the element "+strconv.Quote(element.Key)+" does not allow multiple values")
+			} else {
+				check.validation.add(path+".propertyType", "propertyType "+strconv.Quote(fieldMapping.PropertyType)+" sets a single value but the element "+strconv.Quote(element.Key)+" allows multiple values , use the multi property type")
+			}
+		}
+	}
+
+	switch propertyType {
+	case api.OptionSelection, api.MultiOptionSelection:
+		if fieldMapping.StaticValue != "" {
+			check.checkOptions(path+".staticValue", fieldMapping.StaticValue, propertyType, element)
+		}
+	case api.Group, api.MultiGroup:
+		if element.TypeRef != nil && element.TypeRef.ID != "" {
+			groupDefinition, err := check.definition(element.TypeRef.ID)
+			if err != nil {
+				return errors.ErrorWithStack(err)
+			}
+			return check.checkFieldMappings(path+".fieldMapping", fieldMapping.FieldMapping, groupDefinition, createsContent)
+		}
+	case api.Reference, api.MultiReference:
+		if fieldMapping.AlwaysNew && fieldMapping.RefContentTypeMapping.Type != "" {
+			if len(element.RestrictTypes) > 0 {
+				allowed := false
+				for _, restrictType := range element.RestrictTypes {
+					allowed = allowed || restrictType.ID == fieldMapping.RefContentTypeMapping.Type
+				}
+				if !allowed {
+					check.validation.add(path+".refContentTypeMapping.type", "content type "+strconv.Quote(fieldMapping.RefContentTypeMapping.Type)+" is not allowed for the reference element "+strconv.Quote(element.Key))
+				}
+			}
+			refDefinition, err := check.definition(fieldMapping.RefContentTypeMapping.Type)
+			if err != nil {
+				return errors.ErrorWithStack(err)
+			}
+			return check.checkFieldMappings(path+".fieldMapping", fieldMapping.FieldMapping, refDefinition, true)
+		}
+	}
+	return nil
+}
+
+func (check *contentTypeCheck) checkOptions(path string, value string, propertyType api.FieldType, element api.ContentTypeElement) {
+	selections := []string{value}
+	if propertyType == api.MultiOptionSelection {
+		selections = strings.Split(value, env.MultipleItemsSeperator())
+	}
+	for _, selection := range selections {
+		if !element.HasOption(selection) {
+			options := make([]string, 0, len(element.Options))
+			for _, option := range element.Options {
+				options = append(options, option.Selection)
+			}
+			check.validation.add(path, "option "+strconv.Quote(selection)+" is not an option of the element "+strconv.Quote(element.Key)+" , options : "+strings.Join(options, ", "))
+		}
+	}
+}
+
+func isMultiFieldType(fieldType api.FieldType) bool {
+	switch fieldType {
+	case api.MultiText, api.MultiNumber, api.MultiImage, api.MultiGroup, api.MultiReference, api.MultiOptionSelection:
+		return true
+	default:
+		return false
+	}
+}
+
+func definitionName(definition api.ContentTypeDefinition) string {
+	if definition.Name == "" {
+		return strconv.Quote(definition.ID)
+	}
+	return strconv.Quote(definition.Name) + " (" + definition.ID + ")"
+}
@@ -0,0 +1,150 @@
+package csv
+
+import (
+	"github.com/dekanayake/acoustic-content-sync/pkg/acoustic/author/api"
+	"gopkg.in/resty.v1"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var contentTypeDefinitions = map[string]string{
+	"product": `{"id":"product","name":"Product","elements":[
+		{"key":"title","elementType":"text","required":true},
+		{"key":"sku","elementType":"text","required":true},
+		{"key":"colour","elementType":"text"},
+		{"key":"sizes","elementType":"text","allowMultipleValues":true},
+		{"key":"price","elementType":"number"},
+		{"key":"released","elementType":"datetime"},
+		{"key":"publishedAt","elementType":"datetime"},
+		{"key":"finish","elementType":"optionselection","options":[{"label":"Matte","selection":"matte"},{"label":"Gloss","selection":"gloss"}]},
+		{"key":"brand","elementType":"reference","restrictTypes":[{"id":"brand"}]}]}`,
+	"vendor": `{"id":"vendor","name":"Vendor","elements":[{"key":"name","elementType":"text","required":true},{"key":"code","elementType":"text","required":true}]}`,
+}
+
+const contentTypeCheckConfig = `
+contentType:
+  - type: product
+    fieldMapping:
+      - csvProperty: title
+        acousticProperty: title
+        propertyType: text
+      - csvProperty: colour
+        acousticProperty: colour
+        propertyType: multi-text
+      - csvProperty: sizes
+        acousticProperty: sizes
+        propertyType: text
+      - csvProperty: price
+        acousticProperty: price
+        propertyType: text
+      - csvProperty: released
+        acousticProperty: released
+        propertyType: date
+      - csvProperty: publishedAt
+        acousticProperty: publishedAt
+        propertyType: datetime
+      - acousticProperty: finish
+        propertyType: option-selection
+        staticValue: shiny
+      - csvProperty: weight
+        acousticProperty: weight
+        propertyType: text
+      - acousticProperty: brand
+        propertyType: reference
+        alwaysNew: true
+        refContentTypeMapping:
+          type: vendor
+        fieldMapping:
+          - csvProperty: vendor
+            acousticProperty: name
+            propertyType: text
+`
+
+func TestContentTypeCheck(t *testing.T) {
+	t.Setenv("MultipleItemsSeperator", ";")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		definition, ok := contentTypeDefinitions[strings.TrimPrefix(r.URL.Path, "/authoring/v1/types/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{}`))
+			return
+		}
+		w.Write([]byte(definition))
+	}))
+	defer server.Close()
+	checker := NewContentTypeCheckerWithClient(api.NewContentTypeClientWithRestClient(resty.New(), server.URL))
+
+	problems, err := checker.Check(writeTestFile(t, "config.yaml", contentTypeCheckConfig), "product")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{
+		"contentType[0].fieldMapping":                               `required element "sku" of the content type "Product" (product) is not mapped`,
+		"contentType[0].fieldMapping[1].propertyType":               `propertyType "multi-text" sets multiple values but the element "colour" does not allow multiple values`,
+		"contentType[0].fieldMapping[2].propertyType":               `propertyType "text" sets a single value but the element "sizes" allows multiple values`,
+		"contentType[0].fieldMapping[3].propertyType":               `propertyType "text" maps to a "text" element but "price" is a "number" element`,
+		"contentType[0].fieldMapping[6].staticValue":                `option "shiny" is not an option of the element "finish" , options : matte, gloss`,
+		"contentType[0].fieldMapping[7].acousticProperty":           `acousticProperty "weight" is not an element of the content type "Product" (product)`,
+		"contentType[0].fieldMapping[8].refContentTypeMapping.type": `content type "vendor" is not allowed for the reference element "brand"`,
+		"contentType[0].fieldMapping[8].fieldMapping":               `required element "code" of the content type "Vendor" (vendor) is not mapped`,
+	}
+	found := make(map[string]bool)
+	for _, problem := range problems {
+		message, ok := expected[problem.Path]
+		if !ok || !strings.Contains(problem.Message, message) {
+			t.Errorf("unexpected problem %s", problem)
+			continue
+		}
+		if problem.Line == 0 {
+			t.Errorf("expected the line of the problem %s", problem)
+		}
+		found[problem.Path] = true
+	}
+	for path, message := range expected {
+		if !found[path] {
+			t.Errorf("expected the problem %s : %s", path, message)
+		}
+	}
+}
+
+func TestContentTypeCheckUpdateDoesNotRequireElements(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(contentTypeDefinitions["product"]))
+	}))
+	defer server.Close()
+	checker := NewContentTypeCheckerWithClient(api.NewContentTypeClientWithRestClient(resty.New(), server.URL))
+	config := `
+contentType:
+  - type: product
+    update: true
+    fieldMapping:
+      - csvProperty: price
+        acousticProperty: price
+        propertyType: number
+`
+	problems, err := checker.Check(writeTestFile(t, "config.yaml", config), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(problems) != 0 {
+		t.Errorf("expected no problems , got %v", problems)
+	}
+}
+
+func TestContentTypeCheckUnknownContentType(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+	checker := NewContentTypeCheckerWithClient(api.NewContentTypeClientWithRestClient(resty.New(), server.URL))
+	_, err := checker.Check(writeTestFile(t, "config.yaml", "contentType:\n  - type: product\n"), "")
+	if err == nil || !strings.Contains(err.Error(), "content type not found :product") {
+		t.Errorf("expected the not found error , got %v", err)
+	}
+}
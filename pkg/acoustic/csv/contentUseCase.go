@@ -11,8 +11,13 @@ import (
 	"github.com/wesovilabs/koazee"
 	"os"
 	"sort"
+	"strconv"
+	"sync"
+	"time"
 )
 
+const SKIPPED_CONTENT_EXISTS = "content already exists , not updated without update"
+
 type ContentUseCase interface {
 	CreateBatch(contentType string, dataFeedPath string, configPath string) (ContentCreationStatus, error)
 	ReadBatch(contentType string, dataFeedPath string, configPath string) error
@@ -21,18 +26,40 @@ type ContentUseCase interface {
 type ContentCreationStatus struct {
 	Success []ContentCreationSuccessStatus
 	Failed  []ContentCreationFailedStatus
+	Skipped []ContentCreationSkippedStatus
+	// the contents of the records disappeared from the feed of a delta run
+	Disappeared ContentDeletionStatus
+	// number of the records left out by the record filters of the run
+	Filtered int
+	// the linked references not set in the second pass of the run
+	UnresolvedReferences []UnresolvedReference
+	// the records with more than a content found for the search keys
+	AmbiguousKeys []AmbiguousKey
 }
 
 type ContentCreationFailedStatus struct {
 	CSVIDKey   string
 	CSVIDValue string
 	Error      error
+	Duration   time.Duration
 }
 
 type ContentCreationSuccessStatus struct {
 	CSVIDKey   string
 	CSVIDValue string
 	ContentID  string
+	Action     api.ContentAction
+	Duration   time.Duration
+	// the elements changed by an update
+	ChangedElements []string
+}
+
+type ContentCreationSkippedStatus struct {
+	CSVIDKey   string
+	CSVIDValue string
+	ContentID  string
+	Reason     string
+	Duration   time.Duration
 }
 
 type contentUseCase struct {
@@ -47,11 +74,11 @@ type csvColumnValue struct {
 }
 
 func (contentCreationStatus ContentCreationStatus) TotalCount() int {
-	return len(contentCreationStatus.Failed) + len(contentCreationStatus.Success)
+	return len(contentCreationStatus.Failed) + len(contentCreationStatus.Success) + len(contentCreationStatus.Skipped) + contentCreationStatus.Disappeared.TotalCount()
 }
 
 func (contentCreationStatus ContentCreationStatus) FailuresExist() bool {
-	return len(contentCreationStatus.Failed) > 0
+	return len(contentCreationStatus.Failed) > 0 || len(contentCreationStatus.UnresolvedReferences) > 0 || contentCreationStatus.Disappeared.FailuresExist()
 }
 
 func (contentCreationStatus ContentCreationStatus) PrintFailed() (error error) {
@@ -105,20 +132,28 @@ func (contentCreationStatus ContentCreationStatus) PrintFailed() (error error) {
 }
 
 func NewContentUseCase(acousticAuthApiUrl string, acousticContentLib string) ContentUseCase {
+	var contentService api.ContentService
+	if env.IsDryRunEnabled() {
+		log.Info("Dry run enabled , contents will be written to : " + env.DryRunOutputLocation())
+		contentService = api.NewDryRunContentService(acousticAuthApiUrl, acousticContentLib, env.DryRunOutputLocation())
+	} else {
+		contentService = api.NewContentService(acousticAuthApiUrl, acousticContentLib)
+	}
 	return &contentUseCase{
 		acousticAuthApiUrl: acousticAuthApiUrl,
 		acousticContentLib: acousticContentLib,
-		contentService:     api.NewContentService(acousticAuthApiUrl, acousticContentLib),
+		contentService:     contentService,
 	}
 }
 
-func getFilterValues(fileLocationPath string, columns []string) ([]map[string]string, error) {
-	filterValuesFeed, err := LoadCSV(fileLocationPath)
+func getFilterValues(fileLocationPath string, columns []string, dialect CSVDialect) ([]map[string]string, error) {
+	filterValuesFeed, err := LoadFeed(fileLocationPath, "", "", dialect)
 	if err != nil {
 		return nil, errors.ErrorWithStack(err)
 	}
+	defer filterValuesFeed.Close()
 	filterValues := make([]map[string]string, 0)
-	for ok := true; ok; ok = filterValuesFeed.HasNext() {
+	for filterValuesFeed.HasNext() {
 		filterValueMap := make(map[string]string)
 		filterValueRecord := filterValuesFeed.Next()
 		for _, column := range columns {
@@ -127,56 +162,283 @@ func getFilterValues(fileLocationPath string, columns []string) ([]map[string]st
 				return nil, errors.ErrorWithStack(err)
 			}
 			filterValueMap[column] = filterValue
-			filterValues = append(filterValues, filterValueMap)
 		}
+		filterValues = append(filterValues, filterValueMap)
+	}
+	if err := filterValuesFeed.Err(); err != nil {
+		return nil, errors.ErrorWithStack(err)
 	}
 	return filterValues, nil
 }
 
+// loadKeyIndex indexes the existing contents of the search type when the key index is enabled for the run or the content
+// type , the index is nil otherwise
+func loadKeyIndex(configTypeMapping *ContentTypeMapping) (*api.KeyIndex, error) {
+	if !configTypeMapping.KeyIndex && !env.IsKeyIndexEnabled() {
+		return nil, nil
+	}
+	if len(configTypeMapping.SearchKeys) == 0 {
+		log.Info("The contents of type " + configTypeMapping.Type + " are searched , the key index needs the search keys")
+		return nil, nil
+	}
+	index, err := api.LoadKeyIndex(configTypeMapping.SearchType, configTypeMapping.SearchKeys, configTypeMapping.SearchOnLibrary, configTypeMapping.SearchOnDeliveryAPI, configTypeMapping.PaginationRows)
+	if err != nil {
+		return nil, errors.ErrorWithStack(err)
+	}
+	return index, nil
+}
+
+func matchesFilterValues(record api.AcousticDataRecord, filterValues []map[string]string) bool {
+	return funk.Contains(filterValues, func(filterValueMap map[string]string) bool {
+		contains := true
+		for filterKey, filterValue := range filterValueMap {
+			var value string
+			switch recordValue := record.GetValue(filterKey).(type) {
+			case string:
+				value = recordValue
+			case api.AcousticValue:
+				value = recordValue.Value
+			default:
+				return false
+			}
+			contains = contains && value == filterValue
+		}
+		return contains
+	})
+}
+
 func (contentUseCase *contentUseCase) CreateBatch(contentType string, dataFeedPath string, configPath string) (ContentCreationStatus, error) {
-	records, err := TransformContent(contentType, dataFeedPath, configPath)
+	config, err := InitContentTypeMappingConfig(configPath)
+	if err != nil {
+		return ContentCreationStatus{}, errors.ErrorWithStack(err)
+	}
+	configTypeMapping, err := config.GetContentType(contentType)
 	if err != nil {
 		return ContentCreationStatus{}, errors.ErrorWithStack(err)
 	}
-	filterRecords := records[0].FilterRecords
-	if filterRecords {
-		filterValues, err := getFilterValues(records[0].FilterFileLocation, records[0].FilterColumns)
+	var filterValues []map[string]string
+	if configTypeMapping.FilterRecords {
+		filterValues, err = getFilterValues(configTypeMapping.FilterFileLocation, configTypeMapping.FilterColumns, configTypeMapping.filterFileDialect())
+		if err != nil {
+			return ContentCreationStatus{}, errors.ErrorWithStack(err)
+		}
+	}
+	journal, err := OpenJournal("content", dataFeedPath, configPath, contentType)
+	if err != nil {
+		return ContentCreationStatus{}, errors.ErrorWithStack(err)
+	}
+	defer journal.Close()
+	snapshot, err := OpenSnapshot("content", configPath, contentType)
+	if err != nil {
+		return ContentCreationStatus{}, errors.ErrorWithStack(err)
+	}
+	var keyIndex *api.KeyIndex
+	if configTypeMapping.Update || configTypeMapping.CreateNonExistingItems {
+		keyIndex, err = loadKeyIndex(configTypeMapping)
 		if err != nil {
 			return ContentCreationStatus{}, errors.ErrorWithStack(err)
 		}
-		records = funk.Filter(records, func(record api.AcousticDataRecord) bool {
-			return funk.Contains(filterValues, func(filterValueMap map[string]string) bool {
-				contains := true
-				for filterKey, filterValue := range filterValueMap {
-					value := record.GetValue(filterKey)
-					contains = contains && value != nil && value == filterValue
-				}
-				return contains
-			})
-		}).([]api.AcousticDataRecord)
 	}
 	failed := make([]ContentCreationFailedStatus, 0)
 	success := make([]ContentCreationSuccessStatus, 0)
-	koazee.StreamOf(records).
-		ForEach(func(record api.AcousticDataRecord) {
-			response, err := contentUseCase.contentService.CreateOrUpdateContentWithRetry(record, contentType)
-			if err != nil {
-				log.WithField(record.CSVRecordKey, record.CSVRecordKeyValue()).Error("Failed in creating  the content ")
-				failed = append(failed, ContentCreationFailedStatus{
-					CSVIDKey:   record.CSVRecordKey,
-					CSVIDValue: record.CSVRecordKeyValue(),
-					Error:      errors.ErrorWithStack(err),
-				})
-			} else if response != nil {
-				log.WithField(record.CSVRecordKey, record.CSVRecordKeyValue()).Info("Successfully created the content ")
-				success = append(success, ContentCreationSuccessStatus{
+	skipped := make([]ContentCreationSkippedStatus, 0)
+	ambiguousKeys := make([]AmbiguousKey, 0)
+	var statusMux sync.Mutex
+	var selection feedSelection
+	// the records left out by the filter file , counted by the transform
+	filteredByFile := 0
+	// the records with linked references , linked in the second pass to the contents of the records
+	linksRecords := make([]api.AcousticDataRecord, 0)
+	contentIDs := make(map[string]string)
+	linked := func(record api.AcousticDataRecord, contentID string) {
+		linkedContents.register(contentType, record.CSVRecordKeyValue(), contentID)
+		contentIDs[record.CSVRecordKeyValue()] = contentID
+	}
+	records, transformErr := streamRecords(func(handle func(record api.AcousticDataRecord) error) error {
+		var err error
+		selection, err = transformContentFeed(contentType, dataFeedPath, configPath, func(record api.AcousticDataRecord) error {
+			if record.SkipReason != "" {
+				log.WithField(record.CSVRecordKey, record.CSVRecordKeyValue()).Info("Skipped , " + record.SkipReason)
+				statusMux.Lock()
+				defer statusMux.Unlock()
+				skipped = append(skipped, ContentCreationSkippedStatus{
 					CSVIDKey:   record.CSVRecordKey,
 					CSVIDValue: record.CSVRecordKeyValue(),
-					ContentID:  response.Id,
+					Reason:     record.SkipReason,
 				})
+				return nil
+			}
+			if configTypeMapping.FilterRecords && !matchesFilterValues(record, filterValues) {
+				filteredByFile++
+				return nil
+			}
+			record.KeyIndex = keyIndex
+			record, linksRecord := splitLinks(record)
+			if linksRecord != nil {
+				statusMux.Lock()
+				linksRecords = append(linksRecords, *linksRecord)
+				statusMux.Unlock()
+			}
+			snapshot.Seen(record)
+			return handle(record)
+		})
+		return err
+	})
+	processRecords(workerCount(configTypeMapping), records, func(record api.AcousticDataRecord) {
+		started := time.Now()
+		if completed, entry := journal.IsCompleted(record); completed {
+			log.WithField(record.CSVRecordKey, record.CSVRecordKeyValue()).Info("Skipped , the content is completed in a previous run ")
+			snapshot.Processed(record, entry.AcousticID)
+			statusMux.Lock()
+			defer statusMux.Unlock()
+			linked(record, entry.AcousticID)
+			skipped = append(skipped, ContentCreationSkippedStatus{
+				CSVIDKey:   record.CSVRecordKey,
+				CSVIDValue: record.CSVRecordKeyValue(),
+				ContentID:  entry.AcousticID,
+				Reason:     SKIPPED_ALREADY_COMPLETED,
+				Duration:   time.Since(started),
+			})
+			return
+		}
+		if unchanged, entry := snapshot.IsUnchanged(record); unchanged {
+			log.WithField(record.CSVRecordKey, record.CSVRecordKeyValue()).Info("Skipped , the record is unchanged since the previous run ")
+			statusMux.Lock()
+			defer statusMux.Unlock()
+			linked(record, entry.AcousticID)
+			skipped = append(skipped, ContentCreationSkippedStatus{
+				CSVIDKey:   record.CSVRecordKey,
+				CSVIDValue: record.CSVRecordKeyValue(),
+				ContentID:  entry.AcousticID,
+				Reason:     SKIPPED_UNCHANGED,
+				Duration:   time.Since(started),
+			})
+			return
+		}
+		response, err := contentUseCase.contentService.CreateOrUpdateContentWithRetry(record, contentType)
+		if err != nil {
+			journalResult(journal, record, "", err)
+		} else if response != nil {
+			// an existing content skipped by the record is completed with the existing ID , a resumed run does not search it again
+			journalResult(journal, record, response.Id, nil)
+			snapshot.Processed(record, response.Id)
+		}
+		statusMux.Lock()
+		defer statusMux.Unlock()
+		if response != nil {
+			// an existing content skipped by the record is linked like a created content
+			linked(record, response.Id)
+		}
+		if ambiguous, ok := ambiguousKey(record, response, err); ok {
+			log.WithField(record.CSVRecordKey, record.CSVRecordKeyValue()).WithField("candidates", ambiguous.Candidates).Warn("Duplicate contents found for the record , duplicate policy :" + string(ambiguous.Policy))
+			ambiguousKeys = append(ambiguousKeys, ambiguous)
+		}
+		if err != nil {
+			log.WithField(record.CSVRecordKey, record.CSVRecordKeyValue()).Error("Failed in creating  the content ")
+			failed = append(failed, ContentCreationFailedStatus{
+				CSVIDKey:   record.CSVRecordKey,
+				CSVIDValue: record.CSVRecordKeyValue(),
+				Error:      errors.ErrorWithStack(err),
+				Duration:   time.Since(started),
+			})
+		} else if response != nil && response.Action == api.CONTENT_SKIPPED_UNCHANGED {
+			log.WithField(record.CSVRecordKey, record.CSVRecordKeyValue()).Info("Skipped , the content has the values of the record ")
+			skipped = append(skipped, ContentCreationSkippedStatus{
+				CSVIDKey:   record.CSVRecordKey,
+				CSVIDValue: record.CSVRecordKeyValue(),
+				ContentID:  response.Id,
+				Reason:     string(api.CONTENT_SKIPPED_UNCHANGED),
+				Duration:   time.Since(started),
+			})
+		} else if response != nil && response.Action == api.CONTENT_SKIPPED {
+			log.WithField(record.CSVRecordKey, record.CSVRecordKeyValue()).Info("Skipped , the content already exists ")
+			skipped = append(skipped, ContentCreationSkippedStatus{
+				CSVIDKey:   record.CSVRecordKey,
+				CSVIDValue: record.CSVRecordKeyValue(),
+				ContentID:  response.Id,
+				Reason:     SKIPPED_CONTENT_EXISTS,
+				Duration:   time.Since(started),
+			})
+		} else if response != nil {
+			if !env.IsDryRunEnabled() {
+				log.WithField(record.CSVRecordKey, record.CSVRecordKeyValue()).WithField("changedElements", response.ChangedElements).Info("Successfully created the content ")
 			}
-		}).Do()
-	return ContentCreationStatus{Success: success, Failed: failed}, nil
+			success = append(success, ContentCreationSuccessStatus{
+				CSVIDKey:        record.CSVRecordKey,
+				CSVIDValue:      record.CSVRecordKeyValue(),
+				ContentID:       response.Id,
+				Action:          response.Action,
+				Duration:        time.Since(started),
+				ChangedElements: response.ChangedElements,
+			})
+		}
+	})
+	status := ContentCreationStatus{Success: success, Failed: failed, Skipped: skipped, AmbiguousKeys: ambiguousKeys}
+	if err := transformErr(); err != nil {
+		// the feed is not read to the end , so the disappeared records are not known and the snapshot is kept as is
+		return status, errors.ErrorWithStack(err)
+	}
+	status.UnresolvedReferences = contentUseCase.linkRecords(configTypeMapping, linksRecords, contentIDs)
+	selection.filtered += filteredByFile
+	status.Filtered = selection.filtered
+	if selection.partial() {
+		// the records left out by the filters are not disappeared from the feed , they are kept in the snapshot
+		for _, entry := range snapshot.Disappeared() {
+			snapshot.Keep(entry)
+		}
+	} else {
+		status.Disappeared, err = removeDisappeared(snapshot, configPath, configTypeMapping.CsvRecordKey)
+		if err != nil {
+			return status, errors.ErrorWithStack(err)
+		}
+	}
+	if err := snapshot.Save(); err != nil {
+		return status, errors.ErrorWithStack(err)
+	}
+	return status, nil
+}
+
+// removeDisappeared deletes or retires the contents of the records disappeared from the feed with the delete mapping of
+// the delta run. The records which could not be removed are kept in the snapshot , so the removal is tried again in the next run
+func removeDisappeared(snapshot Snapshot, configPath string, csvRecordKey string) (ContentDeletionStatus, error) {
+	disappeared := snapshot.Disappeared()
+	if len(disappeared) == 0 {
+		return ContentDeletionStatus{}, nil
+	}
+	deleteMappingName := env.DeltaDeleteMapping()
+	if deleteMappingName == "" {
+		log.Info(strconv.Itoa(len(disappeared)) + " records disappeared from the feed , they are not removed without a delete mapping")
+		return ContentDeletionStatus{}, nil
+	}
+	if env.IsDryRunEnabled() {
+		skipped := make([]ContentDeletionSkippedStatus, 0, len(disappeared))
+		for _, entry := range disappeared {
+			skipped = append(skipped, ContentDeletionSkippedStatus{
+				CSVIDKey:   csvRecordKey,
+				CSVIDValue: entry.CSVIDValue,
+				ContentID:  entry.AcousticID,
+				Reason:     SKIPPED_DISAPPEARED_DRY_RUN,
+			})
+		}
+		return ContentDeletionStatus{Skipped: skipped}, nil
+	}
+	status, err := NewDeleteService(env.AcousticAPIUrl()).DeleteDisappeared(deleteMappingName, configPath, csvRecordKey, disappeared)
+	if err != nil {
+		for _, entry := range disappeared {
+			snapshot.Keep(entry)
+		}
+		return status, errors.ErrorWithStack(err)
+	}
+	failedIDValues := make(map[string]bool, len(status.Failed))
+	for _, failed := range status.Failed {
+		failedIDValues[failed.CSVIDValue] = true
+	}
+	for _, entry := range disappeared {
+		if failedIDValues[entry.CSVIDValue] {
+			snapshot.Keep(entry)
+		}
+	}
+	return status, nil
 }
 
 func (contentUseCase contentUseCase) ReadBatch(contentType string, dataFeedPath string, configPath string) error {
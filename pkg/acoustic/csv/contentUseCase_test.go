@@ -0,0 +1,173 @@
+package csv
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const createNonExistingConfig = `
+contentType:
+  - type: product
+    csvRecordKey: sku
+    searchType: product
+    createNonExistingItems: true
+    searchKeys:
+      - sku
+    fieldMapping:
+      - csvProperty: sku
+        acousticProperty: sku
+        propertyType: text
+`
+
+// existingContentServer finds an existing content for every search , the contents created are counted
+func existingContentServer(t *testing.T, created *int) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/authoring/v1/content") {
+			*created++
+			w.Write([]byte(`{"id":"content-new"}`))
+			return
+		}
+		w.Write([]byte(`{"numFound":1,"documents":[{"document":{"id":"content-1"}}]}`))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestCreateBatchSnapshotsTheExistingContentOfCreateNonExistingItems(t *testing.T) {
+	created := 0
+	server := existingContentServer(t, &created)
+	setTestEnv(t, server.URL)
+	snapshotLocation := filepath.Join(t.TempDir(), "snapshot")
+	t.Setenv("DeltaRun", "true")
+	t.Setenv("SnapshotLocation", snapshotLocation)
+	configPath := writeTestFile(t, "config.yaml", createNonExistingConfig)
+	feedPath := writeTestFile(t, "feed.csv", "sku\nA\n")
+
+	if _, err := NewContentUseCase(server.URL, "library").CreateBatch("product", feedPath, configPath); err != nil {
+		t.Fatal(err)
+	}
+	if created != 0 {
+		t.Errorf("expected the existing content not created again , created %d", created)
+	}
+	snapshots, err := filepath.Glob(filepath.Join(snapshotLocation, "*.csv"))
+	if err != nil || len(snapshots) != 1 {
+		t.Fatalf("expected a snapshot , got %v %v", snapshots, err)
+	}
+	entries, err := readSnapshotEntries(snapshots[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry, ok := entries["A"]; !ok || entry.AcousticID != "content-1" {
+		t.Errorf("expected the existing content in the snapshot , got %+v", entries)
+	}
+}
+
+func TestCreateBatchReportsTheExistingContentOfCreateNonExistingItemsAsSkipped(t *testing.T) {
+	for _, dryRun := range []string{"false", "true"} {
+		t.Run("dryRun="+dryRun, func(t *testing.T) {
+			created := 0
+			server := existingContentServer(t, &created)
+			setTestEnv(t, server.URL)
+			t.Setenv("DryRun", dryRun)
+			t.Setenv("DryRunOutputLocation", filepath.Join(t.TempDir(), "dryRun"))
+			configPath := writeTestFile(t, "config.yaml", createNonExistingConfig)
+			feedPath := writeTestFile(t, "feed.csv", "sku\nA\n")
+
+			status, err := NewContentUseCase(server.URL, "library").CreateBatch("product", feedPath, configPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(status.Success) != 0 || len(status.Skipped) != 1 {
+				t.Fatalf("expected the record skipped , got %+v", status)
+			}
+			if skipped := status.Skipped[0]; skipped.CSVIDValue != "A" || skipped.ContentID != "content-1" || skipped.Reason != SKIPPED_CONTENT_EXISTS {
+				t.Errorf("expected the existing content in the skipped status , got %+v", skipped)
+			}
+			report := NewRunReport("content")
+			report.AddContentCreationStatus(status)
+			if report.Totals.Total != 1 || report.Totals.Skipped != 1 || report.Records[0].AcousticID != "content-1" {
+				t.Errorf("expected a skipped record in the report , got %+v", report)
+			}
+		})
+	}
+}
+
+const linkExistingConfig = `
+contentType:
+  - type: linkedProduct
+    csvRecordKey: sku
+    searchType: linkedProduct
+    createNonExistingItems: true
+    searchTerm: "sku:%s"
+    searchKeys:
+      - sku
+    fieldMapping:
+      - csvProperty: sku
+        acousticProperty: sku
+        propertyType: text
+      - csvProperty: related
+        acousticProperty: relatedProducts
+        propertyType: multi-reference
+        linkByKey: true
+        refContentTypeMapping:
+          type: linkedProduct
+`
+
+func TestCreateBatchLinksTheExistingContentOfCreateNonExistingItems(t *testing.T) {
+	var updated string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/authoring/v1/content/content-A"):
+			w.Write([]byte(`{"id":"content-A","name":"A","typeId":"linkedProduct","elements":{"sku":{"elementType":"text","value":"A"}}}`))
+		case r.Method == http.MethodPut:
+			body, _ := io.ReadAll(r.Body)
+			updated = string(body)
+			w.Write([]byte(`{"id":"content-A"}`))
+		case r.URL.Query().Get("q") == "sku:B":
+			w.Write([]byte(`{"numFound":1,"documents":[{"document":{"id":"content-B"}}]}`))
+		default:
+			w.Write([]byte(`{"numFound":1,"documents":[{"document":{"id":"content-A"}}]}`))
+		}
+	}))
+	defer server.Close()
+	setTestEnv(t, server.URL)
+	configPath := writeTestFile(t, "config.yaml", linkExistingConfig)
+	feedPath := writeTestFile(t, "feed.csv", "sku,related\nA,B\nB,\n")
+
+	status, err := NewContentUseCase(server.URL, "library").CreateBatch("linkedProduct", feedPath, configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(status.UnresolvedReferences) != 0 {
+		t.Errorf("expected the reference to the existing content resolved , got %+v", status.UnresolvedReferences)
+	}
+	if !strings.Contains(updated, "content-B") {
+		t.Errorf("expected the existing content linked , updated %s", updated)
+	}
+}
+
+func TestCreateBatchJournalsTheExistingContentOfCreateNonExistingItems(t *testing.T) {
+	created := 0
+	server := existingContentServer(t, &created)
+	setTestEnv(t, server.URL)
+	t.Setenv("ResumeRun", "true")
+	configPath := writeTestFile(t, "config.yaml", createNonExistingConfig)
+	feedPath := writeTestFile(t, "feed.csv", "sku\nA\n")
+
+	if _, err := NewContentUseCase(server.URL, "library").CreateBatch("product", feedPath, configPath); err != nil {
+		t.Fatal(err)
+	}
+	status, err := NewContentUseCase(server.URL, "library").CreateBatch("product", feedPath, configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(status.Skipped) != 1 || status.Skipped[0].Reason != SKIPPED_ALREADY_COMPLETED || status.Skipped[0].ContentID != "content-1" {
+		t.Errorf("expected the existing content completed in the journal , got %+v", status)
+	}
+}
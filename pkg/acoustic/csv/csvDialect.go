@@ -0,0 +1,294 @@
+package csv
+
+import (
+	"bufio"
+	"encoding/csv"
+	"github.com/dekanayake/acoustic-content-sync/pkg/errors"
+	"github.com/dimchansky/utfbom"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+type CSVQuoting string
+
+const (
+	// the fields can be quoted with double quotes , the default
+	QUOTING_STANDARD CSVQuoting = "standard"
+	// the quotes are read as part of the value and a field can not have the delimiter or a line break
+	QUOTING_NONE CSVQuoting = "none"
+)
+
+const (
+	ENCODING_AUTO         = "auto"
+	ENCODING_UTF8         = "utf-8"
+	ENCODING_UTF16        = "utf-16"
+	ENCODING_UTF16LE      = "utf-16le"
+	ENCODING_UTF16BE      = "utf-16be"
+	ENCODING_WINDOWS_1252 = "windows-1252"
+	ENCODING_ISO_8859_1   = "iso-8859-1"
+)
+
+var supportedEncodings = []string{ENCODING_AUTO, ENCODING_UTF8, ENCODING_UTF16, ENCODING_UTF16LE, ENCODING_UTF16BE, ENCODING_WINDOWS_1252, ENCODING_ISO_8859_1}
+
+// CSVDialect is how a CSV feed is written , the defaults are the comma separated UTF-8 files of encoding/csv
+type CSVDialect struct {
+	// single character , "\t" or "tab" for a tab separated file
+	Delimiter  string     `yaml:"delimiter"`
+	Quoting    CSVQuoting `yaml:"quoting"`
+	LazyQuotes bool       `yaml:"lazyQuotes"`
+	// the lines starting with the comment character are skipped
+	Comment string `yaml:"comment"`
+	// the line of the header , the lines before the header are skipped
+	HeaderRow int `yaml:"headerRow"`
+	// the encoding of the file , auto detects UTF-8 , UTF-16 and windows-1252
+	Encoding string `yaml:"encoding"`
+}
+
+// recordReader reads the records of a CSV feed , implemented by csv.Reader and by the reader of the unquoted files
+type recordReader interface {
+	Read() ([]string, error)
+	FieldPos(field int) (line, column int)
+}
+
+func (dialect CSVDialect) isZero() bool {
+	return dialect == CSVDialect{}
+}
+
+func (dialect CSVDialect) delimiter() (rune, error) {
+	switch dialect.Delimiter {
+	case "":
+		return ',', nil
+	case "\\t", "tab":
+		return '\t', nil
+	}
+	delimiter, size := utf8.DecodeRuneInString(dialect.Delimiter)
+	if size != len(dialect.Delimiter) || delimiter == '\r' || delimiter == '\n' || delimiter == '"' || delimiter == utf8.RuneError {
+		return 0, errors.ErrorMessageWithStack("delimiter " + strconv.Quote(dialect.Delimiter) + " should be a single character other than a quote or a line break")
+	}
+	return delimiter, nil
+}
+
+func (dialect CSVDialect) comment() (rune, error) {
+	if dialect.Comment == "" {
+		return 0, nil
+	}
+	comment, size := utf8.DecodeRuneInString(dialect.Comment)
+	if size != len(dialect.Comment) || comment == '\r' || comment == '\n' || comment == '"' || comment == utf8.RuneError {
+		return 0, errors.ErrorMessageWithStack("comment " + strconv.Quote(dialect.Comment) + " should be a single character other than a quote or a line break")
+	}
+	return comment, nil
+}
+
+// check validates the settings of the dialect , the problems are reported by the config path of the setting
+func (dialect CSVDialect) check() map[string]error {
+	problems := make(map[string]error)
+	delimiter, err := dialect.delimiter()
+	if err != nil {
+		problems["delimiter"] = err
+	}
+	comment, err := dialect.comment()
+	if err != nil {
+		problems["comment"] = err
+	} else if comment != 0 && comment == delimiter {
+		problems["comment"] = errors.ErrorMessageWithStack("comment should not be the delimiter")
+	}
+	switch dialect.Quoting {
+	case "", QUOTING_STANDARD, QUOTING_NONE:
+	default:
+		problems["quoting"] = errors.ErrorMessageWithStack("unsupported quoting " + strconv.Quote(string(dialect.Quoting)) + " , supported quoting : standard, none")
+	}
+	if dialect.HeaderRow < 0 {
+		problems["headerRow"] = errors.ErrorMessageWithStack("headerRow should not be negative")
+	}
+	if !isSupportedEncoding(dialect.Encoding) {
+		problems["encoding"] = errors.ErrorMessageWithStack("unsupported encoding " + strconv.Quote(dialect.Encoding) + " , supported encodings : " + strings.Join(supportedEncodings, ", "))
+	}
+	return problems
+}
+
+func isSupportedEncoding(encoding string) bool {
+	switch normalizedEncoding(encoding) {
+	case "", ENCODING_AUTO, ENCODING_UTF8, ENCODING_UTF16, ENCODING_UTF16LE, ENCODING_UTF16BE, ENCODING_WINDOWS_1252, ENCODING_ISO_8859_1:
+		return true
+	}
+	return false
+}
+
+func normalizedEncoding(encoding string) string {
+	switch encoding = strings.ToLower(strings.TrimSpace(encoding)); encoding {
+	case "utf8":
+		return ENCODING_UTF8
+	case "cp1252":
+		return ENCODING_WINDOWS_1252
+	case "latin1", "latin-1":
+		return ENCODING_ISO_8859_1
+	}
+	return encoding
+}
+
+// open decodes the file to UTF-8 and reads up to the header , the reader is positioned at the first record
+func (dialect CSVDialect) open(file io.Reader) (recordReader, []string, error) {
+	for _, err := range dialect.check() {
+		return nil, nil, err
+	}
+	decoded, err := dialect.decode(file)
+	if err != nil {
+		return nil, nil, err
+	}
+	reader := bufio.NewReader(skipBOM(decoded))
+	// the lines before the header are skipped as they are , they can be a title or notes with any number of fields
+	for skipped := 1; skipped < dialect.HeaderRow; skipped++ {
+		if _, err := reader.ReadString('\n'); err == io.EOF {
+			return nil, nil, errors.ErrorMessageWithStack("CSV file has no header on line " + strconv.Itoa(dialect.HeaderRow))
+		} else if err != nil {
+			return nil, nil, errors.ErrorWithStack(err)
+		}
+	}
+	delimiter, _ := dialect.delimiter()
+	comment, _ := dialect.comment()
+	var records recordReader
+	if dialect.Quoting == QUOTING_NONE {
+		records = &unquotedReader{reader: reader, delimiter: string(delimiter), comment: comment}
+	} else {
+		csvReader := csv.NewReader(reader)
+		csvReader.Comma = delimiter
+		csvReader.Comment = comment
+		csvReader.LazyQuotes = dialect.LazyQuotes
+		records = csvReader
+	}
+	if dialect.HeaderRow > 1 {
+		records = &lineOffsetReader{recordReader: records, offset: dialect.HeaderRow - 1}
+	}
+	headerRecord, err := records.Read()
+	if err == io.EOF {
+		return nil, nil, errors.ErrorMessageWithStack("CSV file is empty. ")
+	}
+	if err != nil {
+		return nil, nil, errors.ErrorWithStack(err)
+	}
+	return records, headerRecord, nil
+}
+
+func skipBOM(reader io.Reader) io.Reader {
+	skipped, _ := utfbom.Skip(reader)
+	return skipped
+}
+
+// lineOffsetReader numbers the lines of the records from the start of the file , when the lines before the header are skipped
+type lineOffsetReader struct {
+	recordReader
+	offset int
+}
+
+func (records *lineOffsetReader) FieldPos(field int) (line, column int) {
+	line, column = records.recordReader.FieldPos(field)
+	return line + records.offset, column
+}
+
+// decode converts the file to UTF-8 , the auto encoding looks at the byte order mark and the start of the file
+func (dialect CSVDialect) decode(file io.Reader) (io.Reader, error) {
+	reader := bufio.NewReader(file)
+	encoding := normalizedEncoding(dialect.Encoding)
+	if encoding == ENCODING_AUTO || encoding == ENCODING_UTF16 {
+		sample, err := reader.Peek(4096)
+		if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+			return nil, errors.ErrorWithStack(err)
+		}
+		// the sample is the start of a longer file when the peek did not reach the end of the file
+		detected := detectEncoding(sample, err != io.EOF)
+		if encoding == ENCODING_UTF16 && detected != ENCODING_UTF16BE {
+			detected = ENCODING_UTF16LE
+		}
+		if encoding == ENCODING_AUTO {
+			log.Info("Detected the encoding of the CSV file :" + detected)
+		}
+		encoding = detected
+	}
+	switch encoding {
+	case ENCODING_UTF16LE:
+		return unicode.UTF16(unicode.LittleEndian, unicode.UseBOM).NewDecoder().Reader(reader), nil
+	case ENCODING_UTF16BE:
+		return unicode.UTF16(unicode.BigEndian, unicode.UseBOM).NewDecoder().Reader(reader), nil
+	case ENCODING_WINDOWS_1252:
+		return charmap.Windows1252.NewDecoder().Reader(reader), nil
+	case ENCODING_ISO_8859_1:
+		return charmap.ISO8859_1.NewDecoder().Reader(reader), nil
+	default:
+		return reader, nil
+	}
+}
+
+func detectEncoding(sample []byte, truncated bool) string {
+	switch {
+	case len(sample) >= 3 && sample[0] == 0xEF && sample[1] == 0xBB && sample[2] == 0xBF:
+		return ENCODING_UTF8
+	case len(sample) >= 2 && sample[0] == 0xFF && sample[1] == 0xFE:
+		return ENCODING_UTF16LE
+	case len(sample) >= 2 && sample[0] == 0xFE && sample[1] == 0xFF:
+		return ENCODING_UTF16BE
+	}
+	// a UTF-16 file without a byte order mark has a zero byte in most of the ASCII characters
+	evenZeros, oddZeros := 0, 0
+	for index, b := range sample {
+		if b == 0 {
+			if index%2 == 0 {
+				evenZeros++
+			} else {
+				oddZeros++
+			}
+		}
+	}
+	if len(sample) >= 2 && oddZeros > len(sample)/4 && evenZeros == 0 {
+		return ENCODING_UTF16LE
+	}
+	if len(sample) >= 2 && evenZeros > len(sample)/4 && oddZeros == 0 {
+		return ENCODING_UTF16BE
+	}
+	// a truncated sample can end in the middle of a character
+	if truncated {
+		for trim := 1; trim < utf8.UTFMax && trim <= len(sample); trim++ {
+			if utf8.RuneStart(sample[len(sample)-trim]) {
+				if !utf8.FullRune(sample[len(sample)-trim:]) {
+					sample = sample[:len(sample)-trim]
+				}
+				break
+			}
+		}
+	}
+	if utf8.Valid(sample) {
+		return ENCODING_UTF8
+	}
+	return ENCODING_WINDOWS_1252
+}
+
+// unquotedReader reads the records of a file without quoting , a record per line split by the delimiter
+type unquotedReader struct {
+	reader    *bufio.Reader
+	delimiter string
+	comment   rune
+	line      int
+}
+
+func (records *unquotedReader) Read() ([]string, error) {
+	for {
+		line, err := records.reader.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			return nil, err
+		}
+		records.line++
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" || (records.comment != 0 && strings.HasPrefix(line, string(records.comment))) {
+			continue
+		}
+		return strings.Split(line, records.delimiter), nil
+	}
+}
+
+func (records *unquotedReader) FieldPos(field int) (line, column int) {
+	return records.line, 1
+}
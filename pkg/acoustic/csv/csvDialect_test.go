@@ -0,0 +1,199 @@
+package csv
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+func encodeUTF16(text string, order binary.ByteOrder, bom bool) []byte {
+	units := utf16.Encode([]rune(text))
+	if bom {
+		units = append([]uint16{0xFEFF}, units...)
+	}
+	encoded := make([]byte, len(units)*2)
+	for index, unit := range units {
+		order.PutUint16(encoded[index*2:], unit)
+	}
+	return encoded
+}
+
+func readDialectRecords(t *testing.T, dialect CSVDialect, content []byte) ([]string, [][]string, []int) {
+	records, headers, err := dialect.open(bytes.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := make([][]string, 0)
+	lines := make([]int, 0)
+	for {
+		record, err := records.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		line, _ := records.FieldPos(0)
+		rows = append(rows, record)
+		lines = append(lines, line)
+	}
+	return headers, rows, lines
+}
+
+func TestCSVDialectEncodings(t *testing.T) {
+	const text = "sku,name\n1,Café “Noir” €5\n"
+	expected := [][]string{{"1", "Café “Noir” €5"}}
+	tests := []struct {
+		name     string
+		encoding string
+		content  []byte
+	}{
+		{"utf-8", "", []byte(text)},
+		{"utf-8 with BOM", "", append([]byte{0xEF, 0xBB, 0xBF}, text...)},
+		{"utf-8 set", "utf8", []byte(text)},
+		{"utf-16le with BOM", "auto", encodeUTF16(text, binary.LittleEndian, true)},
+		{"utf-16be with BOM", "auto", encodeUTF16(text, binary.BigEndian, true)},
+		{"utf-16le without BOM", "auto", encodeUTF16(text, binary.LittleEndian, false)},
+		{"utf-16be without BOM", "auto", encodeUTF16(text, binary.BigEndian, false)},
+		{"utf-16 without BOM is little endian", "utf-16", encodeUTF16(text, binary.LittleEndian, false)},
+		{"utf-16 with big endian BOM", "utf-16", encodeUTF16(text, binary.BigEndian, true)},
+		{"utf-16le set", "UTF-16LE", encodeUTF16(text, binary.LittleEndian, false)},
+		{"utf-16be set", "utf-16be", encodeUTF16(text, binary.BigEndian, true)},
+		{"windows-1252", "cp1252", []byte("sku,name\n1,Caf\xe9 \x93Noir\x94 \x805\n")},
+		{"windows-1252 detected", "auto", []byte("sku,name\n1,Caf\xe9 \x93Noir\x94 \x805\n")},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			headers, rows, _ := readDialectRecords(t, CSVDialect{Encoding: test.encoding}, test.content)
+			if !reflect.DeepEqual(headers, []string{"sku", "name"}) {
+				t.Errorf("unexpected headers %q", headers)
+			}
+			if !reflect.DeepEqual(rows, expected) {
+				t.Errorf("expected %q , got %q", expected, rows)
+			}
+		})
+	}
+}
+
+func TestCSVDialectISO88591(t *testing.T) {
+	_, rows, _ := readDialectRecords(t, CSVDialect{Encoding: "latin1"}, []byte("sku,name\n1,Caf\xe9 \x80\n"))
+	if expected := [][]string{{"1", "Café \u0080"}}; !reflect.DeepEqual(rows, expected) {
+		t.Errorf("expected %q , got %q", expected, rows)
+	}
+}
+
+func TestCSVDialectUTF16LoneSurrogate(t *testing.T) {
+	content := encodeUTF16("sku,name\n1,", binary.LittleEndian, true)
+	// a high surrogate without the low surrogate , the character after it is kept
+	content = append(content, 0x3D, 0xD8)
+	content = append(content, encodeUTF16("A\n", binary.LittleEndian, false)...)
+	_, rows, _ := readDialectRecords(t, CSVDialect{Encoding: "auto"}, content)
+	if expected := [][]string{{"1", "�A"}}; !reflect.DeepEqual(rows, expected) {
+		t.Errorf("expected %q , got %q", expected, rows)
+	}
+}
+
+func TestDetectEncoding(t *testing.T) {
+	tests := []struct {
+		name      string
+		sample    []byte
+		truncated bool
+		expected  string
+	}{
+		{"utf-8", []byte("sku,name\n1,Café\n"), false, ENCODING_UTF8},
+		{"utf-8 BOM", []byte("\xef\xbb\xbfsku\n"), false, ENCODING_UTF8},
+		{"utf-16le BOM", []byte("\xff\xfes\x00"), false, ENCODING_UTF16LE},
+		{"utf-16be BOM", []byte("\xfe\xff\x00s"), false, ENCODING_UTF16BE},
+		{"utf-16le", encodeUTF16("sku,name\n", binary.LittleEndian, false), false, ENCODING_UTF16LE},
+		{"utf-16be", encodeUTF16("sku,name\n", binary.BigEndian, false), false, ENCODING_UTF16BE},
+		{"windows-1252", []byte("sku,name\n1,Caf\xe9\n"), false, ENCODING_WINDOWS_1252},
+		{"windows-1252 at the end of the file", []byte("sku,name\n1,Caf\xe9"), false, ENCODING_WINDOWS_1252},
+		{"truncated in a utf-8 character", []byte("sku,name\n1,Caf\xc3"), true, ENCODING_UTF8},
+		{"truncated in a windows-1252 file", []byte("sku,name\n1,Caf\xe9 et th\xe9"), true, ENCODING_WINDOWS_1252},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if detected := detectEncoding(test.sample, test.truncated); detected != test.expected {
+				t.Errorf("expected %s , detected %s", test.expected, detected)
+			}
+		})
+	}
+}
+
+func TestCSVDialectHeaderRowLineNumbers(t *testing.T) {
+	content := "Product export\nGenerated 2023-03-15 , with , commas\nsku,name\n1,Shoe\n2,Hat\n"
+	for _, quoting := range []CSVQuoting{QUOTING_STANDARD, QUOTING_NONE} {
+		t.Run(string(quoting), func(t *testing.T) {
+			headers, rows, lines := readDialectRecords(t, CSVDialect{HeaderRow: 3, Quoting: quoting}, []byte(content))
+			if !reflect.DeepEqual(headers, []string{"sku", "name"}) {
+				t.Errorf("unexpected headers %q", headers)
+			}
+			if expected := [][]string{{"1", "Shoe"}, {"2", "Hat"}}; !reflect.DeepEqual(rows, expected) {
+				t.Errorf("expected %q , got %q", expected, rows)
+			}
+			if expected := []int{4, 5}; !reflect.DeepEqual(lines, expected) {
+				t.Errorf("expected the lines %v , got %v", expected, lines)
+			}
+		})
+	}
+	if _, _, err := (CSVDialect{HeaderRow: 5}).open(strings.NewReader("a\nb\n")); err == nil || !strings.Contains(err.Error(), "CSV file has no header on line 5") {
+		t.Errorf("expected the missing header error , got %v", err)
+	}
+}
+
+func TestCSVDialectQuotingNone(t *testing.T) {
+	content := "sku\tname\tnote\n1\t\"Shoe\" 12\"\tsize, colour\n\n2\tHat\t\n"
+	_, rows, lines := readDialectRecords(t, CSVDialect{Delimiter: "tab", Quoting: QUOTING_NONE}, []byte(content))
+	expected := [][]string{{"1", "\"Shoe\" 12\"", "size, colour"}, {"2", "Hat", ""}}
+	if !reflect.DeepEqual(rows, expected) {
+		t.Errorf("expected %q , got %q", expected, rows)
+	}
+	if expectedLines := []int{2, 4}; !reflect.DeepEqual(lines, expectedLines) {
+		t.Errorf("expected the lines %v , got %v", expectedLines, lines)
+	}
+}
+
+func TestCSVDialectComment(t *testing.T) {
+	content := "sku;name\n# discontinued\n1;Shoe\n#2;Hat\n3;#1 Seller\n"
+	for _, quoting := range []CSVQuoting{QUOTING_STANDARD, QUOTING_NONE} {
+		t.Run(string(quoting), func(t *testing.T) {
+			_, rows, lines := readDialectRecords(t, CSVDialect{Delimiter: ";", Comment: "#", Quoting: quoting}, []byte(content))
+			if expected := [][]string{{"1", "Shoe"}, {"3", "#1 Seller"}}; !reflect.DeepEqual(rows, expected) {
+				t.Errorf("expected %q , got %q", expected, rows)
+			}
+			if expected := []int{3, 5}; !reflect.DeepEqual(lines, expected) {
+				t.Errorf("expected the lines %v , got %v", expected, lines)
+			}
+		})
+	}
+}
+
+func TestCSVDialectCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		dialect CSVDialect
+		setting string
+	}{
+		{"delimiter of two characters", CSVDialect{Delimiter: ";;"}, "delimiter"},
+		{"quote delimiter", CSVDialect{Delimiter: "\""}, "delimiter"},
+		{"comment of two characters", CSVDialect{Comment: "//"}, "comment"},
+		{"comment is the delimiter", CSVDialect{Delimiter: ";", Comment: ";"}, "comment"},
+		{"unsupported quoting", CSVDialect{Quoting: "single"}, "quoting"},
+		{"negative header row", CSVDialect{HeaderRow: -1}, "headerRow"},
+		{"unsupported encoding", CSVDialect{Encoding: "shift-jis"}, "encoding"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			problems := test.dialect.check()
+			if _, ok := problems[test.setting]; !ok || len(problems) != 1 {
+				t.Errorf("expected a problem of %s , got %v", test.setting, problems)
+			}
+		})
+	}
+	if problems := (CSVDialect{Delimiter: "\\t", Comment: "#", Quoting: QUOTING_NONE, HeaderRow: 2, Encoding: "Latin-1"}).check(); len(problems) != 0 {
+		t.Errorf("expected no problems , got %v", problems)
+	}
+}
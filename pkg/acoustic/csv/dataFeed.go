@@ -4,146 +4,157 @@ import (
 	"encoding/csv"
 	"github.com/dekanayake/acoustic-content-sync/pkg/env"
 	"github.com/dekanayake/acoustic-content-sync/pkg/errors"
-	"github.com/dimchansky/utfbom"
-	"github.com/wesovilabs/koazee"
-	"github.com/wesovilabs/koazee/stream"
 	"io"
 	"os"
 	"strings"
 )
 
-type contentData struct {
-	rows []dataRow
-}
-
 type dataRow struct {
-	columns map[string]string
-}
-
-func load(csvFile *os.File) (*contentData, error) {
-	reader, _ := utfbom.Skip(csvFile)
-	records := csv.NewReader(reader)
-
-	headerRecord, err := records.Read()
-	var unparsedRecordsWriter *csv.Writer = nil
-	if env.WriteUnParsedRecordsToCSV() {
-		unparsedRecordsFile, err := os.Create("unparsed_records.csv")
-		if err != nil {
-			return nil, errors.ErrorWithStack(err)
-		}
-		defer unparsedRecordsFile.Close()
-		unparsedRecordsWriter = csv.NewWriter(unparsedRecordsFile)
-		defer unparsedRecordsWriter.Flush()
-		if err := unparsedRecordsWriter.Write(headerRecord); err != nil {
-			return nil, errors.ErrorWithStack(err)
-		}
-	}
-	if err == io.EOF {
-		return nil, errors.ErrorMessageWithStack("CSV file is empty. ")
-	}
-	if err != nil {
-		return nil, errors.ErrorWithStack(err)
-	}
-	if headerRecord != nil {
-		headersMap := make(map[int]string)
-		for index, columnHeader := range headerRecord {
-			headersMap[index] = columnHeader
-		}
-		dataRows := make([]dataRow, 0, len(headersMap))
-		for {
-			contentRecord, err := records.Read()
-			if err == io.EOF {
-				break
-			}
-			if err != nil {
-				if env.WriteUnParsedRecordsToCSV() {
-					if err := unparsedRecordsWriter.Write(contentRecord); err != nil {
-						return nil, errors.ErrorWithStack(err)
-					}
-					continue
-				} else {
-					return nil, errors.ErrorWithStack(err)
-				}
-			}
-			row := make(map[string]string)
-			for index, columnValue := range contentRecord {
-				row[headersMap[index]] = columnValue
-			}
-			dataRow := dataRow{columns: row}
-			dataRows = append(dataRows, dataRow)
-		}
-		contentData := &contentData{rows: dataRows}
-		return contentData, nil
-	} else {
-		return nil, errors.ErrorMessageWithStack("No error nor record found!")
-	}
+	columns   map[string]string
+	rowNumber int
 }
 
+// DataFeed reads the rows of a feed one at a time , a row is read only when the previous one is consumed
+// so the memory used does not grow with the size of the feed.
+// The feed stops at the first row which can not be read , the error is available from Err once HasNext returns false
 type DataFeed interface {
 	HasNext() bool
 	Next() DataRow
 	Headers() ([]string, error)
+	Err() error
+	Close() error
+}
+
+// RecordCounter is implemented by the feeds which know the number of records upfront
+type RecordCounter interface {
 	RecordCount() int
 }
 
 type DataRow interface {
 	Get(columnName string) (string, error)
+	// RowNumber is the line of the row in a CSV feed , the record number in a JSON feed and the row of the sheet in a XLSX feed
+	RowNumber() int
 }
 
-type dataFeed struct {
-	rowIndex   int
-	rowSize    int
-	rows       []dataRow
-	rowsStream stream.Stream
+type csvDataFeed struct {
+	file                  *os.File
+	records               recordReader
+	headers               []string
+	next                  *dataRow
+	err                   error
+	unparsedRecordsFile   *os.File
+	unparsedRecordsWriter *csv.Writer
 }
 
 func LoadCSV(csvFilePath string) (DataFeed, error) {
+	return LoadCSVWithDialect(csvFilePath, CSVDialect{})
+}
+
+// LoadCSVWithDialect opens a CSV feed written with the delimiter , quoting and encoding of the dialect
+func LoadCSVWithDialect(csvFilePath string, dialect CSVDialect) (DataFeed, error) {
 	csvFile, err := os.Open(csvFilePath)
-	defer csvFile.Close()
 	if err != nil {
 		return nil, errors.ErrorWithStack(err)
-	} else {
-		if contentData, err := load(csvFile); err != nil {
-			return nil, err
-		} else {
-			return &dataFeed{
-				rowIndex:   0,
-				rowSize:    len(contentData.rows),
-				rowsStream: koazee.StreamOf(contentData.rows),
-				rows:       contentData.rows,
-			}, nil
+	}
+	dataFeed, err := load(csvFile, dialect)
+	if err != nil {
+		csvFile.Close()
+		return nil, err
+	}
+	return dataFeed, nil
+}
+
+func load(csvFile *os.File, dialect CSVDialect) (*csvDataFeed, error) {
+	records, headerRecord, err := dialect.open(csvFile)
+	if err != nil {
+		return nil, errors.ErrorWithStack(err)
+	}
+	if headerRecord == nil {
+		return nil, errors.ErrorMessageWithStack("No error nor record found!")
+	}
+	dataFeed := &csvDataFeed{
+		file:    csvFile,
+		records: records,
+		headers: headerRecord,
+	}
+	if env.WriteUnParsedRecordsToCSV() {
+		unparsedRecordsFile, err := os.Create("unparsed_records.csv")
+		if err != nil {
+			return nil, errors.ErrorWithStack(err)
+		}
+		dataFeed.unparsedRecordsFile = unparsedRecordsFile
+		dataFeed.unparsedRecordsWriter = csv.NewWriter(unparsedRecordsFile)
+		if err := dataFeed.unparsedRecordsWriter.Write(headerRecord); err != nil {
+			dataFeed.Close()
+			return nil, errors.ErrorWithStack(err)
+		}
+	}
+	dataFeed.readNext()
+	return dataFeed, nil
+}
+
+// readNext reads ahead the next row , so HasNext can tell whether a row is available without consuming it
+func (dataFeed *csvDataFeed) readNext() {
+	dataFeed.next = nil
+	for dataFeed.err == nil {
+		contentRecord, err := dataFeed.records.Read()
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			if dataFeed.unparsedRecordsWriter != nil {
+				if err := dataFeed.unparsedRecordsWriter.Write(contentRecord); err != nil {
+					dataFeed.err = errors.ErrorWithStack(err)
+				}
+				continue
+			}
+			dataFeed.err = errors.ErrorWithStack(err)
+			return
+		}
+		rowNumber, _ := dataFeed.records.FieldPos(0)
+		row := make(map[string]string, len(dataFeed.headers))
+		for index, columnValue := range contentRecord {
+			if index < len(dataFeed.headers) {
+				row[dataFeed.headers[index]] = columnValue
+			}
 		}
+		dataFeed.next = &dataRow{columns: row, rowNumber: rowNumber}
+		return
 	}
 }
 
-func (dataFeed *dataFeed) RecordCount() int {
-	return dataFeed.rowSize
+func (dataFeed *csvDataFeed) HasNext() bool {
+	return dataFeed.next != nil
 }
 
-func (dataFeed *dataFeed) HasNext() bool {
-	return dataFeed.rowIndex < dataFeed.rowSize
+func (dataFeed *csvDataFeed) Next() DataRow {
+	dataRow := dataFeed.next
+	dataFeed.readNext()
+	return dataRow
 }
 
-func (dataFeed *dataFeed) Next() DataRow {
-	val, remainingRows := dataFeed.rowsStream.Pop()
-	dataFeed.rowsStream = remainingRows
-	dataFeed.rowIndex += 1
-	dataRow := val.Val().(dataRow)
-	return &dataRow
+func (dataFeed *csvDataFeed) Headers() ([]string, error) {
+	return dataFeed.headers, nil
 }
 
-func (dataFeed *dataFeed) Headers() ([]string, error) {
-	dataRows := dataFeed.rows
-	if len(dataRows) > 0 {
-		columns := dataRows[0].columns
-		keys := make([]string, 0, len(columns))
-		for k := range columns {
-			keys = append(keys, k)
-		}
-		return keys, nil
-	} else {
-		return nil, errors.ErrorMessageWithStack("No contents in datafeed")
+func (dataFeed *csvDataFeed) Err() error {
+	return dataFeed.err
+}
+
+func (dataFeed *csvDataFeed) Close() error {
+	if dataFeed.unparsedRecordsWriter != nil {
+		dataFeed.unparsedRecordsWriter.Flush()
+		dataFeed.unparsedRecordsFile.Close()
+		dataFeed.unparsedRecordsWriter = nil
+	}
+	if err := dataFeed.file.Close(); err != nil {
+		return errors.ErrorWithStack(err)
 	}
+	return nil
+}
+
+func (dataRow *dataRow) RowNumber() int {
+	return dataRow.rowNumber
 }
 
 func (dataRow *dataRow) Get(columnName string) (string, error) {
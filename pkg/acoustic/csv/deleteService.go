@@ -9,32 +9,48 @@ import (
 	log "github.com/sirupsen/logrus"
 	"github.com/wesovilabs/koazee"
 	"os"
+	"time"
 )
 
+const CONTENT_STATUS_RETIRED = "retired"
+
 type DeleteService interface {
 	DeleteByFeed(deleteMappingName string, contentType string, dataFeedPath string, configPath string) (ContentDeletionStatus, error)
 	Delete(libraryId string, deleteMappingName string, configPath string) error
+	DeleteDisappeared(deleteMappingName string, configPath string, csvRecordKey string, disappeared []SnapshotEntry) (ContentDeletionStatus, error)
+	Dedupe(contentType string, configPath string, keep string, merge bool) (ContentDeletionStatus, error)
 }
 
 type ContentDeletionStatus struct {
 	Success []ContentDeletionSuccessStatus
 	Failed  []ContentDeletionFailedStatus
+	Skipped []ContentDeletionSkippedStatus
 }
 
 type ContentDeletionSuccessStatus struct {
 	CSVIDKey   string
 	CSVIDValue string
 	ContentID  string
+	Duration   time.Duration
 }
 
 type ContentDeletionFailedStatus struct {
 	CSVIDKey   string
 	CSVIDValue string
 	Error      error
+	Duration   time.Duration
+}
+
+type ContentDeletionSkippedStatus struct {
+	CSVIDKey   string
+	CSVIDValue string
+	ContentID  string
+	Reason     string
+	Duration   time.Duration
 }
 
 func (ContentDeletionStatus ContentDeletionStatus) TotalCount() int {
-	return len(ContentDeletionStatus.Failed) + len(ContentDeletionStatus.Success)
+	return len(ContentDeletionStatus.Failed) + len(ContentDeletionStatus.Success) + len(ContentDeletionStatus.Skipped)
 }
 
 func (contentDeletionStatus ContentDeletionStatus) FailuresExist() bool {
@@ -127,14 +143,6 @@ func delete(d deleteService, assetType api.AssetType, id string) error {
 }
 
 func (d deleteService) DeleteByFeed(deleteMappingName string, contentType string, dataFeedPath string, configPath string) (ContentDeletionStatus, error) {
-	records := []api.AcousticDataRecord{}
-	if dataFeedPath != "" {
-		var err error = nil
-		records, err = TransformContent(contentType, dataFeedPath, configPath)
-		if err != nil {
-			return ContentDeletionStatus{}, errors.ErrorWithStack(err)
-		}
-	}
 	config, err := InitContentTypeMappingConfig(configPath)
 	if err != nil {
 		return ContentDeletionStatus{}, errors.ErrorWithStack(err)
@@ -144,37 +152,80 @@ func (d deleteService) DeleteByFeed(deleteMappingName string, contentType string
 		return ContentDeletionStatus{}, errors.ErrorWithStack(err)
 	}
 
+	configTypeMapping, err := config.GetContentType(contentType)
+	if err != nil {
+		return ContentDeletionStatus{}, errors.ErrorWithStack(err)
+	}
+	keyIndex, err := loadKeyIndex(configTypeMapping)
+	if err != nil {
+		return ContentDeletionStatus{}, errors.ErrorWithStack(err)
+	}
+	journal, err := OpenJournal("delete", dataFeedPath, configPath, deleteMappingName, contentType)
+	if err != nil {
+		return ContentDeletionStatus{}, errors.ErrorWithStack(err)
+	}
+	defer journal.Close()
+
 	failed := make([]ContentDeletionFailedStatus, 0)
 	success := make([]ContentDeletionSuccessStatus, 0)
+	skipped := make([]ContentDeletionSkippedStatus, 0)
 
-	if len(records) > 0 {
-		for _, record := range records {
-			query, err := record.SearchQuerytoGetTheContent()
-			if err != nil {
-				return ContentDeletionStatus{}, err
-			}
-			searchRequest := api.SearchRequest{
-				Terms:          query,
-				ContentTypes:   []string{record.SearchType},
-				Classification: "content",
+	if dataFeedPath != "" {
+		err = TransformContentFunc(contentType, dataFeedPath, configPath, func(record api.AcousticDataRecord) error {
+			started := time.Now()
+			if record.SkipReason != "" {
+				log.WithField(record.CSVRecordKey, record.CSVRecordKeyValue()).Info("Skipped , " + record.SkipReason)
+				skipped = append(skipped, ContentDeletionSkippedStatus{
+					CSVIDKey:   record.CSVRecordKey,
+					CSVIDValue: record.CSVRecordKeyValue(),
+					Reason:     record.SkipReason,
+				})
+				return nil
 			}
-			searchResponse, err := api.NewSearchClient(env.AcousticAPIUrl()).Search(env.LibraryID(), record.SearchOnLibrary, record.SearchOnDeliveryAPI, searchRequest, api.Pagination{Start: 0, Rows: 1})
-			if err != nil {
-				log.WithField(record.CSVRecordKey, record.CSVRecordKeyValue()).Error("Failed in deleting  the content ")
-				failed = append(failed, ContentDeletionFailedStatus{
+			if completed, entry := journal.IsCompleted(record); completed {
+				log.WithField(record.CSVRecordKey, record.CSVRecordKeyValue()).Info("Skipped , the content is deleted in a previous run ")
+				skipped = append(skipped, ContentDeletionSkippedStatus{
 					CSVIDKey:   record.CSVRecordKey,
 					CSVIDValue: record.CSVRecordKeyValue(),
-					Error:      errors.ErrorWithStack(err),
+					ContentID:  entry.AcousticID,
+					Reason:     SKIPPED_ALREADY_COMPLETED,
+					Duration:   time.Since(started),
 				})
+				return nil
+			}
+			searchResponse, found := keyIndex.Lookup(record)
+			if !found {
+				query, err := record.SearchQuerytoGetTheContent()
+				if err != nil {
+					return err
+				}
+				searchRequest := api.SearchRequest{
+					Terms:          query,
+					ContentTypes:   []string{record.SearchType},
+					Classification: "content",
+				}
+				searchResponse, err = api.NewSearchClient(env.AcousticAPIUrl()).Search(env.LibraryID(), record.SearchOnLibrary, record.SearchOnDeliveryAPI, searchRequest, api.Pagination{Start: 0, Rows: 1})
+				if err != nil {
+					log.WithField(record.CSVRecordKey, record.CSVRecordKeyValue()).Error("Failed in deleting  the content ")
+					failed = append(failed, ContentDeletionFailedStatus{
+						CSVIDKey:   record.CSVRecordKey,
+						CSVIDValue: record.CSVRecordKeyValue(),
+						Error:      errors.ErrorWithStack(err),
+						Duration:   time.Since(started),
+					})
+					return nil
+				}
 			}
 			if searchResponse.Count > 0 {
 				err := delete(d, deleteMapping.AssetType, searchResponse.Documents[0].Document.ID)
+				journalResult(journal, record, searchResponse.Documents[0].Document.ID, err)
 				if err != nil {
 					log.WithField(record.CSVRecordKey, record.CSVRecordKeyValue()).Error("Failed in deleting  the content ")
 					failed = append(failed, ContentDeletionFailedStatus{
 						CSVIDKey:   record.CSVRecordKey,
 						CSVIDValue: record.CSVRecordKeyValue(),
 						Error:      errors.ErrorWithStack(err),
+						Duration:   time.Since(started),
 					})
 				} else {
 					log.WithField(record.CSVRecordKey, record.CSVRecordKeyValue()).Info("Successfully deleted the content ")
@@ -182,16 +233,70 @@ func (d deleteService) DeleteByFeed(deleteMappingName string, contentType string
 						CSVIDKey:   record.CSVRecordKey,
 						CSVIDValue: record.CSVRecordKeyValue(),
 						ContentID:  searchResponse.Documents[0].Document.ID,
+						Duration:   time.Since(started),
 					})
 				}
 			} else {
+				journalResult(journal, record, "", errors.ErrorMessageWithStack("content is not available"))
 				failed = append(failed, ContentDeletionFailedStatus{
 					CSVIDKey:   record.CSVRecordKey,
 					CSVIDValue: record.CSVRecordKeyValue(),
 					Error:      errors.ErrorMessageWithStack("content is not available"),
+					Duration:   time.Since(started),
 				})
 			}
+			return nil
+		})
+	}
+	status := ContentDeletionStatus{
+		Success: success,
+		Failed:  failed,
+		Skipped: skipped,
+	}
+	if err != nil {
+		// the records deleted before the feed failed are reported with the error
+		return status, errors.ErrorWithStack(err)
+	}
+	return status, nil
+}
+
+// DeleteDisappeared deletes the contents of the records disappeared from the feed of a delta run , or retires them when
+// the delete mapping retires
+func (d deleteService) DeleteDisappeared(deleteMappingName string, configPath string, csvRecordKey string, disappeared []SnapshotEntry) (ContentDeletionStatus, error) {
+	config, err := InitContentTypeMappingConfig(configPath)
+	if err != nil {
+		return ContentDeletionStatus{}, errors.ErrorWithStack(err)
+	}
+	deleteMapping, err := config.GetDeleteMapping(deleteMappingName)
+	if err != nil {
+		return ContentDeletionStatus{}, errors.ErrorWithStack(err)
+	}
+	failed := make([]ContentDeletionFailedStatus, 0)
+	success := make([]ContentDeletionSuccessStatus, 0)
+	for _, entry := range disappeared {
+		started := time.Now()
+		if deleteMapping.Retire {
+			err = retire(d, entry.AcousticID)
+		} else {
+			err = delete(d, deleteMapping.AssetType, entry.AcousticID)
+		}
+		if err != nil {
+			log.WithField(csvRecordKey, entry.CSVIDValue).Error("Failed in removing the content disappeared from the feed ")
+			failed = append(failed, ContentDeletionFailedStatus{
+				CSVIDKey:   csvRecordKey,
+				CSVIDValue: entry.CSVIDValue,
+				Error:      errors.ErrorWithStack(err),
+				Duration:   time.Since(started),
+			})
+			continue
 		}
+		log.WithField(csvRecordKey, entry.CSVIDValue).Info("Successfully removed the content disappeared from the feed ")
+		success = append(success, ContentDeletionSuccessStatus{
+			CSVIDKey:   csvRecordKey,
+			CSVIDValue: entry.CSVIDValue,
+			ContentID:  entry.AcousticID,
+			Duration:   time.Since(started),
+		})
 	}
 	return ContentDeletionStatus{
 		Success: success,
@@ -199,6 +304,20 @@ func (d deleteService) DeleteByFeed(deleteMappingName string, contentType string
 	}, nil
 }
 
+func retire(d deleteService, id string) error {
+	content, err := d.contentClient.Get(id)
+	if err != nil {
+		return errors.ErrorWithStack(err)
+	}
+	content.Status = CONTENT_STATUS_RETIRED
+	if _, err := d.contentClient.Update(*content); err != nil {
+		log.WithField("type", api.DOCUMENT).WithField("id", id).Info("Retire Failed")
+		return errors.ErrorWithStack(err)
+	}
+	log.WithField("type", api.DOCUMENT).WithField("id", id).Info("Retired")
+	return nil
+}
+
 func (d deleteService) Delete(libraryId string, deleteMappingName string, configPath string) error {
 	config, err := InitContentTypeMappingConfig(configPath)
 	if err != nil {
@@ -0,0 +1,61 @@
+package csv
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const deleteByFeedConfig = `
+contentType:
+  - type: product
+    csvRecordKey: sku
+    searchType: product
+    searchKeys:
+      - sku
+    fieldMapping:
+      - csvProperty: sku
+        acousticProperty: sku
+        propertyType: text
+      - csvProperty: price
+        acousticProperty: price
+        propertyType: text
+        transforms:
+          - name: numberFormat
+            args: ["2"]
+delete:
+  - name: products
+    assetType: DOCUMENT
+`
+
+func TestDeleteByFeedReportsTheDeletedRecordsWhenTheFeedFails(t *testing.T) {
+	deleted := make([]string, 0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			deleted = append(deleted, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"numFound":1,"documents":[{"document":{"id":"content-1"}}]}`))
+	}))
+	defer server.Close()
+	setTestEnv(t, server.URL)
+	configPath := writeTestFile(t, "config.yaml", deleteByFeedConfig)
+	feedPath := writeTestFile(t, "feed.csv", "sku,price\nA,1\nB,not a number\nC,3\n")
+
+	status, err := NewDeleteService(server.URL).DeleteByFeed("products", "product", feedPath, configPath)
+	if err == nil {
+		t.Fatal("expected the error of the failed transform")
+	}
+	if !strings.Contains(err.Error(), "numberFormat") {
+		t.Errorf("expected the error to name the failed transform , got %v", err)
+	}
+	if len(status.Success) != 1 || status.Success[0].CSVIDValue != "A" || status.Success[0].ContentID != "content-1" {
+		t.Errorf("expected the content deleted before the failure in the status , got %+v", status)
+	}
+	if len(deleted) != 1 {
+		t.Errorf("expected a content deleted , deleted %v", deleted)
+	}
+}
@@ -0,0 +1,198 @@
+package csv
+
+import (
+	"github.com/dekanayake/acoustic-content-sync/pkg/acoustic/author/api"
+	"github.com/dekanayake/acoustic-content-sync/pkg/env"
+	"github.com/dekanayake/acoustic-content-sync/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DEDUPE_KEEP_OLDEST = "oldest"
+	DEDUPE_KEEP_NEWEST = "newest"
+)
+
+const SKIPPED_DUPLICATE_DRY_RUN = "duplicate content , not retired in a dry run"
+
+// AmbiguousKey is a record with more than a content found for the search keys , the candidates are all the contents
+// found and the updated are the contents updated by the duplicate policy
+type AmbiguousKey struct {
+	CSVIDKey   string
+	CSVIDValue string
+	Policy     api.DuplicatePolicy
+	Candidates []string
+	UpdatedIDs []string
+}
+
+func ambiguousKey(record api.AcousticDataRecord, response *api.ContentAutheringResponse, err error) (AmbiguousKey, bool) {
+	ambiguous := AmbiguousKey{
+		CSVIDKey:   record.CSVRecordKey,
+		CSVIDValue: record.CSVRecordKeyValue(),
+		Policy:     record.DuplicatePolicy,
+	}
+	if ambiguous.Policy == "" {
+		ambiguous.Policy = api.DUPLICATE_UPDATE_FIRST
+	}
+	if err != nil {
+		candidates, ok := api.DuplicateCandidates(err)
+		ambiguous.Candidates = candidates
+		return ambiguous, ok
+	}
+	if response == nil || len(response.Candidates) == 0 {
+		return AmbiguousKey{}, false
+	}
+	ambiguous.Candidates = response.Candidates
+	ambiguous.UpdatedIDs = response.UpdatedIDs
+	return ambiguous, true
+}
+
+// Dedupe retires the duplicate contents of the content type , the contents sharing the values of the search keys. A
+// content of each key is kept , the oldest or the newest , and with merge the elements missing in the kept content are
+// copied from the duplicates before the duplicates are retired
+func (d deleteService) Dedupe(contentType string, configPath string, keep string, merge bool) (ContentDeletionStatus, error) {
+	config, err := InitContentTypeMappingConfig(configPath)
+	if err != nil {
+		return ContentDeletionStatus{}, errors.ErrorWithStack(err)
+	}
+	configTypeMapping, err := config.GetContentType(contentType)
+	if err != nil {
+		return ContentDeletionStatus{}, errors.ErrorWithStack(err)
+	}
+	if configTypeMapping.SearchType == "" {
+		return ContentDeletionStatus{}, errors.ErrorMessageWithStack("search type is required to find the duplicate contents of type :" + contentType)
+	}
+	if keep != DEDUPE_KEEP_OLDEST && keep != DEDUPE_KEEP_NEWEST {
+		return ContentDeletionStatus{}, errors.ErrorMessageWithStack("unsupported keep " + strconv.Quote(keep) + " , supported values : " + DEDUPE_KEEP_OLDEST + ", " + DEDUPE_KEEP_NEWEST)
+	}
+	index, err := api.LoadKeyIndex(configTypeMapping.SearchType, configTypeMapping.SearchKeys, configTypeMapping.SearchOnLibrary, configTypeMapping.SearchOnDeliveryAPI, configTypeMapping.PaginationRows)
+	if err != nil {
+		return ContentDeletionStatus{}, errors.ErrorWithStack(err)
+	}
+	keyName := strings.Join(configTypeMapping.SearchKeys, ", ")
+	duplicates := index.Duplicates()
+	keys := make([]string, 0, len(duplicates))
+	for key := range duplicates {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	failed := make([]ContentDeletionFailedStatus, 0)
+	success := make([]ContentDeletionSuccessStatus, 0)
+	skipped := make([]ContentDeletionSkippedStatus, 0)
+	for _, key := range keys {
+		documents := make([]api.Document, 0, len(duplicates[key]))
+		for _, document := range duplicates[key] {
+			// the duplicates retired by a previous dedupe are left as they are
+			if document.Status != CONTENT_STATUS_RETIRED {
+				documents = append(documents, document)
+			}
+		}
+		if len(documents) <= 1 {
+			continue
+		}
+		kept, extras := documents[0], documents[1:]
+		if keep == DEDUPE_KEEP_NEWEST {
+			kept, extras = documents[len(documents)-1], documents[:len(documents)-1]
+		}
+		log.WithField(keyName, key).WithField("kept", kept.ID).Info("Deduplicating " + strconv.Itoa(len(extras)) + " contents")
+		if env.IsDryRunEnabled() {
+			for _, extra := range extras {
+				skipped = append(skipped, ContentDeletionSkippedStatus{
+					CSVIDKey:   keyName,
+					CSVIDValue: key,
+					ContentID:  extra.ID,
+					Reason:     SKIPPED_DUPLICATE_DRY_RUN,
+				})
+			}
+			continue
+		}
+		if merge {
+			started := time.Now()
+			if err := d.mergeDuplicates(kept.ID, extras); err != nil {
+				log.WithField(keyName, key).Error("Failed in merging the duplicate contents ")
+				for range extras {
+					failed = append(failed, ContentDeletionFailedStatus{
+						CSVIDKey:   keyName,
+						CSVIDValue: key,
+						Error:      errors.ErrorWithStack(err),
+						Duration:   time.Since(started),
+					})
+				}
+				continue
+			}
+		}
+		for _, extra := range extras {
+			started := time.Now()
+			if err := retire(d, extra.ID); err != nil {
+				failed = append(failed, ContentDeletionFailedStatus{
+					CSVIDKey:   keyName,
+					CSVIDValue: key,
+					Error:      errors.ErrorWithStack(err),
+					Duration:   time.Since(started),
+				})
+				continue
+			}
+			success = append(success, ContentDeletionSuccessStatus{
+				CSVIDKey:   keyName,
+				CSVIDValue: key,
+				ContentID:  extra.ID,
+				Duration:   time.Since(started),
+			})
+		}
+	}
+	return ContentDeletionStatus{
+		Success: success,
+		Failed:  failed,
+		Skipped: skipped,
+	}, nil
+}
+
+// mergeDuplicates copies the elements without a value in the kept content from the duplicates , the duplicates are
+// merged in order so an element is taken from the first duplicate which has it
+func (d deleteService) mergeDuplicates(keptID string, duplicates []api.Document) error {
+	kept, err := d.contentClient.Get(keptID)
+	if err != nil {
+		return errors.ErrorWithStack(err)
+	}
+	if kept.Elements == nil {
+		kept.Elements = make(map[string]interface{})
+	}
+	merged := make([]string, 0)
+	for _, duplicate := range duplicates {
+		duplicateContent, err := d.contentClient.Get(duplicate.ID)
+		if err != nil {
+			return errors.ErrorWithStack(err)
+		}
+		for name, element := range duplicateContent.Elements {
+			if !hasElementValue(kept.Elements[name]) && hasElementValue(element) {
+				kept.Elements[name] = element
+				merged = append(merged, name)
+			}
+		}
+	}
+	if len(merged) == 0 {
+		return nil
+	}
+	if _, err := d.contentClient.Update(*kept); err != nil {
+		return errors.ErrorWithStack(err)
+	}
+	log.WithField("id", keptID).WithField("elements", merged).Info("Merged the elements of the duplicate contents")
+	return nil
+}
+
+// hasElementValue checks the element has more than the element type
+func hasElementValue(element interface{}) bool {
+	elementMap, ok := element.(map[string]interface{})
+	if !ok {
+		return element != nil
+	}
+	for name := range elementMap {
+		if name != "elementType" {
+			return true
+		}
+	}
+	return false
+}
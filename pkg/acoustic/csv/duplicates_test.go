@@ -0,0 +1,139 @@
+package csv
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const dedupeConfig = `
+contentType:
+  - type: product
+    csvRecordKey: sku
+    searchType: product
+    searchKeys:
+      - sku
+    fieldMapping:
+      - csvProperty: sku
+        acousticProperty: sku
+        propertyType: text
+`
+
+var dedupeContents = map[string]string{
+	"old":     `{"id":"old","status":"ready","created":"2022-01-01T00:00:00Z","elements":{"sku":{"elementType":"text","value":"A"},"description":{"elementType":"text"}}}`,
+	"middle":  `{"id":"middle","status":"ready","created":"2022-02-01T00:00:00Z","elements":{"sku":{"elementType":"text","value":"A"},"description":{"elementType":"text","value":"from middle"}}}`,
+	"new":     `{"id":"new","status":"ready","created":"2022-03-01T00:00:00Z","elements":{"sku":{"elementType":"text","value":"A"},"description":{"elementType":"text","value":"from new"}}}`,
+	"retired": `{"id":"retired","status":"retired","created":"2021-01-01T00:00:00Z","elements":{"sku":{"elementType":"text","value":"A"}}}`,
+	"single":  `{"id":"single","status":"ready","created":"2022-01-01T00:00:00Z","elements":{"sku":{"elementType":"text","value":"B"}}}`,
+}
+
+// dedupeServer serves the contents of dedupeContents for the search and the gets , the bodies of the updates are kept by
+// the ID of the content
+func dedupeServer(t *testing.T, updates map[string][]map[string]interface{}) *httptest.Server {
+	var mux sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		id := strings.TrimPrefix(r.URL.Path, "/authoring/v1/content/")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/search"):
+			documents := make([]string, 0, len(dedupeContents))
+			for _, content := range dedupeContents {
+				documents = append(documents, `{"document":`+content+`}`)
+			}
+			sort.Strings(documents)
+			w.Write([]byte(`{"numFound":5,"start":0,"rows":100,"documents":[` + strings.Join(documents, ",") + `]}`))
+		case r.Method == http.MethodGet:
+			w.Write([]byte(dedupeContents[id]))
+		case r.Method == http.MethodPut:
+			var update map[string]interface{}
+			if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+				t.Error(err)
+			}
+			mux.Lock()
+			updates[id] = append(updates[id], update)
+			mux.Unlock()
+			w.Write([]byte(`{"id":"` + id + `"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestDedupe(t *testing.T) {
+	tests := []struct {
+		name        string
+		keep        string
+		merge       bool
+		retired     []string
+		description interface{}
+	}{
+		{name: "keep oldest", keep: DEDUPE_KEEP_OLDEST, retired: []string{"middle", "new"}},
+		{name: "keep newest", keep: DEDUPE_KEEP_NEWEST, retired: []string{"middle", "old"}},
+		{name: "keep oldest and merge", keep: DEDUPE_KEEP_OLDEST, merge: true, retired: []string{"middle", "new"}, description: "from middle"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			updates := make(map[string][]map[string]interface{})
+			server := dedupeServer(t, updates)
+			setTestEnv(t, server.URL)
+			configPath := writeTestFile(t, "config.yaml", dedupeConfig)
+
+			status, err := NewDeleteService(server.URL).Dedupe("product", configPath, test.keep, test.merge)
+			if err != nil {
+				t.Fatal(err)
+			}
+			retired := make([]string, 0)
+			for _, success := range status.Success {
+				retired = append(retired, success.ContentID)
+				if updates[success.ContentID][0]["status"] != CONTENT_STATUS_RETIRED {
+					t.Errorf("expected %s retired , updated with %v", success.ContentID, updates[success.ContentID])
+				}
+			}
+			sort.Strings(retired)
+			if !reflect.DeepEqual(retired, test.retired) || len(status.Failed) != 0 {
+				t.Errorf("expected %v retired , got %+v", test.retired, status)
+			}
+			kept := "old"
+			if test.keep == DEDUPE_KEEP_NEWEST {
+				kept = "new"
+			}
+			if test.description == nil {
+				if len(updates[kept]) != 0 {
+					t.Errorf("expected the kept content not updated , updated with %v", updates[kept])
+				}
+				return
+			}
+			if len(updates[kept]) != 1 {
+				t.Fatalf("expected the kept content merged , updated with %v", updates[kept])
+			}
+			description := updates[kept][0]["elements"].(map[string]interface{})["description"].(map[string]interface{})
+			if description["value"] != test.description {
+				t.Errorf("expected the description merged from the first duplicate , got %v", description)
+			}
+		})
+	}
+}
+
+func TestDedupeDryRunRetiresNothing(t *testing.T) {
+	updates := make(map[string][]map[string]interface{})
+	server := dedupeServer(t, updates)
+	setTestEnv(t, server.URL)
+	t.Setenv("DryRun", "true")
+	configPath := writeTestFile(t, "config.yaml", dedupeConfig)
+
+	status, err := NewDeleteService(server.URL).Dedupe("product", configPath, DEDUPE_KEEP_OLDEST, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(status.Skipped) != 2 || status.Skipped[0].Reason != SKIPPED_DUPLICATE_DRY_RUN || len(updates) != 0 {
+		t.Errorf("expected the duplicates skipped in a dry run , got %+v updates %v", status, updates)
+	}
+}
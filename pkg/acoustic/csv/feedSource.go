@@ -0,0 +1,49 @@
+package csv
+
+import (
+	"github.com/dekanayake/acoustic-content-sync/pkg/acoustic/author/api"
+	"github.com/dekanayake/acoustic-content-sync/pkg/errors"
+	"path/filepath"
+	"strings"
+)
+
+// LoadFeed opens the feed with the reader of the feed type. When the feed type is not set ( or is CSV/Acoustic )
+// the reader is selected by the file extension , so the same field mapping works for CSV, JSON, JSON Lines and XLSX feeds.
+// The dialect is only used by the CSV feeds
+func LoadFeed(feedPath string, feedType api.FeedType, feedSheet string, dialect CSVDialect) (DataFeed, error) {
+	switch resolveFeedType(feedPath, feedType) {
+	case api.JSON:
+		return LoadJSON(feedPath)
+	case api.JSONL:
+		return LoadJSONLines(feedPath)
+	case api.XLSX:
+		return LoadXLSX(feedPath, feedSheet)
+	case api.CSV:
+		return LoadCSVWithDialect(feedPath, dialect)
+	default:
+		return nil, errors.ErrorMessageWithStack("Unsupported feed type :" + string(feedType))
+	}
+}
+
+func resolveFeedType(feedPath string, feedType api.FeedType) api.FeedType {
+	switch api.FeedType(strings.ToUpper(string(feedType))) {
+	case api.JSON:
+		return api.JSON
+	case api.JSONL:
+		return api.JSONL
+	case api.XLSX:
+		return api.XLSX
+	case api.CSV:
+		return api.CSV
+	}
+	switch strings.ToLower(filepath.Ext(feedPath)) {
+	case ".json":
+		return api.JSON
+	case ".jsonl", ".ndjson":
+		return api.JSONL
+	case ".xlsx":
+		return api.XLSX
+	default:
+		return api.CSV
+	}
+}
@@ -0,0 +1,218 @@
+package csv
+
+import (
+	"github.com/dekanayake/acoustic-content-sync/pkg/acoustic/author/api"
+	"github.com/dekanayake/acoustic-content-sync/pkg/env"
+	"github.com/dekanayake/acoustic-content-sync/pkg/errors"
+	"strings"
+)
+
+type RowGrouping string
+
+const (
+	// the consecutive rows with the same record key are a record
+	GROUP_CONSECUTIVE_ROWS RowGrouping = "consecutive"
+	// all the rows with the same record key are a record , the feed is read in to memory
+	GROUP_KEYED_ROWS RowGrouping = "keyed"
+)
+
+// groupedRows is implemented by a row collapsed from the rows sharing the record key
+type groupedRows interface {
+	Rows() []DataRow
+}
+
+type groupedDataFeed struct {
+	DataFeed
+	keyMapping ContentFieldMapping
+	grouping   RowGrouping
+	// the first row of the next record , read ahead to find the end of the current record
+	pending DataRow
+	// the records of a keyed grouping
+	records []*groupedDataRow
+}
+
+// groupedDataRow is a record of the rows sharing the record key , a column is read from the first row with a value and
+// the multi value fields read all the rows
+type groupedDataRow struct {
+	rows []DataRow
+}
+
+// LoadGroupedFeed opens the feed of the content type mapping ( with its joins ) and collapses the rows sharing the record
+// key in to a record when the rows are grouped
+func LoadGroupedFeed(feedPath string, configTypeMapping *ContentTypeMapping) (DataFeed, error) {
+	dataFeed, err := LoadJoinedFeed(feedPath, configTypeMapping)
+	if err != nil {
+		return nil, errors.ErrorWithStack(err)
+	}
+	if configTypeMapping.GroupRows == "" {
+		return dataFeed, nil
+	}
+	keyMapping, err := configTypeMapping.recordKeyMapping()
+	if err != nil {
+		dataFeed.Close()
+		return nil, errors.ErrorWithStack(err)
+	}
+	groupedFeed := &groupedDataFeed{
+		DataFeed:   dataFeed,
+		keyMapping: keyMapping,
+		grouping:   configTypeMapping.GroupRows,
+	}
+	if groupedFeed.grouping == GROUP_KEYED_ROWS {
+		groupedFeed.groupByKey()
+	}
+	return groupedFeed, nil
+}
+
+func (csvContentTypeMapping *ContentTypeMapping) recordKeyMapping() (ContentFieldMapping, error) {
+	for _, fieldMapping := range csvContentTypeMapping.FieldMapping {
+		if fieldMapping.AcousticProperty == csvContentTypeMapping.CsvRecordKey {
+			return fieldMapping, nil
+		}
+	}
+	return ContentFieldMapping{}, errors.ErrorMessageWithStack("No mapping found for the record key :" + csvContentTypeMapping.CsvRecordKey + " , the rows are grouped by the record key")
+}
+
+// rowKey is the record key of the row , a row without a key ( or with a key which can not be read ) belongs to the record
+// of the previous row
+func (dataFeed *groupedDataFeed) rowKey(dataRow DataRow) string {
+	key, err := dataFeed.keyMapping.getCsvValueOrStaticValue(dataRow)
+	if err != nil {
+		return ""
+	}
+	return key
+}
+
+func (dataFeed *groupedDataFeed) groupByKey() {
+	records := make(map[string]*groupedDataRow)
+	var previous *groupedDataRow
+	for dataFeed.DataFeed.HasNext() {
+		dataRow := dataFeed.DataFeed.Next()
+		key := dataFeed.rowKey(dataRow)
+		if key == "" && previous != nil {
+			previous.rows = append(previous.rows, dataRow)
+			continue
+		}
+		record, ok := records[key]
+		if !ok || key == "" {
+			record = &groupedDataRow{}
+			records[key] = record
+			dataFeed.records = append(dataFeed.records, record)
+		}
+		record.rows = append(record.rows, dataRow)
+		previous = record
+	}
+}
+
+func (dataFeed *groupedDataFeed) HasNext() bool {
+	if dataFeed.grouping == GROUP_KEYED_ROWS {
+		return len(dataFeed.records) > 0
+	}
+	return dataFeed.pending != nil || dataFeed.DataFeed.HasNext()
+}
+
+func (dataFeed *groupedDataFeed) Next() DataRow {
+	if dataFeed.grouping == GROUP_KEYED_ROWS {
+		record := dataFeed.records[0]
+		dataFeed.records = dataFeed.records[1:]
+		return record
+	}
+	first := dataFeed.pending
+	dataFeed.pending = nil
+	if first == nil {
+		first = dataFeed.DataFeed.Next()
+	}
+	record := &groupedDataRow{rows: []DataRow{first}}
+	key := dataFeed.rowKey(first)
+	for dataFeed.DataFeed.HasNext() {
+		dataRow := dataFeed.DataFeed.Next()
+		rowKey := dataFeed.rowKey(dataRow)
+		if rowKey != "" && rowKey != key {
+			dataFeed.pending = dataRow
+			break
+		}
+		record.rows = append(record.rows, dataRow)
+	}
+	return record
+}
+
+func (dataRow *groupedDataRow) Rows() []DataRow {
+	return dataRow.rows
+}
+
+// RowNumber of a record is the number of its first row
+func (dataRow *groupedDataRow) RowNumber() int {
+	return dataRow.rows[0].RowNumber()
+}
+
+func (dataRow *groupedDataRow) Get(columnName string) (string, error) {
+	value, err := dataRow.rows[0].Get(columnName)
+	if err != nil || value != "" {
+		return value, err
+	}
+	for _, row := range dataRow.rows[1:] {
+		if value, err := row.Get(columnName); err == nil && value != "" {
+			return value, nil
+		}
+	}
+	return "", nil
+}
+
+// groupedValue is the values of the rows of a grouped record for a multi value field , the empty values are left out. A
+// static value is not read from the rows , it is read once for the record
+func (contentFieldMapping ContentFieldMapping) groupedValue(dataRow DataRow, configTypeMapping *ContentTypeMapping) (string, bool, error) {
+	grouped, ok := dataRow.(groupedRows)
+	propertyType := api.FieldType(contentFieldMapping.PropertyType)
+	if !ok || !isMultiFieldType(propertyType) || propertyType == api.MultiGroup {
+		return "", false, nil
+	}
+	if contentFieldMapping.JoinedValue == "" && contentFieldMapping.StaticValue != "" {
+		return "", false, nil
+	}
+	values := make([]string, 0, len(grouped.Rows()))
+	for _, row := range grouped.Rows() {
+		value, err := contentFieldMapping.getCsvValueOrStaticValue(row)
+		if err != nil {
+			return "", true, err
+		}
+		value, err = contentFieldMapping.lookupValue(value, row, configTypeMapping)
+		if err != nil {
+			return "", true, err
+		}
+		if value != "" {
+			values = append(values, value)
+		}
+	}
+	return strings.Join(values, env.MultipleItemsSeperator()), true, nil
+}
+
+// groupedMultiGroup converts each row of a grouped record with a value to an item of the multi group
+func (contentFieldMapping ContentFieldMapping) groupedMultiGroup(grouped groupedRows, configTypeMapping *ContentTypeMapping) ([][]api.GenericData, error) {
+	groupDataList := make([][]api.GenericData, 0, len(grouped.Rows()))
+	for _, row := range grouped.Rows() {
+		hasValue := false
+		for _, fieldMapping := range contentFieldMapping.FieldMapping {
+			value, err := fieldMapping.getSourceValue(row)
+			if err == nil && value != "" && fieldMapping.StaticValue == "" {
+				hasValue = true
+				break
+			}
+		}
+		if !hasValue {
+			continue
+		}
+		fieldMappings, err := applicableFieldMappings(contentFieldMapping.FieldMapping, row)
+		if err != nil {
+			return nil, errors.ErrorWithStack(err)
+		}
+		dataList := make([]api.GenericData, 0, len(fieldMappings))
+		for _, fieldMapping := range fieldMappings {
+			data, err := fieldMapping.ConvertToGenericData(row, configTypeMapping)
+			if err != nil {
+				return nil, errors.ErrorWithStack(err)
+			}
+			dataList = append(dataList, data)
+		}
+		groupDataList = append(groupDataList, dataList)
+	}
+	return groupDataList, nil
+}
@@ -0,0 +1,34 @@
+package csv
+
+import (
+	"testing"
+)
+
+func TestGroupedValue(t *testing.T) {
+	t.Setenv("MultipleItemsSeperator", ";")
+	record := &groupedDataRow{rows: []DataRow{
+		&dataRow{columns: map[string]string{"sku": "A", "tag": "red"}, rowNumber: 1},
+		&dataRow{columns: map[string]string{"sku": "", "tag": ""}, rowNumber: 2},
+		&dataRow{columns: map[string]string{"sku": "", "tag": "blue"}, rowNumber: 3},
+	}}
+	tests := []struct {
+		name         string
+		fieldMapping ContentFieldMapping
+		value        string
+	}{
+		{name: "column of the rows", fieldMapping: ContentFieldMapping{CsvProperty: "tag", PropertyType: "multi-text"}, value: "red;blue"},
+		{name: "static value", fieldMapping: ContentFieldMapping{StaticValue: "new;sale", PropertyType: "multi-text"}, value: "new;sale"},
+		{name: "single value field", fieldMapping: ContentFieldMapping{CsvProperty: "tag", PropertyType: "text"}, value: "red"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			value, err := test.fieldMapping.getMappedValue(record, &ContentTypeMapping{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if value != test.value {
+				t.Errorf("expected %q , got %q", test.value, value)
+			}
+		})
+	}
+}
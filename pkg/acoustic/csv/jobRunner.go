@@ -0,0 +1,303 @@
+package csv
+
+import (
+	"github.com/dekanayake/acoustic-content-sync/pkg/env"
+	"github.com/dekanayake/acoustic-content-sync/pkg/errors"
+	"github.com/goccy/go-yaml"
+	log "github.com/sirupsen/logrus"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+type JobStepType string
+
+const (
+	JOB_STEP_CATEGORY   JobStepType = "category"
+	JOB_STEP_CONTENT    JobStepType = "content"
+	JOB_STEP_SITE_PAGES JobStepType = "sitePages"
+	JOB_STEP_DELETE     JobStepType = "delete"
+)
+
+var jobStepTypes = []JobStepType{JOB_STEP_CATEGORY, JOB_STEP_CONTENT, JOB_STEP_SITE_PAGES, JOB_STEP_DELETE}
+
+type JobStepStatus string
+
+const SKIPPED_STEP_DRY_RUN = "only the content steps are run in a dry run"
+
+const (
+	JOB_STEP_SUCCEEDED JobStepStatus = "succeeded"
+	JOB_STEP_FAILED    JobStepStatus = "failed"
+	JOB_STEP_SKIPPED   JobStepStatus = "skipped"
+)
+
+// JobManifest is an ingestion of several feeds run as a single job , the steps are run in the order of the manifest
+type JobManifest struct {
+	Name string `yaml:"name"`
+	// the config of the steps without a config of their own
+	Config string `yaml:"config"`
+	// the job stops at the first failed step by default , with continue on failure only the steps depending on a failed
+	// step are skipped
+	ContinueOnFailure bool      `yaml:"continueOnFailure"`
+	Steps             []JobStep `yaml:"steps"`
+}
+
+type JobStep struct {
+	Name   string      `yaml:"name"`
+	Type   JobStepType `yaml:"type"`
+	Config string      `yaml:"config"`
+	Feed   string      `yaml:"feed"`
+	// the content type of the content , site pages and delete by feed steps
+	ContentType string `yaml:"contentType"`
+	// the root category of a category step
+	CategoryName string `yaml:"categoryName"`
+	SiteID       string `yaml:"siteID"`
+	ParentPageID string `yaml:"parentPageID"`
+	// the delete mapping of a delete step , the contents of the records of the feed are deleted when the step has a feed
+	DeleteMapping string `yaml:"deleteMapping"`
+	// the steps which should succeed before the step , they should be earlier in the manifest
+	DependsOn []string `yaml:"dependsOn"`
+	// the failed records tolerated by the step , as a number of records or as a rate of the records of the step
+	MaxFailures    int     `yaml:"maxFailures"`
+	MaxFailureRate float64 `yaml:"maxFailureRate"`
+	// env variables set while the step runs , like WorkerCount or LibraryID
+	Settings map[string]string `yaml:"settings"`
+}
+
+type JobRunner interface {
+	Run(manifestPath string, report *RunReport) error
+}
+
+type jobRunner struct {
+	acousticApiUrl string
+}
+
+func NewJobRunner(acousticApiUrl string) JobRunner {
+	return &jobRunner{
+		acousticApiUrl: acousticApiUrl,
+	}
+}
+
+// LoadJobManifest reads the manifest , the relative paths of the manifest are resolved against the folder of the manifest
+func LoadJobManifest(manifestPath string) (*JobManifest, error) {
+	manifestContent, err := ioutil.ReadFile(manifestPath)
+	if err != nil {
+		return nil, errors.ErrorWithStack(err)
+	}
+	manifest := &JobManifest{}
+	if err := yaml.Unmarshal(manifestContent, manifest); err != nil {
+		return nil, errors.ErrorWithStack(err)
+	}
+	manifestDir := filepath.Dir(manifestPath)
+	manifest.Config = manifestRelativePath(manifestDir, manifest.Config)
+	for index := range manifest.Steps {
+		step := &manifest.Steps[index]
+		step.Config = manifestRelativePath(manifestDir, step.Config)
+		step.Feed = manifestRelativePath(manifestDir, step.Feed)
+		if step.Config == "" {
+			step.Config = manifest.Config
+		}
+	}
+	if problems := manifest.validate(); len(problems) > 0 {
+		return nil, errors.ErrorMessageWithStack("There are " + strconv.Itoa(len(problems)) + " problems in the job manifest :" + manifestPath + "\n" + strings.Join(problems, "\n"))
+	}
+	return manifest, nil
+}
+
+func manifestRelativePath(manifestDir string, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(manifestDir, path)
+}
+
+func (manifest *JobManifest) validate() []string {
+	problems := make([]string, 0)
+	if len(manifest.Steps) == 0 {
+		problems = append(problems, "steps : the job has no steps")
+	}
+	stepNames := make(map[string]bool, len(manifest.Steps))
+	for index, step := range manifest.Steps {
+		path := "steps[" + strconv.Itoa(index) + "]"
+		required := func(field string, value string) {
+			if value == "" {
+				problems = append(problems, path+"."+field+" : "+field+" is required for a "+string(step.Type)+" step")
+			}
+		}
+		if step.Name == "" {
+			problems = append(problems, path+".name : name is required")
+		} else if stepNames[step.Name] {
+			problems = append(problems, path+".name : step name "+strconv.Quote(step.Name)+" is used by another step")
+		}
+		for _, dependency := range step.DependsOn {
+			if !stepNames[dependency] {
+				problems = append(problems, path+".dependsOn : "+strconv.Quote(dependency)+" is not a step before the step")
+			}
+		}
+		stepNames[step.Name] = true
+		required("config", step.Config)
+		switch step.Type {
+		case JOB_STEP_CATEGORY:
+			required("feed", step.Feed)
+			required("categoryName", step.CategoryName)
+		case JOB_STEP_CONTENT:
+			required("feed", step.Feed)
+			required("contentType", step.ContentType)
+		case JOB_STEP_SITE_PAGES:
+			required("feed", step.Feed)
+			required("contentType", step.ContentType)
+			required("siteID", step.SiteID)
+			required("parentPageID", step.ParentPageID)
+		case JOB_STEP_DELETE:
+			required("deleteMapping", step.DeleteMapping)
+			if step.Feed != "" {
+				required("contentType", step.ContentType)
+			}
+		default:
+			types := make([]string, 0, len(jobStepTypes))
+			for _, stepType := range jobStepTypes {
+				types = append(types, string(stepType))
+			}
+			problems = append(problems, path+".type : unsupported step type "+strconv.Quote(string(step.Type))+" , supported types : "+strings.Join(types, ", "))
+		}
+		if step.MaxFailures < 0 {
+			problems = append(problems, path+".maxFailures : maxFailures should not be negative")
+		}
+		if step.MaxFailureRate < 0 || step.MaxFailureRate > 1 {
+			problems = append(problems, path+".maxFailureRate : maxFailureRate should be between 0 and 1")
+		}
+	}
+	return problems
+}
+
+// Run runs the steps of the job in order with a combined report , the job fails when a step fails. A step fails when it
+// ends with an error or its failed records are more than the tolerated failures
+func (runner *jobRunner) Run(manifestPath string, report *RunReport) error {
+	manifest, err := LoadJobManifest(manifestPath)
+	if err != nil {
+		return errors.ErrorWithStack(err)
+	}
+	log.Info("Running the job " + manifest.Name + " with " + strconv.Itoa(len(manifest.Steps)) + " steps")
+	statuses := make(map[string]JobStepStatus, len(manifest.Steps))
+	failedSteps := make([]string, 0)
+	for _, step := range manifest.Steps {
+		if reason := skipReason(manifest, step, statuses, len(failedSteps) > 0); reason != "" {
+			log.Info("Skipped the step " + step.Name + " , " + reason)
+			statuses[step.Name] = JOB_STEP_SKIPPED
+			report.AddStep(RunReportStep{Name: step.Name, Type: string(step.Type), Status: JOB_STEP_SKIPPED, Reason: reason}, nil)
+			continue
+		}
+		if env.IsDryRunEnabled() && step.Type != JOB_STEP_CONTENT {
+			// the steps depending on the step are run , as a dry run of the step would not fail
+			log.Info("Skipped the step " + step.Name + " , " + SKIPPED_STEP_DRY_RUN)
+			statuses[step.Name] = JOB_STEP_SUCCEEDED
+			report.AddStep(RunReportStep{Name: step.Name, Type: string(step.Type), Status: JOB_STEP_SKIPPED, Reason: SKIPPED_STEP_DRY_RUN}, nil)
+			continue
+		}
+		log.Info("Running the step " + step.Name)
+		stepReport := NewRunReport(string(step.Type))
+		err := runner.runStep(step, stepReport)
+		stepReport.Finish(err)
+		status := JOB_STEP_SUCCEEDED
+		if err != nil {
+			status = JOB_STEP_FAILED
+			log.WithError(err).Error("The step " + step.Name + " failed")
+		} else if step.exceedsFailures(stepReport) {
+			status = JOB_STEP_FAILED
+			log.Error("The step " + step.Name + " failed with " + strconv.Itoa(stepReport.failures()) + " failed records")
+		}
+		if status == JOB_STEP_FAILED {
+			failedSteps = append(failedSteps, step.Name)
+		}
+		statuses[step.Name] = status
+		report.AddStep(RunReportStep{
+			Name:       step.Name,
+			Type:       string(step.Type),
+			Status:     status,
+			DurationMs: stepReport.DurationMs,
+			Totals:     stepReport.Totals,
+			Error:      stepReport.Error,
+			ErrorClass: stepReport.ErrorClass,
+		}, stepReport)
+	}
+	if len(failedSteps) > 0 {
+		return errors.ErrorMessageWithStack("The steps " + strings.Join(failedSteps, ", ") + " of the job failed")
+	}
+	return nil
+}
+
+func skipReason(manifest *JobManifest, step JobStep, statuses map[string]JobStepStatus, failed bool) string {
+	if failed && !manifest.ContinueOnFailure {
+		return "a previous step failed"
+	}
+	for _, dependency := range step.DependsOn {
+		if statuses[dependency] != JOB_STEP_SUCCEEDED {
+			return "the step " + dependency + " is " + string(statuses[dependency])
+		}
+	}
+	return ""
+}
+
+// exceedsFailures checks the failed records of the step are more than the tolerated failures , either limit tolerates
+// the failures when both are set
+func (step JobStep) exceedsFailures(stepReport *RunReport) bool {
+	failures := stepReport.failures()
+	exceeds := failures > step.MaxFailures
+	if step.MaxFailureRate > 0 && stepReport.Totals.Total > 0 {
+		exceeds = exceeds && float64(failures)/float64(stepReport.Totals.Total) > step.MaxFailureRate
+	}
+	return exceeds
+}
+
+func (runner *jobRunner) runStep(step JobStep, stepReport *RunReport) error {
+	restore := setStepEnv(step.Settings)
+	defer restore()
+	switch step.Type {
+	case JOB_STEP_CATEGORY:
+		return NewCategoryService(runner.acousticApiUrl).Create(step.CategoryName, step.Feed, step.Config)
+	case JOB_STEP_CONTENT:
+		status, err := NewContentUseCase(runner.acousticApiUrl, env.LibraryID()).CreateBatch(step.ContentType, step.Feed, step.Config)
+		stepReport.AddContentCreationStatus(status)
+		return err
+	case JOB_STEP_SITE_PAGES:
+		restoreContentType := setStepEnv(map[string]string{"ParentPageContentTypeID": step.ContentType})
+		defer restoreContentType()
+		status, err := NewSiteUseCase(runner.acousticApiUrl).CreatePages(step.SiteID, step.ParentPageID, step.ContentType, step.Feed, step.Config)
+		stepReport.AddContentCreationStatus(status)
+		return err
+	case JOB_STEP_DELETE:
+		deleteService := NewDeleteService(runner.acousticApiUrl)
+		if step.Feed == "" {
+			return deleteService.Delete(env.LibraryID(), step.DeleteMapping, step.Config)
+		}
+		status, err := deleteService.DeleteByFeed(step.DeleteMapping, step.ContentType, step.Feed, step.Config)
+		stepReport.AddContentDeletionStatus(status)
+		return err
+	default:
+		return errors.ErrorMessageWithStack("unsupported step type " + string(step.Type))
+	}
+}
+
+// setStepEnv sets the env variables of the step , the returned function restores the env variables as they were
+func setStepEnv(settings map[string]string) func() {
+	previous := make(map[string]*string, len(settings))
+	for name, value := range settings {
+		if previousValue, ok := os.LookupEnv(name); ok {
+			previous[name] = &previousValue
+		} else {
+			previous[name] = nil
+		}
+		os.Setenv(name, value)
+	}
+	return func() {
+		for name, previousValue := range previous {
+			if previousValue == nil {
+				os.Unsetenv(name)
+			} else {
+				os.Setenv(name, *previousValue)
+			}
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package csv
+
+import (
+	"strings"
+	"testing"
+)
+
+func stepReportOf(total int, failed int) *RunReport {
+	report := NewRunReport("content")
+	report.Totals.Total = total
+	report.Totals.Failed = failed
+	return report
+}
+
+func TestJobStepExceedsFailures(t *testing.T) {
+	tests := []struct {
+		name           string
+		maxFailures    int
+		maxFailureRate float64
+		total          int
+		failed         int
+		exceeds        bool
+	}{
+		{name: "no failures", total: 10},
+		{name: "no limit", total: 10, failed: 1, exceeds: true},
+		{name: "within max failures", maxFailures: 2, total: 10, failed: 2},
+		{name: "over max failures", maxFailures: 2, total: 10, failed: 3, exceeds: true},
+		{name: "within max failure rate", maxFailureRate: 0.2, total: 10, failed: 2},
+		{name: "over max failure rate", maxFailureRate: 0.2, total: 10, failed: 3, exceeds: true},
+		{name: "both limits , over both", maxFailures: 2, maxFailureRate: 0.2, total: 10, failed: 3, exceeds: true},
+		{name: "both limits , within the rate", maxFailures: 2, maxFailureRate: 0.5, total: 10, failed: 3},
+		{name: "both limits , within max failures", maxFailures: 5, maxFailureRate: 0.2, total: 10, failed: 3},
+		{name: "rate of a step without records", maxFailureRate: 0.2, failed: 1, exceeds: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			step := JobStep{MaxFailures: test.maxFailures, MaxFailureRate: test.maxFailureRate}
+			if exceeds := step.exceedsFailures(stepReportOf(test.total, test.failed)); exceeds != test.exceeds {
+				t.Errorf("expected exceeds %v , got %v", test.exceeds, exceeds)
+			}
+		})
+	}
+}
+
+func TestJobManifestValidate(t *testing.T) {
+	contentStep := JobStep{Name: "products", Type: JOB_STEP_CONTENT, Config: "config.yaml", Feed: "products.csv", ContentType: "product"}
+	tests := []struct {
+		name     string
+		steps    []JobStep
+		problems []string
+	}{
+		{name: "valid", steps: []JobStep{contentStep}},
+		{name: "no steps", problems: []string{"steps : the job has no steps"}},
+		{
+			name:     "missing fields",
+			steps:    []JobStep{{Name: "products", Type: JOB_STEP_CONTENT}},
+			problems: []string{"steps[0].config :", "steps[0].feed :", "steps[0].contentType :"},
+		},
+		{
+			name:     "duplicate name",
+			steps:    []JobStep{contentStep, contentStep},
+			problems: []string{"steps[1].name : step name \"products\" is used by another step"},
+		},
+		{
+			name: "dependency after the step",
+			steps: []JobStep{
+				{Name: "pages", Type: JOB_STEP_DELETE, Config: "config.yaml", DeleteMapping: "pages", DependsOn: []string{"products"}},
+				contentStep,
+			},
+			problems: []string{"steps[0].dependsOn : \"products\" is not a step before the step"},
+		},
+		{
+			name:     "unsupported type",
+			steps:    []JobStep{{Name: "products", Type: "import", Config: "config.yaml"}},
+			problems: []string{"steps[0].type : unsupported step type \"import\""},
+		},
+		{
+			name: "limits out of range",
+			steps: []JobStep{
+				{Name: "products", Type: JOB_STEP_CONTENT, Config: "config.yaml", Feed: "products.csv", ContentType: "product", MaxFailures: -1, MaxFailureRate: 1.5},
+			},
+			problems: []string{"steps[0].maxFailures :", "steps[0].maxFailureRate :"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			problems := (&JobManifest{Steps: test.steps}).validate()
+			if len(problems) != len(test.problems) {
+				t.Fatalf("expected %d problems , got %v", len(test.problems), problems)
+			}
+			for index, problem := range test.problems {
+				if !strings.HasPrefix(problems[index], problem) {
+					t.Errorf("expected the problem %q , got %q", problem, problems[index])
+				}
+			}
+		})
+	}
+}
+
+func TestSkipReason(t *testing.T) {
+	step := JobStep{Name: "pages", DependsOn: []string{"products"}}
+	tests := []struct {
+		name              string
+		continueOnFailure bool
+		step              JobStep
+		status            JobStepStatus
+		failed            bool
+		reason            string
+	}{
+		{name: "dependency succeeded", step: step, status: JOB_STEP_SUCCEEDED},
+		{name: "previous step failed", step: JobStep{Name: "pages"}, status: JOB_STEP_FAILED, failed: true, reason: "a previous step failed"},
+		{name: "continue on failure without dependency", continueOnFailure: true, step: JobStep{Name: "pages"}, status: JOB_STEP_FAILED, failed: true},
+		{name: "continue on failure , dependency failed", continueOnFailure: true, step: step, status: JOB_STEP_FAILED, failed: true, reason: "the step products is failed"},
+		{name: "continue on failure , dependency skipped", continueOnFailure: true, step: step, status: JOB_STEP_SKIPPED, failed: true, reason: "the step products is skipped"},
+		{name: "continue on failure , dependency succeeded", continueOnFailure: true, step: step, status: JOB_STEP_SUCCEEDED, failed: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			manifest := &JobManifest{ContinueOnFailure: test.continueOnFailure}
+			statuses := map[string]JobStepStatus{"products": test.status}
+			if reason := skipReason(manifest, test.step, statuses, test.failed); reason != test.reason {
+				t.Errorf("expected the reason %q , got %q", test.reason, reason)
+			}
+		})
+	}
+}
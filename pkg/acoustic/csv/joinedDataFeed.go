@@ -0,0 +1,213 @@
+package csv
+
+import (
+	"encoding/json"
+	"github.com/dekanayake/acoustic-content-sync/pkg/acoustic/author/api"
+	"github.com/dekanayake/acoustic-content-sync/pkg/env"
+	"github.com/dekanayake/acoustic-content-sync/pkg/errors"
+	"strconv"
+	"strings"
+)
+
+type JoinType string
+
+const (
+	INNER_JOIN JoinType = "inner"
+	LEFT_JOIN  JoinType = "left"
+)
+
+// FeedJoin is a secondary feed joined to the rows of the main feed on a key column. The columns of the joined feed are
+// read with the name of the join as the prefix , <name>.<column>
+type FeedJoin struct {
+	Name       string       `yaml:"name"`
+	Feed       string       `yaml:"feed"`
+	FeedType   api.FeedType `yaml:"feedType"`
+	FeedSheet  string       `yaml:"feedSheet"`
+	CSVDialect CSVDialect   `yaml:"csvDialect"`
+	// the key column of the main feed , and of the joined feed when the foreign key is not set
+	Key        string `yaml:"key"`
+	ForeignKey string `yaml:"foreignKey"`
+	// inner skips the rows of the main feed without a joined row , left ( the default ) reads them with empty joined columns
+	JoinType JoinType `yaml:"joinType"`
+	// many joins all the rows with the key , a joined column is the values of the rows separated by the multiple items
+	// separator and the <name> column is the JSON list of the rows , for the multi groups
+	Many bool `yaml:"many"`
+}
+
+func (join FeedJoin) foreignKey() string {
+	if join.ForeignKey != "" {
+		return join.ForeignKey
+	}
+	return join.Key
+}
+
+type loadedJoin struct {
+	FeedJoin
+	headers []string
+	rows    map[string][]map[string]string
+}
+
+type joinedDataFeed struct {
+	DataFeed
+	joins []loadedJoin
+	next  DataRow
+}
+
+type joinedDataRow struct {
+	DataRow
+	joinedColumns map[string]string
+}
+
+// LoadJoinedFeed opens the main feed with the joins of the content type mapping , the joined feeds are read in to memory
+// and the main feed is streamed
+func LoadJoinedFeed(feedPath string, configTypeMapping *ContentTypeMapping) (DataFeed, error) {
+	if len(configTypeMapping.Joins) == 0 {
+		return LoadFeed(feedPath, configTypeMapping.FeedType, configTypeMapping.FeedSheet, configTypeMapping.CSVDialect)
+	}
+	joins := make([]loadedJoin, 0, len(configTypeMapping.Joins))
+	for _, join := range configTypeMapping.Joins {
+		loaded, err := loadJoin(join)
+		if err != nil {
+			return nil, errors.ErrorWithStack(err)
+		}
+		joins = append(joins, loaded)
+	}
+	dataFeed, err := LoadFeed(feedPath, configTypeMapping.FeedType, configTypeMapping.FeedSheet, configTypeMapping.CSVDialect)
+	if err != nil {
+		return nil, errors.ErrorWithStack(err)
+	}
+	joinedFeed := &joinedDataFeed{
+		DataFeed: dataFeed,
+		joins:    joins,
+	}
+	joinedFeed.readNext()
+	return joinedFeed, nil
+}
+
+func loadJoin(join FeedJoin) (loadedJoin, error) {
+	dataFeed, err := LoadFeed(join.Feed, join.FeedType, join.FeedSheet, join.CSVDialect)
+	if err != nil {
+		return loadedJoin{}, errors.ErrorWithStack(err)
+	}
+	defer dataFeed.Close()
+	headers, err := dataFeed.Headers()
+	if err != nil {
+		return loadedJoin{}, errors.ErrorWithStack(err)
+	}
+	loaded := loadedJoin{
+		FeedJoin: join,
+		headers:  headers,
+		rows:     make(map[string][]map[string]string),
+	}
+	for dataFeed.HasNext() {
+		dataRow := dataFeed.Next()
+		key, err := dataRow.Get(join.foreignKey())
+		if err != nil {
+			return loadedJoin{}, errors.ErrorMessageWithStack("Failed in reading the key of the row " + strconv.Itoa(dataRow.RowNumber()) + " of the joined feed :" + join.Feed + " : " + err.Error())
+		}
+		if key == "" {
+			// a row without a key is not joined to any row of the main feed
+			continue
+		}
+		if len(loaded.rows[key]) > 0 && !join.Many {
+			return loadedJoin{}, errors.ErrorMessageWithStack("key :" + key + " is repeated on the row " + strconv.Itoa(dataRow.RowNumber()) + " of the joined feed :" + join.Feed + " , set many to join all the rows of a key")
+		}
+		row := make(map[string]string, len(headers))
+		for _, header := range headers {
+			value, err := dataRow.Get(header)
+			if err != nil {
+				return loadedJoin{}, errors.ErrorWithStack(err)
+			}
+			row[header] = value
+		}
+		loaded.rows[key] = append(loaded.rows[key], row)
+	}
+	if err := dataFeed.Err(); err != nil {
+		return loadedJoin{}, errors.ErrorWithStack(err)
+	}
+	return loaded, nil
+}
+
+// readNext joins the next row of the main feed , the rows without a row in an inner join are skipped
+func (dataFeed *joinedDataFeed) readNext() {
+	dataFeed.next = nil
+	for dataFeed.DataFeed.HasNext() {
+		dataRow := dataFeed.DataFeed.Next()
+		joinedColumns := make(map[string]string)
+		matched := true
+		for _, join := range dataFeed.joins {
+			// a row without a key has no joined row , a missing key column is reported when the row is transformed
+			var rows []map[string]string
+			if key, err := dataRow.Get(join.Key); err == nil && key != "" {
+				rows = join.rows[key]
+			}
+			if len(rows) == 0 && join.JoinType == INNER_JOIN {
+				matched = false
+				break
+			}
+			join.addColumns(joinedColumns, rows)
+		}
+		if matched {
+			dataFeed.next = &joinedDataRow{DataRow: dataRow, joinedColumns: joinedColumns}
+			return
+		}
+	}
+}
+
+func (join loadedJoin) addColumns(joinedColumns map[string]string, rows []map[string]string) {
+	for _, header := range join.headers {
+		values := make([]string, 0, len(rows))
+		for _, row := range rows {
+			values = append(values, row[header])
+		}
+		joinedColumns[join.Name+"."+header] = strings.Join(values, env.MultipleItemsSeperator())
+	}
+	if join.Many {
+		if rows == nil {
+			rows = make([]map[string]string, 0)
+		}
+		rowsAsJSON, _ := json.Marshal(rows)
+		joinedColumns[join.Name] = string(rowsAsJSON)
+	}
+}
+
+func (dataFeed *joinedDataFeed) HasNext() bool {
+	return dataFeed.next != nil
+}
+
+func (dataFeed *joinedDataFeed) Next() DataRow {
+	dataRow := dataFeed.next
+	dataFeed.readNext()
+	return dataRow
+}
+
+// Headers of a joined feed are the headers of the main feed followed by the prefixed headers of the joined feeds
+func (dataFeed *joinedDataFeed) Headers() ([]string, error) {
+	headers, err := dataFeed.DataFeed.Headers()
+	if err != nil {
+		return nil, err
+	}
+	joinedHeaders := append([]string{}, headers...)
+	for _, join := range dataFeed.joins {
+		joinedHeaders = append(joinedHeaders, join.prefixedHeaders()...)
+	}
+	return joinedHeaders, nil
+}
+
+func (join loadedJoin) prefixedHeaders() []string {
+	headers := make([]string, 0, len(join.headers)+1)
+	for _, header := range join.headers {
+		headers = append(headers, join.Name+"."+header)
+	}
+	if join.Many {
+		headers = append(headers, join.Name)
+	}
+	return headers
+}
+
+func (dataRow *joinedDataRow) Get(columnName string) (string, error) {
+	if value, ok := dataRow.joinedColumns[columnName]; ok {
+		return strings.TrimSpace(value), nil
+	}
+	return dataRow.DataRow.Get(columnName)
+}
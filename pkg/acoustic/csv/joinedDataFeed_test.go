@@ -0,0 +1,43 @@
+package csv
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestJoinedFeedDoesNotJoinEmptyKeys(t *testing.T) {
+	setTestEnv(t, "http://localhost:1")
+	pricesPath := writeTestFile(t, "prices.csv", "sku,amount\nA,10\n,99\nC,30\n")
+	feedPath := writeTestFile(t, "products.csv", "sku,name\nA,Apple\n,No key\nB,Banana\n")
+	tests := []struct {
+		name     string
+		joinType JoinType
+		names    []string
+		amounts  []string
+	}{
+		{name: "left join", joinType: LEFT_JOIN, names: []string{"Apple", "No key", "Banana"}, amounts: []string{"10", "", ""}},
+		{name: "inner join", joinType: INNER_JOIN, names: []string{"Apple"}, amounts: []string{"10"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			configTypeMapping := &ContentTypeMapping{Joins: []FeedJoin{{Name: "prices", Feed: pricesPath, Key: "sku", JoinType: test.joinType}}}
+			dataFeed, err := LoadJoinedFeed(feedPath, configTypeMapping)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer dataFeed.Close()
+			names := make([]string, 0)
+			amounts := make([]string, 0)
+			for dataFeed.HasNext() {
+				dataRow := dataFeed.Next()
+				name, _ := dataRow.Get("name")
+				amount, _ := dataRow.Get("prices.amount")
+				names = append(names, name)
+				amounts = append(amounts, amount)
+			}
+			if !reflect.DeepEqual(names, test.names) || !reflect.DeepEqual(amounts, test.amounts) {
+				t.Errorf("expected the rows %v with the amounts %v , got %v %v", test.names, test.amounts, names, amounts)
+			}
+		})
+	}
+}
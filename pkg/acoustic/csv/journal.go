@@ -0,0 +1,264 @@
+package csv
+
+import (
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/dekanayake/acoustic-content-sync/pkg/acoustic/author/api"
+	"github.com/dekanayake/acoustic-content-sync/pkg/env"
+	"github.com/dekanayake/acoustic-content-sync/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type JournalStatus string
+
+const (
+	JOURNAL_COMPLETED JournalStatus = "completed"
+	JOURNAL_FAILED    JournalStatus = "failed"
+)
+
+const SKIPPED_ALREADY_COMPLETED = "completed in a previous run"
+
+var journalHeaders = []string{"csvRecordKeyValue", "acousticID", "status", "contentHash", "time"}
+
+type JournalEntry struct {
+	CSVIDValue  string
+	AcousticID  string
+	Status      JournalStatus
+	ContentHash string
+}
+
+// Journal records the outcome of each record of a run, so a run which stopped halfway can be resumed
+// by skipping the records completed in the previous run for the same feed and config
+type Journal interface {
+	IsCompleted(record api.AcousticDataRecord) (bool, JournalEntry)
+	Completed(record api.AcousticDataRecord, acousticID string) error
+	Failed(record api.AcousticDataRecord) error
+	Close() error
+}
+
+type journal struct {
+	location  string
+	file      *os.File
+	writer    *csv.Writer
+	completed map[string]JournalEntry
+	mux       *sync.Mutex
+}
+
+type noOpJournal struct {
+}
+
+// OpenJournal opens the journal of the given operation. The journal file is identified by the operation and the
+// feed , config and the other run arguments , so the same feed/config pair always resolves to the same journal.
+// When resume is not enabled the previous journal is discarded and a new one is started
+func OpenJournal(operation string, dataFeedPath string, configPath string, runArgs ...string) (Journal, error) {
+	if env.IsDryRunEnabled() {
+		return noOpJournal{}, nil
+	}
+	location, err := journalLocation(operation, dataFeedPath, configPath, runArgs...)
+	if err != nil {
+		return nil, errors.ErrorWithStack(err)
+	}
+	completed := make(map[string]JournalEntry)
+	if env.IsResumeEnabled() {
+		completed, err = readCompletedJournalEntries(location)
+		if err != nil {
+			return nil, errors.ErrorWithStack(err)
+		}
+		log.Info("Resuming the run , " + strconv.Itoa(len(completed)) + " records completed in the previous run will be skipped. Journal :" + location)
+	} else {
+		log.Info("Journal of the run :" + location)
+	}
+	if err := os.MkdirAll(filepath.Dir(location), 0755); err != nil {
+		return nil, errors.ErrorWithStack(err)
+	}
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if env.IsResumeEnabled() {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+	file, err := os.OpenFile(location, flags, 0644)
+	if err != nil {
+		return nil, errors.ErrorWithStack(err)
+	}
+	writer := csv.NewWriter(file)
+	fileInfo, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, errors.ErrorWithStack(err)
+	}
+	if fileInfo.Size() == 0 {
+		if err := writer.Write(journalHeaders); err != nil {
+			file.Close()
+			return nil, errors.ErrorWithStack(err)
+		}
+		writer.Flush()
+	}
+	return &journal{
+		location:  location,
+		file:      file,
+		writer:    writer,
+		completed: completed,
+		mux:       &sync.Mutex{},
+	}, nil
+}
+
+func journalLocation(operation string, dataFeedPath string, configPath string, runArgs ...string) (string, error) {
+	runID, err := runFileID(operation, []string{dataFeedPath, configPath}, runArgs...)
+	if err != nil {
+		return "", errors.ErrorWithStack(err)
+	}
+	return filepath.Join(env.JournalLocation(), runID+".csv"), nil
+}
+
+// runFileID identifies the files kept across the runs by the operation , the absolute paths of the files of the run and
+// the other run arguments
+func runFileID(operation string, paths []string, runArgs ...string) (string, error) {
+	hash := sha256.New()
+	hash.Write([]byte(operation))
+	for _, path := range paths {
+		if path != "" {
+			absolutePath, err := filepath.Abs(path)
+			if err != nil {
+				return "", errors.ErrorWithStack(err)
+			}
+			path = absolutePath
+		}
+		hash.Write([]byte("\x00" + path))
+	}
+	for _, runArg := range runArgs {
+		hash.Write([]byte("\x00" + runArg))
+	}
+	return operation + "_" + hex.EncodeToString(hash.Sum(nil))[:16], nil
+}
+
+func readCompletedJournalEntries(location string) (map[string]JournalEntry, error) {
+	entries := make(map[string]JournalEntry)
+	file, err := os.Open(location)
+	if os.IsNotExist(err) {
+		return entries, nil
+	} else if err != nil {
+		return nil, errors.ErrorWithStack(err)
+	}
+	defer file.Close()
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	for first := true; ; first = false {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			// a run killed while writing can leave a partial last line , the records after it are reprocessed
+			log.WithError(err).Warn("Stopped reading the journal at a malformed line :" + location)
+			break
+		}
+		if first || len(row) < len(journalHeaders)-1 {
+			continue
+		}
+		entry := JournalEntry{
+			CSVIDValue:  row[0],
+			AcousticID:  row[1],
+			Status:      JournalStatus(row[2]),
+			ContentHash: row[3],
+		}
+		// the last entry of a record wins , a record failed after it was completed is processed again
+		entries[entry.CSVIDValue] = entry
+	}
+	completed := make(map[string]JournalEntry)
+	for csvIDValue, entry := range entries {
+		if entry.Status == JOURNAL_COMPLETED {
+			completed[csvIDValue] = entry
+		}
+	}
+	return completed, nil
+}
+
+// contentHash is the hash of the mapped values of the record , a record changed in the feed after it was completed
+// gets a different hash and is processed again on resume
+func contentHash(record api.AcousticDataRecord) string {
+	values, err := json.Marshal(record.Values)
+	if err != nil {
+		values = []byte(fmt.Sprintf("%v", record.Values))
+	}
+	hash := sha256.Sum256(values)
+	return hex.EncodeToString(hash[:])
+}
+
+func (j *journal) IsCompleted(record api.AcousticDataRecord) (bool, JournalEntry) {
+	j.mux.Lock()
+	defer j.mux.Unlock()
+	entry, ok := j.completed[record.CSVRecordKeyValue()]
+	if !ok || entry.ContentHash != contentHash(record) {
+		return false, JournalEntry{}
+	}
+	return true, entry
+}
+
+func (j *journal) Completed(record api.AcousticDataRecord, acousticID string) error {
+	return j.write(record, acousticID, JOURNAL_COMPLETED)
+}
+
+func (j *journal) Failed(record api.AcousticDataRecord) error {
+	return j.write(record, "", JOURNAL_FAILED)
+}
+
+func (j *journal) write(record api.AcousticDataRecord, acousticID string, status JournalStatus) error {
+	j.mux.Lock()
+	defer j.mux.Unlock()
+	if err := j.writer.Write([]string{record.CSVRecordKeyValue(), acousticID, string(status), contentHash(record), time.Now().Format(time.RFC3339)}); err != nil {
+		return errors.ErrorWithStack(err)
+	}
+	// flushed on every record so the journal survives the run being killed
+	j.writer.Flush()
+	if err := j.writer.Error(); err != nil {
+		return errors.ErrorWithStack(err)
+	}
+	return nil
+}
+
+// journalResult records the outcome of the record in the journal. A failure in writing the journal does not
+// change the outcome of the record , it only means the record is processed again on resume
+func journalResult(journal Journal, record api.AcousticDataRecord, acousticID string, err error) {
+	var journalErr error
+	if err != nil {
+		journalErr = journal.Failed(record)
+	} else {
+		journalErr = journal.Completed(record, acousticID)
+	}
+	if journalErr != nil {
+		log.WithField(record.CSVRecordKey, record.CSVRecordKeyValue()).WithError(journalErr).Error("Failed in writing the journal")
+	}
+}
+
+func (j *journal) Close() error {
+	j.mux.Lock()
+	defer j.mux.Unlock()
+	j.writer.Flush()
+	if err := j.file.Close(); err != nil {
+		return errors.ErrorWithStack(err)
+	}
+	return nil
+}
+
+func (n noOpJournal) IsCompleted(record api.AcousticDataRecord) (bool, JournalEntry) {
+	return false, JournalEntry{}
+}
+
+func (n noOpJournal) Completed(record api.AcousticDataRecord, acousticID string) error {
+	return nil
+}
+
+func (n noOpJournal) Failed(record api.AcousticDataRecord) error {
+	return nil
+}
+
+func (n noOpJournal) Close() error {
+	return nil
+}
@@ -0,0 +1,214 @@
+package csv
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"github.com/dekanayake/acoustic-content-sync/pkg/env"
+	"github.com/dekanayake/acoustic-content-sync/pkg/errors"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// jsonDataFeed reads a JSON array or a JSON Lines feed one record at a time.
+// Nested objects are flattened in to dotted column names ( {"price":{"amount":1}} is read as the column price.amount ),
+// arrays of values are joined by the MultipleItemsSeperator and arrays of objects are kept as a JSON string
+type jsonDataFeed struct {
+	file       *os.File
+	readRecord func() (interface{}, error)
+	headers    []string
+	next       DataRow
+	err        error
+	recordNo   int
+}
+
+// jsonDataRow treats a missing attribute as an empty value , the records of a JSON feed do not need to have
+// all the attributes unlike the rows of a CSV feed
+type jsonDataRow struct {
+	dataRow
+}
+
+func LoadJSON(jsonFilePath string) (DataFeed, error) {
+	jsonFile, err := os.Open(jsonFilePath)
+	if err != nil {
+		return nil, errors.ErrorWithStack(err)
+	}
+	decoder := json.NewDecoder(bufio.NewReader(jsonFile))
+	decoder.UseNumber()
+	token, err := decoder.Token()
+	if err == io.EOF {
+		jsonFile.Close()
+		return nil, errors.ErrorMessageWithStack("JSON file is empty. ")
+	}
+	if delim, ok := token.(json.Delim); err != nil || !ok || delim != '[' {
+		jsonFile.Close()
+		return nil, errors.ErrorMessageWithStack("JSON feed should be an array of records :" + jsonFilePath)
+	}
+	return newJSONDataFeed(jsonFile, func() (interface{}, error) {
+		if !decoder.More() {
+			return nil, io.EOF
+		}
+		var record interface{}
+		if err := decoder.Decode(&record); err != nil {
+			return nil, errors.ErrorWithStack(err)
+		}
+		return record, nil
+	}), nil
+}
+
+func LoadJSONLines(jsonLinesFilePath string) (DataFeed, error) {
+	jsonLinesFile, err := os.Open(jsonLinesFilePath)
+	if err != nil {
+		return nil, errors.ErrorWithStack(err)
+	}
+	reader := bufio.NewReader(jsonLinesFile)
+	return newJSONDataFeed(jsonLinesFile, func() (interface{}, error) {
+		for {
+			line, err := reader.ReadBytes('\n')
+			if err != nil && err != io.EOF {
+				return nil, errors.ErrorWithStack(err)
+			}
+			line = bytes.TrimSpace(line)
+			if len(line) == 0 {
+				if err == io.EOF {
+					return nil, io.EOF
+				}
+				continue
+			}
+			decoder := json.NewDecoder(bytes.NewReader(line))
+			decoder.UseNumber()
+			var record interface{}
+			if err := decoder.Decode(&record); err != nil {
+				return nil, errors.ErrorWithStack(err)
+			}
+			return record, nil
+		}
+	}), nil
+}
+
+func newJSONDataFeed(file *os.File, readRecord func() (interface{}, error)) *jsonDataFeed {
+	dataFeed := &jsonDataFeed{
+		file:       file,
+		readRecord: readRecord,
+	}
+	dataFeed.readNext()
+	if dataFeed.next != nil {
+		columns := dataFeed.next.(*jsonDataRow).columns
+		dataFeed.headers = make([]string, 0, len(columns))
+		for column := range columns {
+			dataFeed.headers = append(dataFeed.headers, column)
+		}
+		sort.Strings(dataFeed.headers)
+	}
+	return dataFeed
+}
+
+func (dataFeed *jsonDataFeed) readNext() {
+	dataFeed.next = nil
+	if dataFeed.err != nil {
+		return
+	}
+	record, err := dataFeed.readRecord()
+	if err == io.EOF {
+		return
+	}
+	dataFeed.recordNo += 1
+	if err != nil {
+		dataFeed.err = errors.ErrorMessageWithStack("Failed in reading the record " + strconv.Itoa(dataFeed.recordNo) + " of the JSON feed :" + err.Error())
+		return
+	}
+	object, ok := record.(map[string]interface{})
+	if !ok {
+		dataFeed.err = errors.ErrorMessageWithStack("Record " + strconv.Itoa(dataFeed.recordNo) + " of the JSON feed is not an object")
+		return
+	}
+	columns := make(map[string]string)
+	if err := flattenJSON("", object, columns); err != nil {
+		dataFeed.err = errors.ErrorWithStack(err)
+		return
+	}
+	dataFeed.next = &jsonDataRow{dataRow{columns: columns, rowNumber: dataFeed.recordNo}}
+}
+
+func flattenJSON(prefix string, value interface{}, columns map[string]string) error {
+	switch typedValue := value.(type) {
+	case map[string]interface{}:
+		for key, childValue := range typedValue {
+			if prefix != "" {
+				key = prefix + "." + key
+			}
+			if err := flattenJSON(key, childValue, columns); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		values := make([]string, 0, len(typedValue))
+		for _, item := range typedValue {
+			itemValue, ok := jsonScalarValue(item)
+			if !ok {
+				jsonValue, err := json.Marshal(typedValue)
+				if err != nil {
+					return errors.ErrorWithStack(err)
+				}
+				columns[prefix] = string(jsonValue)
+				return nil
+			}
+			values = append(values, itemValue)
+		}
+		columns[prefix] = strings.Join(values, env.MultipleItemsSeperator())
+	default:
+		scalarValue, _ := jsonScalarValue(typedValue)
+		columns[prefix] = scalarValue
+	}
+	return nil
+}
+
+func jsonScalarValue(value interface{}) (string, bool) {
+	switch typedValue := value.(type) {
+	case nil:
+		return "", true
+	case string:
+		return typedValue, true
+	case json.Number:
+		return typedValue.String(), true
+	case bool:
+		return strconv.FormatBool(typedValue), true
+	default:
+		return "", false
+	}
+}
+
+func (dataFeed *jsonDataFeed) HasNext() bool {
+	return dataFeed.next != nil
+}
+
+func (dataFeed *jsonDataFeed) Next() DataRow {
+	dataRow := dataFeed.next
+	dataFeed.readNext()
+	return dataRow
+}
+
+func (dataFeed *jsonDataFeed) Headers() ([]string, error) {
+	if dataFeed.headers == nil {
+		return nil, errors.ErrorMessageWithStack("No contents in datafeed")
+	}
+	return dataFeed.headers, nil
+}
+
+func (dataFeed *jsonDataFeed) Err() error {
+	return dataFeed.err
+}
+
+func (dataFeed *jsonDataFeed) Close() error {
+	if err := dataFeed.file.Close(); err != nil {
+		return errors.ErrorWithStack(err)
+	}
+	return nil
+}
+
+func (dataRow *jsonDataRow) Get(columnName string) (string, error) {
+	return strings.TrimSpace(dataRow.columns[columnName]), nil
+}
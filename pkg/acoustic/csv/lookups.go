@@ -0,0 +1,188 @@
+package csv
+
+import (
+	"encoding/csv"
+	"github.com/dekanayake/acoustic-content-sync/pkg/acoustic/author/api"
+	"github.com/dekanayake/acoustic-content-sync/pkg/env"
+	"github.com/dekanayake/acoustic-content-sync/pkg/errors"
+	"github.com/dimchansky/utfbom"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// LookupMapping is a key to value dictionary loaded from a CSV file , a field mapping translates its value through a
+// lookup by the name of the lookup
+type LookupMapping struct {
+	Name string `yaml:"name"`
+	File string `yaml:"file"`
+	// the columns of the keys and the values , the first and the second column when not set
+	KeyColumn   string `yaml:"keyColumn"`
+	ValueColumn string `yaml:"valueColumn"`
+	// the keys are matched ignoring the case
+	IgnoreCase bool `yaml:"ignoreCase"`
+}
+
+type LookupMissingPolicy string
+
+const (
+	LOOKUP_MISSING_FAIL         LookupMissingPolicy = "fail"
+	LOOKUP_MISSING_SKIP         LookupMissingPolicy = "skip"
+	LOOKUP_MISSING_PASS_THROUGH LookupMissingPolicy = "passThrough"
+	LOOKUP_MISSING_DEFAULT      LookupMissingPolicy = "default"
+)
+
+var lookupMissingPolicies = []LookupMissingPolicy{LOOKUP_MISSING_FAIL, LOOKUP_MISSING_SKIP, LOOKUP_MISSING_PASS_THROUGH, LOOKUP_MISSING_DEFAULT}
+
+type lookupTable struct {
+	values     map[string]string
+	ignoreCase bool
+}
+
+type lookupTables map[string]lookupTable
+
+func (table lookupTable) get(key string) (string, bool) {
+	if table.ignoreCase {
+		key = strings.ToLower(key)
+	}
+	value, ok := table.values[key]
+	return value, ok
+}
+
+// loadLookup reads the key and value columns of the lookup file , a key repeated in the file is an error as the
+// translation would depend on the order of the rows
+func loadLookup(lookup LookupMapping) (lookupTable, error) {
+	lookupFile, err := os.Open(lookup.File)
+	if err != nil {
+		return lookupTable{}, errors.ErrorWithStack(err)
+	}
+	defer lookupFile.Close()
+	reader, _ := utfbom.Skip(lookupFile)
+	records := csv.NewReader(reader)
+	headers, err := records.Read()
+	if err == io.EOF {
+		return lookupTable{}, errors.ErrorMessageWithStack("lookup file :" + lookup.File + " is empty")
+	}
+	if err != nil {
+		return lookupTable{}, errors.ErrorWithStack(err)
+	}
+	keyIndex, err := lookupColumnIndex(headers, lookup.KeyColumn, 0, lookup.File)
+	if err != nil {
+		return lookupTable{}, err
+	}
+	valueIndex, err := lookupColumnIndex(headers, lookup.ValueColumn, 1, lookup.File)
+	if err != nil {
+		return lookupTable{}, err
+	}
+	table := lookupTable{
+		values:     make(map[string]string),
+		ignoreCase: lookup.IgnoreCase,
+	}
+	for {
+		record, err := records.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return lookupTable{}, errors.ErrorWithStack(err)
+		}
+		if keyIndex >= len(record) || valueIndex >= len(record) {
+			continue
+		}
+		key := strings.TrimSpace(record[keyIndex])
+		if lookup.IgnoreCase {
+			key = strings.ToLower(key)
+		}
+		if _, ok := table.values[key]; ok {
+			line, _ := records.FieldPos(keyIndex)
+			return lookupTable{}, errors.ErrorMessageWithStack("key :" + key + " is repeated in the lookup file :" + lookup.File + " on line " + strconv.Itoa(line))
+		}
+		table.values[key] = strings.TrimSpace(record[valueIndex])
+	}
+	return table, nil
+}
+
+func lookupColumnIndex(headers []string, column string, defaultIndex int, file string) (int, error) {
+	if column == "" {
+		if defaultIndex >= len(headers) {
+			return 0, errors.ErrorMessageWithStack("lookup file :" + file + " should have a key and a value column")
+		}
+		return defaultIndex, nil
+	}
+	for index, header := range headers {
+		if header == column {
+			return index, nil
+		}
+	}
+	return 0, errors.ErrorMessageWithStack("column :" + column + " not found in the lookup file :" + file)
+}
+
+func loadLookups(lookups []LookupMapping) (lookupTables, error) {
+	tables := make(lookupTables, len(lookups))
+	for _, lookup := range lookups {
+		table, err := loadLookup(lookup)
+		if err != nil {
+			return nil, errors.ErrorWithStack(err)
+		}
+		tables[lookup.Name] = table
+	}
+	return tables, nil
+}
+
+// lookupValue translates the value through the lookup of the field mapping , the items of a multi valued field are
+// translated one by one. An empty value is returned for a value to skip
+func (contentFieldMapping ContentFieldMapping) lookupValue(value string, dataRow DataRow, configTypeMapping *ContentTypeMapping) (string, error) {
+	if contentFieldMapping.Lookup == "" || value == "" {
+		return value, nil
+	}
+	table, ok := configTypeMapping.lookups[contentFieldMapping.Lookup]
+	if !ok {
+		return "", errors.ErrorMessageWithStack("No lookup found with the name :" + contentFieldMapping.Lookup)
+	}
+	propertyType := api.FieldType(contentFieldMapping.PropertyType)
+	if !isMultiFieldType(propertyType) && propertyType != api.Category && propertyType != api.CategoryPart {
+		return contentFieldMapping.lookupItem(table, value, dataRow)
+	}
+	translatedItems := make([]string, 0)
+	for _, item := range strings.Split(value, env.MultipleItemsSeperator()) {
+		translatedItem, err := contentFieldMapping.lookupItem(table, strings.TrimSpace(item), dataRow)
+		if err != nil {
+			return "", err
+		}
+		if translatedItem != "" {
+			translatedItems = append(translatedItems, translatedItem)
+		}
+	}
+	return strings.Join(translatedItems, env.MultipleItemsSeperator()), nil
+}
+
+func (contentFieldMapping ContentFieldMapping) lookupItem(table lookupTable, key string, dataRow DataRow) (string, error) {
+	if value, ok := table.get(key); ok {
+		return value, nil
+	}
+	switch contentFieldMapping.LookupMissing {
+	case LOOKUP_MISSING_SKIP:
+		return "", nil
+	case LOOKUP_MISSING_PASS_THROUGH:
+		return key, nil
+	case LOOKUP_MISSING_DEFAULT:
+		return contentFieldMapping.LookupDefault, nil
+	default:
+		return "", errors.ErrorMessageWithStack("key :" + key + " of the field " + strconv.Quote(contentFieldMapping.CsvProperty) +
+			" not found in the lookup :" + contentFieldMapping.Lookup + " on row " + strconv.Itoa(dataRow.RowNumber()))
+	}
+}
+
+// getMappedValue is the value of the feed after the transforms , translated through the lookup of the field mapping. The
+// value of a multi value field of a grouped record is read from all the rows of the record
+func (contentFieldMapping ContentFieldMapping) getMappedValue(dataRow DataRow, configTypeMapping *ContentTypeMapping) (string, error) {
+	if value, grouped, err := contentFieldMapping.groupedValue(dataRow, configTypeMapping); grouped {
+		return value, err
+	}
+	value, err := contentFieldMapping.getCsvValueOrStaticValue(dataRow)
+	if err != nil {
+		return "", err
+	}
+	return contentFieldMapping.lookupValue(value, dataRow, configTypeMapping)
+}
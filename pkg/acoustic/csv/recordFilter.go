@@ -0,0 +1,140 @@
+package csv
+
+import (
+	"github.com/dekanayake/acoustic-content-sync/pkg/env"
+	"github.com/dekanayake/acoustic-content-sync/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"math/rand"
+	"strconv"
+	"time"
+)
+
+// recordFilter selects the rows of the feed processed by a run , the rows are selected before they are transformed so
+// a row left out is never sent to acoustic. The first row and the record keys of the run , the include and exclude
+// conditions of the content type mapping and of the run and the sample are applied in order , and the limit stops the
+// run after the number of selected rows
+type recordFilter struct {
+	configTypeMapping *ContentTypeMapping
+	include           []*Condition
+	exclude           []*Condition
+	fromRow           int
+	limit             int
+	sampleRate        float64
+	random            *rand.Rand
+	keys              map[string]bool
+	selected          int
+}
+
+// feedSelection is the outcome of the record filters over the feed
+type feedSelection struct {
+	filtered int
+	// the feed is not read to the end as the limit of the run is reached
+	limitReached bool
+}
+
+func newRecordFilter(configTypeMapping *ContentTypeMapping) (*recordFilter, error) {
+	filter := &recordFilter{
+		configTypeMapping: configTypeMapping,
+		fromRow:           env.FromRow(),
+		limit:             env.RecordLimit(),
+		sampleRate:        env.SampleRate(),
+	}
+	var err error
+	if filter.include, err = compileConditions(configTypeMapping.Include, env.RecordInclude()); err != nil {
+		return nil, errors.ErrorWithStack(err)
+	}
+	if filter.exclude, err = compileConditions(configTypeMapping.Exclude, env.RecordExclude()); err != nil {
+		return nil, errors.ErrorWithStack(err)
+	}
+	if filter.sampleRate < 0 || filter.sampleRate > 1 {
+		return nil, errors.ErrorMessageWithStack("sample rate " + strconv.FormatFloat(filter.sampleRate, 'f', -1, 64) + " should be between 0 and 1")
+	}
+	if filter.sampleRate > 0 {
+		seed := env.SampleSeed()
+		if seed == 0 {
+			seed = time.Now().UnixNano()
+		}
+		log.Info("Sampling the records with the rate " + strconv.FormatFloat(filter.sampleRate, 'f', -1, 64) + " , seed :" + strconv.FormatInt(seed, 10))
+		filter.random = rand.New(rand.NewSource(seed))
+	}
+	if keys := env.RecordKeys(); len(keys) > 0 {
+		filter.keys = make(map[string]bool, len(keys))
+		for _, key := range keys {
+			filter.keys[key] = true
+		}
+	}
+	return filter, nil
+}
+
+func compileConditions(expressions []string, runExpression string) ([]*Condition, error) {
+	if runExpression != "" {
+		expressions = append(append([]string{}, expressions...), runExpression)
+	}
+	conditions := make([]*Condition, 0, len(expressions))
+	for _, expression := range expressions {
+		condition, err := compileCondition(expression)
+		if err != nil {
+			return nil, errors.ErrorWithStack(err)
+		}
+		conditions = append(conditions, condition)
+	}
+	return conditions, nil
+}
+
+// selects checks the row passes the filters , a row is included when it satisfies any of the include conditions and
+// excluded when it satisfies any of the exclude conditions
+func (filter *recordFilter) selects(dataRow DataRow) (bool, error) {
+	selected, err := filter.matches(dataRow)
+	if err != nil {
+		return false, errors.ErrorMessageWithStack("row " + strconv.Itoa(dataRow.RowNumber()) + " : " + err.Error())
+	}
+	if selected {
+		filter.selected++
+	}
+	return selected, nil
+}
+
+func (filter *recordFilter) matches(dataRow DataRow) (bool, error) {
+	if filter.fromRow > 0 && dataRow.RowNumber() < filter.fromRow {
+		return false, nil
+	}
+	if filter.keys != nil {
+		key, err := recordKeyValue(filter.configTypeMapping, dataRow)
+		if err != nil || !filter.keys[key] {
+			return false, nil
+		}
+	}
+	if len(filter.include) > 0 {
+		included, err := matchesAny(filter.include, dataRow)
+		if err != nil || !included {
+			return false, err
+		}
+	}
+	excluded, err := matchesAny(filter.exclude, dataRow)
+	if err != nil || excluded {
+		return false, err
+	}
+	if filter.random != nil && filter.random.Float64() >= filter.sampleRate {
+		return false, nil
+	}
+	return true, nil
+}
+
+func matchesAny(conditions []*Condition, dataRow DataRow) (bool, error) {
+	for _, condition := range conditions {
+		matches, err := condition.Matches(dataRow)
+		if err != nil || matches {
+			return matches, err
+		}
+	}
+	return false, nil
+}
+
+func (filter *recordFilter) limitReached() bool {
+	return filter.limit > 0 && filter.selected >= filter.limit
+}
+
+// partial is true when some records of the feed are not processed by the run
+func (selection feedSelection) partial() bool {
+	return selection.filtered > 0 || selection.limitReached
+}
@@ -0,0 +1,204 @@
+package csv
+
+import (
+	"github.com/dekanayake/acoustic-content-sync/pkg/acoustic/author/api"
+	"github.com/dekanayake/acoustic-content-sync/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// UnresolvedReference is a linked reference of a record which is not set , either the record keys are not synced in
+// the process or the update of the element failed
+type UnresolvedReference struct {
+	CSVIDKey   string
+	CSVIDValue string
+	ContentID  string
+	Element    string
+	RecordKeys []string
+	Error      error
+}
+
+// linkRegistry is the ID of the content of each record synced by the process , per content type. The records of a feed
+// are linked with the records of the same feed and of the feeds synced before it in the process
+type linkRegistry struct {
+	mux sync.RWMutex
+	ids map[string]map[string]string
+}
+
+var linkedContents = &linkRegistry{ids: make(map[string]map[string]string)}
+
+func (registry *linkRegistry) register(contentType string, recordKey string, contentID string) {
+	if recordKey == "" || contentID == "" {
+		return
+	}
+	registry.mux.Lock()
+	defer registry.mux.Unlock()
+	if registry.ids[contentType] == nil {
+		registry.ids[contentType] = make(map[string]string)
+	}
+	registry.ids[contentType][recordKey] = contentID
+}
+
+func (registry *linkRegistry) lookup(contentType string, recordKey string) (string, bool) {
+	registry.mux.RLock()
+	defer registry.mux.RUnlock()
+	contentID, ok := registry.ids[contentType][recordKey]
+	return contentID, ok
+}
+
+// splitLinks takes the linked reference elements out of the record , so the record is created without them in the first
+// pass. The links record has the record key and the linked elements , it is nil when the record has no linked element
+func splitLinks(record api.AcousticDataRecord) (api.AcousticDataRecord, *api.AcousticDataRecord) {
+	values := make([]api.GenericData, 0, len(record.Values))
+	links := make([]api.GenericData, 0)
+	for _, data := range record.Values {
+		if isLinked(data) {
+			links = append(links, data)
+		} else {
+			values = append(values, data)
+		}
+	}
+	if len(links) == 0 {
+		return record, nil
+	}
+	linksRecord := api.AcousticDataRecord{CSVRecordKey: record.CSVRecordKey}
+	for _, data := range values {
+		if data.Name == record.CSVRecordKey {
+			// the key identifies the record , the content already has it
+			data.Ignore = true
+			linksRecord.Values = append(linksRecord.Values, data)
+		}
+	}
+	linksRecord.Values = append(linksRecord.Values, links...)
+	record.Values = values
+	return record, &linksRecord
+}
+
+func isLinked(data api.GenericData) bool {
+	if data.Ignore {
+		return false
+	}
+	switch value := data.Value.(type) {
+	case api.AcousticReference:
+		return value.RecordKey != ""
+	case api.AcousticMultiReference:
+		for _, reference := range value.References {
+			if reference.RecordKey != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// resolveLinks sets the ID of the linked references synced in the process. A reference not synced is searched when the
+// mapping has a search term , otherwise it is left out of the element and returned as unresolved
+func resolveLinks(linksRecord api.AcousticDataRecord) (api.AcousticDataRecord, []UnresolvedReference) {
+	unresolved := make([]UnresolvedReference, 0)
+	values := make([]api.GenericData, 0, len(linksRecord.Values))
+	for _, data := range linksRecord.Values {
+		switch value := data.Value.(type) {
+		case api.AcousticReference:
+			reference, resolved := resolveLink(value)
+			if !resolved {
+				unresolved = append(unresolved, UnresolvedReference{Element: data.Name, RecordKeys: []string{value.RecordKey}})
+				continue
+			}
+			data.Value = reference
+		case api.AcousticMultiReference:
+			references := make([]api.AcousticReference, 0, len(value.References))
+			unresolvedKeys := make([]string, 0)
+			for _, reference := range value.References {
+				reference, resolved := resolveLink(reference)
+				if !resolved {
+					unresolvedKeys = append(unresolvedKeys, reference.RecordKey)
+					continue
+				}
+				references = append(references, reference)
+			}
+			if len(unresolvedKeys) > 0 {
+				unresolved = append(unresolved, UnresolvedReference{Element: data.Name, RecordKeys: unresolvedKeys})
+			}
+			if len(references) == 0 {
+				continue
+			}
+			value.References = references
+			data.Value = value
+		}
+		values = append(values, data)
+	}
+	linksRecord.Values = values
+	for index := range unresolved {
+		unresolved[index].CSVIDKey = linksRecord.CSVRecordKey
+		unresolved[index].CSVIDValue = linksRecord.CSVRecordKeyValue()
+	}
+	return linksRecord, unresolved
+}
+
+func resolveLink(reference api.AcousticReference) (api.AcousticReference, bool) {
+	if reference.RecordKey == "" {
+		return reference, true
+	}
+	if contentID, ok := linkedContents.lookup(reference.Type, reference.RecordKey); ok {
+		reference.ID = contentID
+		return reference, true
+	}
+	return reference, reference.SearchTerm != ""
+}
+
+// linkedElements is the names of the elements of the links record which are set
+func linkedElements(linksRecord api.AcousticDataRecord) []string {
+	elements := make([]string, 0, len(linksRecord.Values))
+	for _, data := range linksRecord.Values {
+		if !data.Ignore {
+			elements = append(elements, data.Name)
+		}
+	}
+	return elements
+}
+
+// linkRecords is the second pass of the run , the linked elements of each record are set once all the records of the
+// run are created. The records failed in the first pass have no content and are not linked
+func (contentUseCase *contentUseCase) linkRecords(configTypeMapping *ContentTypeMapping, linksRecords []api.AcousticDataRecord, contentIDs map[string]string) []UnresolvedReference {
+	unresolved := make([]UnresolvedReference, 0)
+	if len(linksRecords) == 0 {
+		return unresolved
+	}
+	log.Info("Linking the references of " + strconv.Itoa(len(linksRecords)) + " records")
+	var unresolvedMux sync.Mutex
+	records, _ := streamRecords(func(handle func(record api.AcousticDataRecord) error) error {
+		for _, linksRecord := range linksRecords {
+			if _, ok := contentIDs[linksRecord.CSVRecordKeyValue()]; ok {
+				handle(linksRecord)
+			}
+		}
+		return nil
+	})
+	processRecords(workerCount(configTypeMapping), records, func(linksRecord api.AcousticDataRecord) {
+		contentID := contentIDs[linksRecord.CSVRecordKeyValue()]
+		resolved, unresolvedLinks := resolveLinks(linksRecord)
+		if elements := linkedElements(resolved); len(elements) > 0 {
+			response, err := contentUseCase.contentService.UpdateContentElementsWithRetry(contentID, resolved)
+			if err != nil {
+				log.WithField(linksRecord.CSVRecordKey, linksRecord.CSVRecordKeyValue()).Error("Failed in linking the references " + strings.Join(elements, ", "))
+				unresolvedLinks = append(unresolvedLinks, UnresolvedReference{
+					CSVIDKey:   linksRecord.CSVRecordKey,
+					CSVIDValue: linksRecord.CSVRecordKeyValue(),
+					Element:    strings.Join(elements, ", "),
+					Error:      errors.ErrorWithStack(err),
+				})
+			} else {
+				log.WithField(linksRecord.CSVRecordKey, linksRecord.CSVRecordKeyValue()).WithField("action", response.Action).Info("Linked the references ")
+			}
+		}
+		for index := range unresolvedLinks {
+			unresolvedLinks[index].ContentID = contentID
+		}
+		unresolvedMux.Lock()
+		defer unresolvedMux.Unlock()
+		unresolved = append(unresolved, unresolvedLinks...)
+	})
+	return unresolved
+}
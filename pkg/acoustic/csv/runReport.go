@@ -0,0 +1,412 @@
+package csv
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"github.com/dekanayake/acoustic-content-sync/pkg/acoustic/author/api"
+	"github.com/dekanayake/acoustic-content-sync/pkg/errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type RecordAction string
+
+const (
+	RECORD_CREATED RecordAction = "created"
+	RECORD_UPDATED RecordAction = "updated"
+	RECORD_SKIPPED RecordAction = "skipped"
+	RECORD_DELETED RecordAction = "deleted"
+	RECORD_FAILED  RecordAction = "failed"
+)
+
+// RunReport is the machine readable outcome of a command , with a record per feed record ( or config problem ) and the totals.
+// It is written as JSON and optionally as JUnit XML , so a pipeline can gate on the failures
+type RunReport struct {
+	Command    string            `json:"command"`
+	StartedAt  time.Time         `json:"startedAt"`
+	FinishedAt time.Time         `json:"finishedAt"`
+	DurationMs int64             `json:"durationMs"`
+	Succeeded  bool              `json:"succeeded"`
+	Error      string            `json:"error,omitempty"`
+	ErrorClass string            `json:"errorClass,omitempty"`
+	Totals     RunReportTotals   `json:"totals"`
+	Records    []RunReportRecord `json:"records"`
+	// the linked references not set by the run
+	UnresolvedReferences []RunReportUnresolvedReference `json:"unresolvedReferences,omitempty"`
+	// the records with more than a content found for the search keys
+	AmbiguousKeys []RunReportAmbiguousKey `json:"ambiguousKeys,omitempty"`
+	// the steps of a job , the records of the steps are in the records of the job
+	Steps []RunReportStep `json:"steps,omitempty"`
+}
+
+type RunReportTotals struct {
+	Total   int `json:"total"`
+	Created int `json:"created"`
+	Updated int `json:"updated"`
+	Skipped int `json:"skipped"`
+	Deleted int `json:"deleted"`
+	Failed  int `json:"failed"`
+	// the records left out by the record filters , they are not in the total
+	Filtered int `json:"filtered"`
+}
+
+type RunReportUnresolvedReference struct {
+	Step       string   `json:"step,omitempty"`
+	KeyName    string   `json:"keyName,omitempty"`
+	Key        string   `json:"key"`
+	AcousticID string   `json:"acousticId,omitempty"`
+	Element    string   `json:"element"`
+	RecordKeys []string `json:"recordKeys,omitempty"`
+	Error      string   `json:"error,omitempty"`
+	ErrorClass string   `json:"errorClass,omitempty"`
+}
+
+type RunReportAmbiguousKey struct {
+	Step       string   `json:"step,omitempty"`
+	KeyName    string   `json:"keyName,omitempty"`
+	Key        string   `json:"key"`
+	Policy     string   `json:"policy"`
+	Candidates []string `json:"candidates"`
+	UpdatedIDs []string `json:"updatedIds,omitempty"`
+}
+
+type RunReportRecord struct {
+	// the step of the job the record is processed by
+	Step       string       `json:"step,omitempty"`
+	KeyName    string       `json:"keyName,omitempty"`
+	Key        string       `json:"key"`
+	Action     RecordAction `json:"action"`
+	AcousticID string       `json:"acousticId,omitempty"`
+	DurationMs int64        `json:"durationMs"`
+	Reason     string       `json:"reason,omitempty"`
+	Error      string       `json:"error,omitempty"`
+	ErrorClass string       `json:"errorClass,omitempty"`
+	// the elements changed by an update
+	ChangedElements []string `json:"changedElements,omitempty"`
+}
+
+type RunReportStep struct {
+	Name       string          `json:"name"`
+	Type       string          `json:"type"`
+	Status     JobStepStatus   `json:"status"`
+	DurationMs int64           `json:"durationMs"`
+	Totals     RunReportTotals `json:"totals"`
+	Reason     string          `json:"reason,omitempty"`
+	Error      string          `json:"error,omitempty"`
+	ErrorClass string          `json:"errorClass,omitempty"`
+}
+
+func NewRunReport(command string) *RunReport {
+	return &RunReport{
+		Command:   command,
+		StartedAt: time.Now(),
+		Records:   make([]RunReportRecord, 0),
+	}
+}
+
+func (report *RunReport) add(record RunReportRecord) {
+	report.Records = append(report.Records, record)
+	report.Totals.Total += 1
+	switch record.Action {
+	case RECORD_CREATED:
+		report.Totals.Created += 1
+	case RECORD_UPDATED:
+		report.Totals.Updated += 1
+	case RECORD_SKIPPED:
+		report.Totals.Skipped += 1
+	case RECORD_DELETED:
+		report.Totals.Deleted += 1
+	case RECORD_FAILED:
+		report.Totals.Failed += 1
+	}
+}
+
+func (report *RunReport) addFailed(keyName string, key string, err error, duration time.Duration) {
+	record := RunReportRecord{
+		KeyName:    keyName,
+		Key:        key,
+		Action:     RECORD_FAILED,
+		DurationMs: duration.Milliseconds(),
+	}
+	if err != nil {
+		record.Error = err.Error()
+		record.ErrorClass = string(errors.ErrorClass(err))
+	}
+	report.add(record)
+}
+
+func (report *RunReport) AddContentCreationStatus(status ContentCreationStatus) {
+	for _, success := range status.Success {
+		action := RECORD_CREATED
+		switch success.Action {
+		case api.CONTENT_UPDATED:
+			action = RECORD_UPDATED
+		}
+		report.add(RunReportRecord{
+			KeyName:         success.CSVIDKey,
+			Key:             success.CSVIDValue,
+			Action:          action,
+			AcousticID:      success.ContentID,
+			DurationMs:      success.Duration.Milliseconds(),
+			ChangedElements: success.ChangedElements,
+		})
+	}
+	for _, skipped := range status.Skipped {
+		report.add(RunReportRecord{
+			KeyName:    skipped.CSVIDKey,
+			Key:        skipped.CSVIDValue,
+			Action:     RECORD_SKIPPED,
+			AcousticID: skipped.ContentID,
+			DurationMs: skipped.Duration.Milliseconds(),
+			Reason:     skipped.Reason,
+		})
+	}
+	for _, failed := range status.Failed {
+		report.addFailed(failed.CSVIDKey, failed.CSVIDValue, failed.Error, failed.Duration)
+	}
+	for _, unresolved := range status.UnresolvedReferences {
+		reference := RunReportUnresolvedReference{
+			KeyName:    unresolved.CSVIDKey,
+			Key:        unresolved.CSVIDValue,
+			AcousticID: unresolved.ContentID,
+			Element:    unresolved.Element,
+			RecordKeys: unresolved.RecordKeys,
+		}
+		if unresolved.Error != nil {
+			reference.Error = unresolved.Error.Error()
+			reference.ErrorClass = string(errors.ErrorClass(unresolved.Error))
+		}
+		report.UnresolvedReferences = append(report.UnresolvedReferences, reference)
+	}
+	for _, ambiguous := range status.AmbiguousKeys {
+		report.AmbiguousKeys = append(report.AmbiguousKeys, RunReportAmbiguousKey{
+			KeyName:    ambiguous.CSVIDKey,
+			Key:        ambiguous.CSVIDValue,
+			Policy:     string(ambiguous.Policy),
+			Candidates: ambiguous.Candidates,
+			UpdatedIDs: ambiguous.UpdatedIDs,
+		})
+	}
+	report.AddContentDeletionStatus(status.Disappeared)
+	report.Totals.Filtered += status.Filtered
+}
+
+func (report *RunReport) AddContentDeletionStatus(status ContentDeletionStatus) {
+	for _, success := range status.Success {
+		report.add(RunReportRecord{
+			KeyName:    success.CSVIDKey,
+			Key:        success.CSVIDValue,
+			Action:     RECORD_DELETED,
+			AcousticID: success.ContentID,
+			DurationMs: success.Duration.Milliseconds(),
+		})
+	}
+	for _, skipped := range status.Skipped {
+		report.add(RunReportRecord{
+			KeyName:    skipped.CSVIDKey,
+			Key:        skipped.CSVIDValue,
+			Action:     RECORD_SKIPPED,
+			AcousticID: skipped.ContentID,
+			DurationMs: skipped.Duration.Milliseconds(),
+			Reason:     skipped.Reason,
+		})
+	}
+	for _, failed := range status.Failed {
+		report.addFailed(failed.CSVIDKey, failed.CSVIDValue, failed.Error, failed.Duration)
+	}
+}
+
+// AddStep adds a step of a job with the records and the unresolved references of the report of the step , a skipped
+// step has no report
+func (report *RunReport) AddStep(step RunReportStep, stepReport *RunReport) {
+	report.Steps = append(report.Steps, step)
+	if stepReport == nil {
+		return
+	}
+	for _, record := range stepReport.Records {
+		record.Step = step.Name
+		report.add(record)
+	}
+	for _, unresolved := range stepReport.UnresolvedReferences {
+		unresolved.Step = step.Name
+		report.UnresolvedReferences = append(report.UnresolvedReferences, unresolved)
+	}
+	for _, ambiguous := range stepReport.AmbiguousKeys {
+		ambiguous.Step = step.Name
+		report.AmbiguousKeys = append(report.AmbiguousKeys, ambiguous)
+	}
+	report.Totals.Filtered += stepReport.Totals.Filtered
+}
+
+// failures is the number of the failed records and the unresolved references
+func (report *RunReport) failures() int {
+	return report.Totals.Failed + len(report.UnresolvedReferences)
+}
+
+// AddConfigProblems reports each problem of a config as a failed record keyed by the config path of the problem
+func (report *RunReport) AddConfigProblems(problems []ConfigProblem) {
+	for _, problem := range problems {
+		report.add(RunReportRecord{
+			KeyName:    "line " + strconv.Itoa(problem.Line),
+			Key:        problem.Path,
+			Action:     RECORD_FAILED,
+			Error:      problem.Message,
+			ErrorClass: "config",
+		})
+	}
+}
+
+// Finish records the end of the command , the command succeeded when it did not fail , no record failed and all the
+// linked references are set
+func (report *RunReport) Finish(err error) {
+	report.FinishedAt = time.Now()
+	report.DurationMs = report.FinishedAt.Sub(report.StartedAt).Milliseconds()
+	if err != nil {
+		report.Error = err.Error()
+		report.ErrorClass = string(errors.ErrorClass(err))
+	}
+	report.Succeeded = err == nil && report.Totals.Failed == 0 && len(report.UnresolvedReferences) == 0
+	if len(report.Steps) > 0 {
+		// the failures tolerated by the steps of a job do not fail the job
+		report.Succeeded = err == nil
+	}
+}
+
+func (report *RunReport) WriteJSON(reportPath string) error {
+	content, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return errors.ErrorWithStack(err)
+	}
+	return writeReport(reportPath, content)
+}
+
+type junitTestSuites struct {
+	XMLName    xml.Name         `xml:"testsuites"`
+	Name       string           `xml:"name,attr"`
+	Tests      int              `xml:"tests,attr"`
+	Failures   int              `xml:"failures,attr"`
+	Errors     int              `xml:"errors,attr"`
+	Skipped    int              `xml:"skipped,attr"`
+	Time       string           `xml:"time,attr"`
+	TestSuites []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Error     *junitMessage `xml:"error,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit writes the report as a JUnit test suite with a test case per record , a failed record is a failure and an
+// error of the command itself is an error test case
+func (report *RunReport) WriteJUnit(reportPath string) error {
+	suite := junitTestSuite{
+		Name:      report.Command,
+		Failures:  report.Totals.Failed,
+		Skipped:   report.Totals.Skipped,
+		Time:      junitSeconds(report.DurationMs),
+		Timestamp: report.StartedAt.Format(time.RFC3339),
+		TestCases: make([]junitTestCase, 0, len(report.Records)+1),
+	}
+	for _, record := range report.Records {
+		name := record.Key
+		if record.KeyName != "" {
+			name = record.KeyName + "=" + record.Key
+		}
+		className := report.Command
+		if record.Step != "" {
+			className = report.Command + "." + record.Step
+		}
+		testCase := junitTestCase{
+			Name:      name,
+			ClassName: className,
+			Time:      junitSeconds(record.DurationMs),
+		}
+		switch record.Action {
+		case RECORD_FAILED:
+			testCase.Failure = &junitMessage{Message: record.Error, Type: record.ErrorClass, Text: record.Error}
+		case RECORD_SKIPPED:
+			testCase.Skipped = &junitMessage{Message: record.Reason}
+		default:
+			testCase.SystemOut = string(record.Action) + " " + record.AcousticID
+		}
+		suite.TestCases = append(suite.TestCases, testCase)
+	}
+	for _, unresolved := range report.UnresolvedReferences {
+		message := unresolved.Error
+		if message == "" {
+			message = "record keys not synced : " + strings.Join(unresolved.RecordKeys, ", ")
+		}
+		suite.Failures++
+		suite.TestCases = append(suite.TestCases, junitTestCase{
+			Name:      unresolved.KeyName + "=" + unresolved.Key + " " + unresolved.Element,
+			ClassName: report.Command,
+			Time:      junitSeconds(0),
+			Failure:   &junitMessage{Message: message, Type: "unresolvedReference", Text: message},
+		})
+	}
+	if report.Error != "" {
+		suite.Errors = 1
+		suite.TestCases = append(suite.TestCases, junitTestCase{
+			Name:      report.Command,
+			ClassName: report.Command,
+			Time:      junitSeconds(report.DurationMs),
+			Error:     &junitMessage{Message: report.Error, Type: report.ErrorClass, Text: report.Error},
+		})
+	}
+	suite.Tests = len(suite.TestCases)
+	content, err := xml.MarshalIndent(junitTestSuites{
+		Name:       "acoustic-content-sync",
+		Tests:      suite.Tests,
+		Failures:   suite.Failures,
+		Errors:     suite.Errors,
+		Skipped:    suite.Skipped,
+		Time:       suite.Time,
+		TestSuites: []junitTestSuite{suite},
+	}, "", "  ")
+	if err != nil {
+		return errors.ErrorWithStack(err)
+	}
+	return writeReport(reportPath, append([]byte(xml.Header), content...))
+}
+
+func junitSeconds(durationMs int64) string {
+	return strconv.FormatFloat(float64(durationMs)/1000, 'f', 3, 64)
+}
+
+func writeReport(reportPath string, content []byte) error {
+	if dir := filepath.Dir(reportPath); dir != "." {
+		if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+			return errors.ErrorWithStack(err)
+		}
+	}
+	if err := ioutil.WriteFile(reportPath, content, 0644); err != nil {
+		return errors.ErrorWithStack(err)
+	}
+	return nil
+}
@@ -0,0 +1,251 @@
+package csv
+
+import (
+	"encoding/csv"
+	"github.com/dekanayake/acoustic-content-sync/pkg/acoustic/author/api"
+	"github.com/dekanayake/acoustic-content-sync/pkg/errors"
+	"github.com/goccy/go-yaml"
+	log "github.com/sirupsen/logrus"
+	"io"
+)
+
+// ConfigScaffolder generates a starter config entry of a content type from its definition in acoustic , with a header
+// only CSV template of the columns read by the generated field mappings
+type ConfigScaffolder interface {
+	Scaffold(contentTypeID string, configOut io.Writer, csvTemplateOut io.Writer) error
+}
+
+type configScaffolder struct {
+	contentTypeClient api.ContentTypeClient
+}
+
+type scaffold struct {
+	contentTypeClient api.ContentTypeClient
+	columns           []string
+	// the group types being scaffolded , a group referring back to itself is not expanded again
+	groupTypes map[string]bool
+}
+
+func NewConfigScaffolder(acousticApiUrl string) ConfigScaffolder {
+	return &configScaffolder{
+		contentTypeClient: api.NewContentTypeClient(acousticApiUrl),
+	}
+}
+
+func (c configScaffolder) Scaffold(contentTypeID string, configOut io.Writer, csvTemplateOut io.Writer) error {
+	definition, err := c.contentTypeClient.Get(contentTypeID)
+	if err != nil {
+		return errors.ErrorWithStack(err)
+	}
+	scaffold := &scaffold{
+		contentTypeClient: c.contentTypeClient,
+		columns:           make([]string, 0),
+		groupTypes:        map[string]bool{definition.ID: true},
+	}
+	fieldMapping, err := scaffold.fieldMappings("", definition)
+	if err != nil {
+		return errors.ErrorWithStack(err)
+	}
+	recordKey := scaffoldRecordKey(definition)
+	contentTypeMapping := yaml.MapSlice{
+		{Key: "type", Value: definition.ID},
+		{Key: "csvRecordKey", Value: recordKey},
+		{Key: "name", Value: []string{recordKey}},
+		{Key: "tags", Value: []string{}},
+		{Key: "fieldMapping", Value: fieldMapping},
+	}
+	config, err := yaml.Marshal(yaml.MapSlice{
+		{Key: "contentType", Value: []yaml.MapSlice{contentTypeMapping}},
+	})
+	if err != nil {
+		return errors.ErrorWithStack(err)
+	}
+	if _, err := configOut.Write(config); err != nil {
+		return errors.ErrorWithStack(err)
+	}
+	if csvTemplateOut != nil {
+		csvTemplate := csv.NewWriter(csvTemplateOut)
+		if err := csvTemplate.Write(scaffold.columns); err != nil {
+			return errors.ErrorWithStack(err)
+		}
+		csvTemplate.Flush()
+		if err := csvTemplate.Error(); err != nil {
+			return errors.ErrorWithStack(err)
+		}
+	}
+	return nil
+}
+
+// scaffoldRecordKey picks the first required text element as the record key , the first text element otherwise
+func scaffoldRecordKey(definition api.ContentTypeDefinition) string {
+	recordKey := ""
+	for _, element := range definition.Elements {
+		if element.ElementType != string(api.AcousticFieldText) || element.AllowMultipleValues {
+			continue
+		}
+		if element.Required {
+			return element.Key
+		}
+		if recordKey == "" {
+			recordKey = element.Key
+		}
+	}
+	return recordKey
+}
+
+// scaffoldPropertyType returns the property type of the field mapping of an element , the reverse of api.FieldType.Convert
+func scaffoldPropertyType(element api.ContentTypeElement) (api.FieldType, bool) {
+	switch api.FieldType(element.ElementType) {
+	case api.AcousticFieldText:
+		if element.AllowMultipleValues {
+			return api.MultiText, true
+		}
+		return api.Text, true
+	case api.AcousticFieldFormattedText:
+		return api.FormattedText, true
+	case api.AcousticFieldNumber:
+		if element.AllowMultipleValues {
+			return api.MultiNumber, true
+		}
+		if element.FieldType == "decimal" {
+			return api.Float, true
+		}
+		return api.Number, true
+	case api.AcousticFieldBoolean:
+		return api.Boolean, true
+	case api.AcousticFieldLink:
+		return api.Link, true
+	case api.AcousticFieldDate:
+		return api.Date, true
+	case api.AcousticFieldDateTime:
+		return api.DateTime, true
+	case api.AcousticFieldCategory:
+		return api.Category, true
+	case api.AcousticFieldFile:
+		return api.File, true
+	case api.AcousticFieldVideo:
+		return api.Video, true
+	case api.AcousticFieldImage:
+		if element.AllowMultipleValues {
+			return api.MultiImage, true
+		}
+		return api.Image, true
+	case api.AcousticFieldGroup:
+		if element.AllowMultipleValues {
+			return api.MultiGroup, true
+		}
+		return api.Group, true
+	case api.AcousticFieldReference:
+		if element.AllowMultipleValues {
+			return api.MultiReference, true
+		}
+		return api.Reference, true
+	case api.AcousticOptionSelection:
+		if element.AllowMultipleValues {
+			return api.MultiOptionSelection, true
+		}
+		return api.OptionSelection, true
+	default:
+		return "", false
+	}
+}
+
+// fieldMappings generates the field mappings of the elements , the columns of a group are prefixed by the group key
+// the same way the nested attributes of a JSON feed are named
+func (scaffold *scaffold) fieldMappings(columnPrefix string, definition api.ContentTypeDefinition) ([]yaml.MapSlice, error) {
+	fieldMappings := make([]yaml.MapSlice, 0, len(definition.Elements))
+	for _, element := range definition.Elements {
+		propertyType, ok := scaffoldPropertyType(element)
+		if !ok {
+			log.Warn("No property type for the element :" + element.Key + " of type :" + element.ElementType + " , the element is not scaffolded")
+			continue
+		}
+		column := element.Key
+		if columnPrefix != "" {
+			column = columnPrefix + "." + element.Key
+		}
+		fieldMapping, err := scaffold.fieldMapping(column, propertyType, element)
+		if err != nil {
+			return nil, err
+		}
+		fieldMappings = append(fieldMappings, fieldMapping)
+	}
+	return fieldMappings, nil
+}
+
+func (scaffold *scaffold) fieldMapping(column string, propertyType api.FieldType, element api.ContentTypeElement) (yaml.MapSlice, error) {
+	fieldMapping := yaml.MapSlice{}
+	if propertyType != api.Group {
+		fieldMapping = append(fieldMapping, yaml.MapItem{Key: "csvProperty", Value: column})
+		scaffold.columns = append(scaffold.columns, column)
+	}
+	fieldMapping = append(fieldMapping,
+		yaml.MapItem{Key: "acousticProperty", Value: element.Key},
+		yaml.MapItem{Key: "propertyType", Value: string(propertyType)},
+	)
+	if element.Required {
+		fieldMapping = append(fieldMapping, yaml.MapItem{Key: "mandatory", Value: true})
+	}
+
+	switch propertyType {
+	case api.Category:
+		fieldMapping = append(fieldMapping, yaml.MapItem{Key: "categoryName", Value: element.Label})
+	case api.File, api.Video, api.Image, api.MultiImage:
+		fieldMapping = append(fieldMapping,
+			yaml.MapItem{Key: "acousticAssetBasePath", Value: "/dxdam/" + element.Key},
+			yaml.MapItem{Key: "assetLocation", Value: "assets"},
+		)
+	case api.Group, api.MultiGroup:
+		if element.TypeRef == nil || element.TypeRef.ID == "" {
+			break
+		}
+		fieldMapping = append(fieldMapping, yaml.MapItem{Key: "type", Value: element.TypeRef.ID})
+		if scaffold.groupTypes[element.TypeRef.ID] {
+			log.Warn("The group type :" + element.TypeRef.ID + " of the element :" + element.Key + " refers back to itself , the field mappings of the group are not scaffolded")
+			break
+		}
+		groupDefinition, err := scaffold.contentTypeClient.Get(element.TypeRef.ID)
+		if err != nil {
+			return nil, errors.ErrorWithStack(err)
+		}
+		scaffold.groupTypes[element.TypeRef.ID] = true
+		defer func() {
+			scaffold.groupTypes[element.TypeRef.ID] = false
+		}()
+		if propertyType == api.MultiGroup {
+			// the items of a multi group are read from a JSON array in the column of the group
+			fieldMapping = append(fieldMapping, yaml.MapItem{Key: "valueAsJSON", Value: true})
+			groupFieldMappings := make([]yaml.MapSlice, 0, len(groupDefinition.Elements))
+			for _, groupElement := range groupDefinition.Elements {
+				groupPropertyType, ok := scaffoldPropertyType(groupElement)
+				if !ok {
+					log.Warn("No property type for the element :" + groupElement.Key + " of type :" + groupElement.ElementType + " , the element is not scaffolded")
+					continue
+				}
+				groupFieldMappings = append(groupFieldMappings, yaml.MapSlice{
+					{Key: "JSONKey", Value: groupElement.Key},
+					{Key: "acousticProperty", Value: groupElement.Key},
+					{Key: "propertyType", Value: string(groupPropertyType)},
+				})
+			}
+			fieldMapping = append(fieldMapping, yaml.MapItem{Key: "fieldMapping", Value: groupFieldMappings})
+		} else {
+			groupFieldMappings, err := scaffold.fieldMappings(column, groupDefinition)
+			if err != nil {
+				return nil, err
+			}
+			fieldMapping = append(fieldMapping, yaml.MapItem{Key: "fieldMapping", Value: groupFieldMappings})
+		}
+	case api.Reference, api.MultiReference:
+		refType := ""
+		if len(element.RestrictTypes) > 0 {
+			refType = element.RestrictTypes[0].ID
+		}
+		fieldMapping = append(fieldMapping,
+			yaml.MapItem{Key: "searchTerm", Value: "name:%s"},
+			yaml.MapItem{Key: "searchKeys", Value: []string{"name"}},
+			yaml.MapItem{Key: "refContentTypeMapping", Value: yaml.MapSlice{{Key: "type", Value: refType}}},
+		)
+	}
+	return fieldMapping, nil
+}
@@ -0,0 +1,29 @@
+package csv
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// setTestEnv sets the env variables read by the feeds and the clients , the journals are kept in a temp dir
+func setTestEnv(t *testing.T, acousticApiUrl string) {
+	t.Setenv("AcousticAPIURL", acousticApiUrl)
+	t.Setenv("AcousticAuthURL", acousticApiUrl)
+	t.Setenv("AcousticAPIKey", "key")
+	t.Setenv("LibraryID", "library")
+	t.Setenv("ContentStatus", "draft")
+	t.Setenv("AlwaysCreateNewAcousticRestAPIConnection", "false")
+	t.Setenv("WriteUnParsedRecordsToCSV", "false")
+	t.Setenv("MultipleItemsSeperator", ";")
+	t.Setenv("JournalLocation", filepath.Join(t.TempDir(), "journal"))
+}
+
+// writeTestFile writes the content in to a file of the temp dir of the test
+func writeTestFile(t *testing.T, name string, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
@@ -4,9 +4,11 @@ import (
 	"github.com/dekanayake/acoustic-content-sync/pkg/acoustic/author/api"
 	"github.com/dekanayake/acoustic-content-sync/pkg/errors"
 	log "github.com/sirupsen/logrus"
-	"github.com/wesovilabs/koazee"
+	"time"
 )
 
+const SKIPPED_PAGE_EXISTS = "page already exists"
+
 type SiteUseCase interface {
 	CreatePages(siteId string, parentPageId string, contentType string, dataFeedPath string, configPath string) (ContentCreationStatus, error)
 	CreatePageForContent(siteId string, parentPageId string, contentID string, relativePath string) (string, error)
@@ -30,39 +32,83 @@ func NewSiteUseCase(acousticAuthApiUrl string) SiteUseCase {
 }
 
 func (s siteUseCase) CreatePages(siteId string, parentPageId string, contentType string, dataFeedPath string, configPath string) (ContentCreationStatus, error) {
-	pageRecords, err := TransformSite(contentType, dataFeedPath, configPath)
+	journal, err := OpenJournal("site-pages", dataFeedPath, configPath, siteId, parentPageId, contentType)
 	if err != nil {
 		return ContentCreationStatus{}, errors.ErrorWithStack(err)
 	}
+	defer journal.Close()
 	failed := make([]ContentCreationFailedStatus, 0)
 	success := make([]ContentCreationSuccessStatus, 0)
-	koazee.StreamOf(pageRecords).
-		ForEach(func(record api.AcousticDataRecord) {
-			status, response, err := s.siteService.CreatePageWithRetry(siteId, parentPageId, record)
-			if err != nil {
-				log.WithField(record.CSVRecordKey, record.CSVRecordKeyValue()).Error("Failed in creating  the content ")
-				failed = append(failed, ContentCreationFailedStatus{
+	skipped := make([]ContentCreationSkippedStatus, 0)
+	selection, err := transformSiteFeed(contentType, dataFeedPath, configPath, func(record api.AcousticDataRecord) error {
+		started := time.Now()
+		if record.SkipReason != "" {
+			log.WithField(record.CSVRecordKey, record.CSVRecordKeyValue()).Info("Skipped , " + record.SkipReason)
+			skipped = append(skipped, ContentCreationSkippedStatus{
+				CSVIDKey:   record.CSVRecordKey,
+				CSVIDValue: record.CSVRecordKeyValue(),
+				Reason:     record.SkipReason,
+			})
+			return nil
+		}
+		if completed, entry := journal.IsCompleted(record); completed {
+			log.WithField(record.CSVRecordKey, record.CSVRecordKeyValue()).Info("Skipped , the page is completed in a previous run ")
+			skipped = append(skipped, ContentCreationSkippedStatus{
+				CSVIDKey:   record.CSVRecordKey,
+				CSVIDValue: record.CSVRecordKeyValue(),
+				ContentID:  entry.AcousticID,
+				Reason:     SKIPPED_ALREADY_COMPLETED,
+				Duration:   time.Since(started),
+			})
+			return nil
+		}
+		status, response, err := s.siteService.CreatePageWithRetry(siteId, parentPageId, record)
+		if err != nil {
+			journalResult(journal, record, "", err)
+			log.WithField(record.CSVRecordKey, record.CSVRecordKeyValue()).Error("Failed in creating  the content ")
+			failed = append(failed, ContentCreationFailedStatus{
+				CSVIDKey:   record.CSVRecordKey,
+				CSVIDValue: record.CSVRecordKeyValue(),
+				Error:      errors.ErrorWithStack(err),
+				Duration:   time.Since(started),
+			})
+		} else if response != nil {
+			journalResult(journal, record, response.ID, nil)
+			if status == api.PAGE_CREATED {
+				log.WithField(record.CSVRecordKey, record.CSVRecordKeyValue()).Info("Successfully created the content ")
+			} else if status == api.PAGE_UPDATED {
+				log.WithField(record.CSVRecordKey, record.CSVRecordKeyValue()).Info("Successfully updated the content ")
+			} else if status == api.PAGE_EXIST {
+				log.WithField(record.CSVRecordKey, record.CSVRecordKeyValue()).Info("page already exist ")
+			}
+
+			if status == api.PAGE_CREATED || status == api.PAGE_UPDATED {
+				action := api.CONTENT_CREATED
+				if status == api.PAGE_UPDATED {
+					action = api.CONTENT_UPDATED
+				}
+				success = append(success, ContentCreationSuccessStatus{
 					CSVIDKey:   record.CSVRecordKey,
 					CSVIDValue: record.CSVRecordKeyValue(),
-					Error:      errors.ErrorWithStack(err),
+					ContentID:  response.ID,
+					Action:     action,
+					Duration:   time.Since(started),
+				})
+			} else if status == api.PAGE_EXIST {
+				skipped = append(skipped, ContentCreationSkippedStatus{
+					CSVIDKey:   record.CSVRecordKey,
+					CSVIDValue: record.CSVRecordKeyValue(),
+					ContentID:  response.ID,
+					Reason:     SKIPPED_PAGE_EXISTS,
+					Duration:   time.Since(started),
 				})
-			} else if response != nil {
-				if status == api.PAGE_CREATED {
-					log.WithField(record.CSVRecordKey, record.CSVRecordKeyValue()).Info("Successfully created the content ")
-				} else if status == api.PAGE_UPDATED {
-					log.WithField(record.CSVRecordKey, record.CSVRecordKeyValue()).Info("Successfully updated the content ")
-				} else if status == api.PAGE_EXIST {
-					log.WithField(record.CSVRecordKey, record.CSVRecordKeyValue()).Info("page already exist ")
-				}
-
-				if status == api.PAGE_CREATED || status == api.PAGE_UPDATED {
-					success = append(success, ContentCreationSuccessStatus{
-						CSVIDKey:   record.CSVRecordKey,
-						CSVIDValue: record.CSVRecordKeyValue(),
-						ContentID:  response.ID,
-					})
-				}
 			}
-		}).Do()
-	return ContentCreationStatus{Success: success, Failed: failed}, nil
+		}
+		return nil
+	})
+	status := ContentCreationStatus{Success: success, Failed: failed, Skipped: skipped, Filtered: selection.filtered}
+	if err != nil {
+		return status, errors.ErrorWithStack(err)
+	}
+	return status, nil
 }
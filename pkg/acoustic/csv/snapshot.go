@@ -0,0 +1,217 @@
+package csv
+
+import (
+	"encoding/csv"
+	"github.com/dekanayake/acoustic-content-sync/pkg/acoustic/author/api"
+	"github.com/dekanayake/acoustic-content-sync/pkg/env"
+	"github.com/dekanayake/acoustic-content-sync/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+const SKIPPED_UNCHANGED = "unchanged since the previous run"
+
+const SKIPPED_DISAPPEARED_DRY_RUN = "disappeared from the feed , not removed in a dry run"
+
+var snapshotHeaders = []string{"csvRecordKeyValue", "acousticID", "contentHash"}
+
+type SnapshotEntry struct {
+	CSVIDValue  string
+	AcousticID  string
+	ContentHash string
+}
+
+// Snapshot is the hash of each record of the last delta run of a config and content type , a delta run only processes
+// the records added or changed since the snapshot and finds the records which disappeared from the feed
+type Snapshot interface {
+	IsUnchanged(record api.AcousticDataRecord) (bool, SnapshotEntry)
+	Seen(record api.AcousticDataRecord)
+	Processed(record api.AcousticDataRecord, acousticID string)
+	Disappeared() []SnapshotEntry
+	Keep(entry SnapshotEntry)
+	Save() error
+}
+
+type snapshot struct {
+	location string
+	previous map[string]SnapshotEntry
+	seen     map[string]bool
+	next     map[string]SnapshotEntry
+	mux      *sync.Mutex
+}
+
+type noOpSnapshot struct {
+}
+
+// OpenSnapshot reads the snapshot of the previous delta run of the config and content type. The feed is not part of
+// the snapshot identity , so a new feed file of every night is compared with the snapshot of the previous night
+func OpenSnapshot(operation string, configPath string, runArgs ...string) (Snapshot, error) {
+	if !env.IsDeltaEnabled() {
+		return noOpSnapshot{}, nil
+	}
+	runID, err := runFileID(operation, []string{configPath}, runArgs...)
+	if err != nil {
+		return nil, errors.ErrorWithStack(err)
+	}
+	location := filepath.Join(env.SnapshotLocation(), runID+".csv")
+	previous, err := readSnapshotEntries(location)
+	if err != nil {
+		return nil, errors.ErrorWithStack(err)
+	}
+	log.Info("Delta run , " + strconv.Itoa(len(previous)) + " records in the snapshot of the previous run. Snapshot :" + location)
+	return &snapshot{
+		location: location,
+		previous: previous,
+		seen:     make(map[string]bool),
+		next:     make(map[string]SnapshotEntry),
+		mux:      &sync.Mutex{},
+	}, nil
+}
+
+func readSnapshotEntries(location string) (map[string]SnapshotEntry, error) {
+	entries := make(map[string]SnapshotEntry)
+	file, err := os.Open(location)
+	if os.IsNotExist(err) {
+		return entries, nil
+	} else if err != nil {
+		return nil, errors.ErrorWithStack(err)
+	}
+	defer file.Close()
+	reader := csv.NewReader(file)
+	for first := true; ; first = false {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, errors.ErrorMessageWithStack("Failed in reading the snapshot :" + location + " : " + err.Error())
+		}
+		if first {
+			continue
+		}
+		entries[row[0]] = SnapshotEntry{
+			CSVIDValue:  row[0],
+			AcousticID:  row[1],
+			ContentHash: row[2],
+		}
+	}
+	return entries, nil
+}
+
+// IsUnchanged checks the record has the hash of the snapshot , an unchanged record is carried over to the next snapshot
+func (s *snapshot) IsUnchanged(record api.AcousticDataRecord) (bool, SnapshotEntry) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	entry, ok := s.previous[record.CSVRecordKeyValue()]
+	if !ok || entry.ContentHash != contentHash(record) {
+		return false, SnapshotEntry{}
+	}
+	s.next[entry.CSVIDValue] = entry
+	return true, entry
+}
+
+// Seen marks the record as in the feed , the records of the snapshot not seen in the run are the disappeared records
+func (s *snapshot) Seen(record api.AcousticDataRecord) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.seen[record.CSVRecordKeyValue()] = true
+}
+
+// Processed adds the record to the next snapshot , a failed record is left out so it is processed again in the next run
+func (s *snapshot) Processed(record api.AcousticDataRecord, acousticID string) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.next[record.CSVRecordKeyValue()] = SnapshotEntry{
+		CSVIDValue:  record.CSVRecordKeyValue(),
+		AcousticID:  acousticID,
+		ContentHash: contentHash(record),
+	}
+}
+
+func (s *snapshot) Disappeared() []SnapshotEntry {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	disappeared := make([]SnapshotEntry, 0)
+	for csvIDValue, entry := range s.previous {
+		if !s.seen[csvIDValue] {
+			disappeared = append(disappeared, entry)
+		}
+	}
+	sort.Slice(disappeared, func(i, j int) bool {
+		return disappeared[i].CSVIDValue < disappeared[j].CSVIDValue
+	})
+	return disappeared
+}
+
+// Keep carries a disappeared record over to the next snapshot , for the records which could not be removed
+func (s *snapshot) Keep(entry SnapshotEntry) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.next[entry.CSVIDValue] = entry
+}
+
+// Save replaces the snapshot with the records of the run , the snapshot is not changed by a dry run
+func (s *snapshot) Save() error {
+	if env.IsDryRunEnabled() {
+		return nil
+	}
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if err := os.MkdirAll(filepath.Dir(s.location), 0755); err != nil {
+		return errors.ErrorWithStack(err)
+	}
+	csvIDValues := make([]string, 0, len(s.next))
+	for csvIDValue := range s.next {
+		csvIDValues = append(csvIDValues, csvIDValue)
+	}
+	sort.Strings(csvIDValues)
+	// written to a temporary file first , so a run killed while saving keeps the previous snapshot
+	file, err := os.Create(s.location + ".tmp")
+	if err != nil {
+		return errors.ErrorWithStack(err)
+	}
+	writer := csv.NewWriter(file)
+	writer.Write(snapshotHeaders)
+	for _, csvIDValue := range csvIDValues {
+		entry := s.next[csvIDValue]
+		writer.Write([]string{entry.CSVIDValue, entry.AcousticID, entry.ContentHash})
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		file.Close()
+		return errors.ErrorWithStack(err)
+	}
+	if err := file.Close(); err != nil {
+		return errors.ErrorWithStack(err)
+	}
+	if err := os.Rename(s.location+".tmp", s.location); err != nil {
+		return errors.ErrorWithStack(err)
+	}
+	log.Info("Saved the snapshot of " + strconv.Itoa(len(s.next)) + " records :" + s.location)
+	return nil
+}
+
+func (n noOpSnapshot) IsUnchanged(record api.AcousticDataRecord) (bool, SnapshotEntry) {
+	return false, SnapshotEntry{}
+}
+
+func (n noOpSnapshot) Seen(record api.AcousticDataRecord) {
+}
+
+func (n noOpSnapshot) Processed(record api.AcousticDataRecord, acousticID string) {
+}
+
+func (n noOpSnapshot) Disappeared() []SnapshotEntry {
+	return nil
+}
+
+func (n noOpSnapshot) Keep(entry SnapshotEntry) {
+}
+
+func (n noOpSnapshot) Save() error {
+	return nil
+}
@@ -0,0 +1,291 @@
+package csv
+
+import (
+	"github.com/dekanayake/acoustic-content-sync/pkg/env"
+	"github.com/dekanayake/acoustic-content-sync/pkg/errors"
+	"html"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// FieldTransform is a named transform of the value of a field mapping , the transforms of a field mapping are applied
+// in order to the value read from the feed before it is converted to the acoustic element
+type FieldTransform struct {
+	Name string   `yaml:"name"`
+	Args []string `yaml:"args"`
+}
+
+type transformFunc func(value string) (string, error)
+
+type fieldTransformer struct {
+	minArgs int
+	maxArgs int
+	// compile parses the arguments once , the returned function transforms the values
+	compile func(args []string) (transformFunc, error)
+}
+
+var fieldTransformers = map[string]fieldTransformer{
+	"trim": {
+		maxArgs: 1,
+		compile: func(args []string) (transformFunc, error) {
+			if len(args) == 0 {
+				return func(value string) (string, error) {
+					return strings.TrimSpace(value), nil
+				}, nil
+			}
+			return func(value string) (string, error) {
+				return strings.Trim(value, args[0]), nil
+			}, nil
+		},
+	},
+	"upper": {
+		compile: func(args []string) (transformFunc, error) {
+			return func(value string) (string, error) {
+				return strings.ToUpper(value), nil
+			}, nil
+		},
+	},
+	"lower": {
+		compile: func(args []string) (transformFunc, error) {
+			return func(value string) (string, error) {
+				return strings.ToLower(value), nil
+			}, nil
+		},
+	},
+	"replace": {
+		minArgs: 2,
+		maxArgs: 2,
+		compile: func(args []string) (transformFunc, error) {
+			return func(value string) (string, error) {
+				return strings.ReplaceAll(value, args[0], args[1]), nil
+			}, nil
+		},
+	},
+	"replaceRegx": {
+		minArgs: 2,
+		maxArgs: 2,
+		compile: func(args []string) (transformFunc, error) {
+			compiledRegx, err := regexp.Compile(args[0])
+			if err != nil {
+				return nil, err
+			}
+			return func(value string) (string, error) {
+				return compiledRegx.ReplaceAllString(value, args[1]), nil
+			}, nil
+		},
+	},
+	"default": {
+		minArgs: 1,
+		maxArgs: 1,
+		compile: func(args []string) (transformFunc, error) {
+			return func(value string) (string, error) {
+				if value == "" {
+					return args[0], nil
+				}
+				return value, nil
+			}, nil
+		},
+	},
+	// prefix and suffix are not added to an empty value , so an empty value is still skipped
+	"prefix": {
+		minArgs: 1,
+		maxArgs: 1,
+		compile: func(args []string) (transformFunc, error) {
+			return func(value string) (string, error) {
+				if value == "" {
+					return value, nil
+				}
+				return args[0] + value, nil
+			}, nil
+		},
+	},
+	"suffix": {
+		minArgs: 1,
+		maxArgs: 1,
+		compile: func(args []string) (transformFunc, error) {
+			return func(value string) (string, error) {
+				if value == "" {
+					return value, nil
+				}
+				return value + args[0], nil
+			}, nil
+		},
+	},
+	"numberFormat": {
+		minArgs: 1,
+		maxArgs: 1,
+		compile: func(args []string) (transformFunc, error) {
+			decimals, err := strconv.Atoi(args[0])
+			if err != nil || decimals < 0 {
+				return nil, errors.ErrorMessageWithStack("the number of decimals should be a positive number")
+			}
+			return func(value string) (string, error) {
+				if value == "" {
+					return value, nil
+				}
+				number, err := strconv.ParseFloat(strings.ReplaceAll(value, ",", ""), 64)
+				if err != nil {
+					return "", errors.ErrorMessageWithStack("value :" + value + " is not a number")
+				}
+				return strconv.FormatFloat(number, 'f', decimals, 64), nil
+			}, nil
+		},
+	},
+	// split picks an item of the value split by the separator , a negative index counts from the last item
+	"split": {
+		minArgs: 2,
+		maxArgs: 2,
+		compile: func(args []string) (transformFunc, error) {
+			index, err := strconv.Atoi(args[1])
+			if err != nil {
+				return nil, err
+			}
+			return func(value string) (string, error) {
+				items := strings.Split(value, args[0])
+				itemIndex := index
+				if itemIndex < 0 {
+					itemIndex = len(items) + itemIndex
+				}
+				if itemIndex < 0 || itemIndex >= len(items) {
+					return "", nil
+				}
+				return strings.TrimSpace(items[itemIndex]), nil
+			}, nil
+		},
+	},
+	// splitJoin splits the value by the separator and joins the trimmed non empty items with the joiner , the joiner is the
+	// multiple items separator when not given
+	"splitJoin": {
+		minArgs: 1,
+		maxArgs: 2,
+		compile: func(args []string) (transformFunc, error) {
+			return func(value string) (string, error) {
+				joiner := env.MultipleItemsSeperator()
+				if len(args) > 1 {
+					joiner = args[1]
+				}
+				items := make([]string, 0)
+				for _, item := range strings.Split(value, args[0]) {
+					if item = strings.TrimSpace(item); item != "" {
+						items = append(items, item)
+					}
+				}
+				return strings.Join(items, joiner), nil
+			}, nil
+		},
+	},
+	"htmlEscape": {
+		compile: func(args []string) (transformFunc, error) {
+			return func(value string) (string, error) {
+				return html.EscapeString(value), nil
+			}, nil
+		},
+	},
+	"htmlUnescape": {
+		compile: func(args []string) (transformFunc, error) {
+			return func(value string) (string, error) {
+				return html.UnescapeString(value), nil
+			}, nil
+		},
+	},
+}
+
+func transformNames() []string {
+	names := make([]string, 0, len(fieldTransformers))
+	for name := range fieldTransformers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// checkTransform checks the transform is known and has valid arguments
+func checkTransform(transform FieldTransform) error {
+	_, err := compileTransform(transform)
+	return err
+}
+
+func compileTransform(transform FieldTransform) (transformFunc, error) {
+	transformer, ok := fieldTransformers[transform.Name]
+	if !ok {
+		return nil, errors.ErrorMessageWithStack("unknown transform " + strconv.Quote(transform.Name) + " , supported transforms : " + strings.Join(transformNames(), ", "))
+	}
+	if len(transform.Args) < transformer.minArgs || len(transform.Args) > transformer.maxArgs {
+		expected := strconv.Itoa(transformer.minArgs)
+		if transformer.maxArgs != transformer.minArgs {
+			expected = expected + " to " + strconv.Itoa(transformer.maxArgs)
+		}
+		return nil, errors.ErrorMessageWithStack("transform " + strconv.Quote(transform.Name) + " takes " + expected + " args , " + strconv.Itoa(len(transform.Args)) + " given")
+	}
+	compiled, err := transformer.compile(transform.Args)
+	if err != nil {
+		return nil, errors.ErrorMessageWithStack("invalid args of the transform " + strconv.Quote(transform.Name) + " : " + err.Error())
+	}
+	return compiled, nil
+}
+
+var compiledTransforms = sync.Map{}
+
+// compileTransforms checks the transforms of a field mapping and parses their arguments , the compiled transforms are
+// kept as the same transforms are applied to the value of every row of the feed. On an error the transforms compiled
+// before the failed transform are returned
+func compileTransforms(transforms []FieldTransform) ([]transformFunc, error) {
+	key := transformsKey(transforms)
+	if compiled, ok := compiledTransforms.Load(key); ok {
+		return compiled.([]transformFunc), nil
+	}
+	compiled := make([]transformFunc, 0, len(transforms))
+	for _, transform := range transforms {
+		compiledTransform, err := compileTransform(transform)
+		if err != nil {
+			return compiled, err
+		}
+		compiled = append(compiled, compiledTransform)
+	}
+	compiledTransforms.Store(key, compiled)
+	return compiled, nil
+}
+
+func transformsKey(transforms []FieldTransform) string {
+	var key strings.Builder
+	for _, transform := range transforms {
+		key.WriteString(strconv.Quote(transform.Name))
+		for _, arg := range transform.Args {
+			key.WriteString(" " + strconv.Quote(arg))
+		}
+		key.WriteString(";")
+	}
+	return key.String()
+}
+
+// applyTransforms applies the transforms of the field mapping in order , the error names the failing transform and the row
+func (contentFieldMapping ContentFieldMapping) applyTransforms(value string, dataRow DataRow) (string, error) {
+	if len(contentFieldMapping.Transforms) == 0 {
+		return value, nil
+	}
+	compiled, err := compileTransforms(contentFieldMapping.Transforms)
+	if err != nil {
+		index := len(compiled)
+		return "", errors.ErrorMessageWithStack(contentFieldMapping.transformFailure(index, contentFieldMapping.Transforms[index], dataRow) + err.Error())
+	}
+	for index, transform := range compiled {
+		transformed, err := transform(value)
+		if err != nil {
+			return "", errors.ErrorMessageWithStack(contentFieldMapping.transformFailure(index, contentFieldMapping.Transforms[index], dataRow) + err.Error())
+		}
+		value = transformed
+	}
+	return value, nil
+}
+
+func (contentFieldMapping ContentFieldMapping) transformFailure(index int, transform FieldTransform, dataRow DataRow) string {
+	field := contentFieldMapping.AcousticProperty
+	if contentFieldMapping.CsvProperty != "" {
+		field = contentFieldMapping.CsvProperty
+	}
+	return "transform " + strconv.Quote(transform.Name) + " (transforms[" + strconv.Itoa(index) + "]) of the field " + strconv.Quote(field) +
+		" failed on row " + strconv.Itoa(dataRow.RowNumber()) + " : "
+}
@@ -0,0 +1,126 @@
+package csv
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTransforms(t *testing.T) {
+	t.Setenv("MultipleItemsSeperator", ";")
+	tests := []struct {
+		name      string
+		transform FieldTransform
+		value     string
+		expected  string
+	}{
+		{"trim spaces", FieldTransform{Name: "trim"}, "  shoe \t", "shoe"},
+		{"trim chars", FieldTransform{Name: "trim", Args: []string{"#"}}, "##shoe#", "shoe"},
+		{"upper", FieldTransform{Name: "upper"}, "Shoe", "SHOE"},
+		{"lower", FieldTransform{Name: "lower"}, "Shoe", "shoe"},
+		{"replace", FieldTransform{Name: "replace", Args: []string{"-", " "}}, "red-shoe-41", "red shoe 41"},
+		{"replaceRegx", FieldTransform{Name: "replaceRegx", Args: []string{`(\d+)cm`, "$1 cm"}}, "12cm x 4cm", "12 cm x 4 cm"},
+		{"default of empty", FieldTransform{Name: "default", Args: []string{"n/a"}}, "", "n/a"},
+		{"default of value", FieldTransform{Name: "default", Args: []string{"n/a"}}, "shoe", "shoe"},
+		{"prefix", FieldTransform{Name: "prefix", Args: []string{"SKU-"}}, "41", "SKU-41"},
+		{"prefix of empty", FieldTransform{Name: "prefix", Args: []string{"SKU-"}}, "", ""},
+		{"suffix", FieldTransform{Name: "suffix", Args: []string{" cm"}}, "12", "12 cm"},
+		{"suffix of empty", FieldTransform{Name: "suffix", Args: []string{" cm"}}, "", ""},
+		{"numberFormat", FieldTransform{Name: "numberFormat", Args: []string{"2"}}, "1,234.5", "1234.50"},
+		{"numberFormat of empty", FieldTransform{Name: "numberFormat", Args: []string{"2"}}, "", ""},
+		{"split", FieldTransform{Name: "split", Args: []string{"/", "1"}}, "shoes / running / trail", "running"},
+		{"split from the last", FieldTransform{Name: "split", Args: []string{"/", "-1"}}, "shoes / running / trail", "trail"},
+		{"split out of range", FieldTransform{Name: "split", Args: []string{"/", "5"}}, "shoes / running", ""},
+		{"splitJoin", FieldTransform{Name: "splitJoin", Args: []string{"|"}}, "red| blue ||green", "red;blue;green"},
+		{"splitJoin with joiner", FieldTransform{Name: "splitJoin", Args: []string{"|", ", "}}, "red| blue", "red, blue"},
+		{"htmlEscape", FieldTransform{Name: "htmlEscape"}, `<b>"Tom" & Co</b>`, "&lt;b&gt;&#34;Tom&#34; &amp; Co&lt;/b&gt;"},
+		{"htmlUnescape", FieldTransform{Name: "htmlUnescape"}, "Tom &amp; Co &lt;3", "Tom & Co <3"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mapping := ContentFieldMapping{CsvProperty: "value", Transforms: []FieldTransform{test.transform}}
+			transformed, err := mapping.applyTransforms(test.value, &dataRow{rowNumber: 2})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if transformed != test.expected {
+				t.Errorf("expected %q , got %q", test.expected, transformed)
+			}
+		})
+	}
+}
+
+func TestTransformsAreAppliedInOrder(t *testing.T) {
+	mapping := ContentFieldMapping{CsvProperty: "name", Transforms: []FieldTransform{
+		{Name: "trim"},
+		{Name: "default", Args: []string{"unknown"}},
+		{Name: "upper"},
+		{Name: "prefix", Args: []string{"BRAND-"}},
+	}}
+	for value, expected := range map[string]string{" acme ": "BRAND-ACME", "  ": "BRAND-UNKNOWN"} {
+		transformed, err := mapping.applyTransforms(value, &dataRow{rowNumber: 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if transformed != expected {
+			t.Errorf("expected %q , got %q", expected, transformed)
+		}
+	}
+	if _, ok := compiledTransforms.Load(transformsKey(mapping.Transforms)); !ok {
+		t.Error("expected the compiled transforms to be kept")
+	}
+}
+
+func TestCheckTransform(t *testing.T) {
+	tests := []struct {
+		name      string
+		transform FieldTransform
+		expected  string
+	}{
+		{"unknown", FieldTransform{Name: "capitalize"}, `unknown transform "capitalize" , supported transforms : default, htmlEscape`},
+		{"too many args", FieldTransform{Name: "upper", Args: []string{"x"}}, `transform "upper" takes 0 args , 1 given`},
+		{"missing args", FieldTransform{Name: "replace", Args: []string{"x"}}, `transform "replace" takes 2 args , 1 given`},
+		{"args range", FieldTransform{Name: "splitJoin"}, `transform "splitJoin" takes 1 to 2 args , 0 given`},
+		{"invalid regx", FieldTransform{Name: "replaceRegx", Args: []string{"(", ""}}, `invalid args of the transform "replaceRegx" : error parsing regexp`},
+		{"negative decimals", FieldTransform{Name: "numberFormat", Args: []string{"-1"}}, `invalid args of the transform "numberFormat" : the number of decimals should be a positive number`},
+		{"invalid decimals", FieldTransform{Name: "numberFormat", Args: []string{"two"}}, `invalid args of the transform "numberFormat" : the number of decimals should be a positive number`},
+		{"invalid index", FieldTransform{Name: "split", Args: []string{"/", "last"}}, `invalid args of the transform "split" : strconv.Atoi`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := checkTransform(test.transform)
+			if err == nil || !strings.Contains(err.Error(), test.expected) {
+				t.Errorf("expected the error %q , got %v", test.expected, err)
+			}
+		})
+	}
+	if err := checkTransform(FieldTransform{Name: "split", Args: []string{"/", "-1"}}); err != nil {
+		t.Errorf("expected a valid transform , got %v", err)
+	}
+}
+
+func TestTransformFailure(t *testing.T) {
+	tests := []struct {
+		name     string
+		mapping  ContentFieldMapping
+		expected string
+	}{
+		{
+			"failed transform",
+			ContentFieldMapping{CsvProperty: "price", Transforms: []FieldTransform{{Name: "trim"}, {Name: "numberFormat", Args: []string{"2"}}}},
+			`transform "numberFormat" (transforms[1]) of the field "price" failed on row 7 : value :abc is not a number`,
+		},
+		{
+			"invalid transform",
+			ContentFieldMapping{AcousticProperty: "size", StaticValue: "abc", Transforms: []FieldTransform{{Name: "lower"}, {Name: "upper"}, {Name: "split", Args: []string{"/"}}}},
+			`transform "split" (transforms[2]) of the field "size" failed on row 7 : transform "split" takes 2 args , 1 given`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := test.mapping.applyTransforms(" abc ", &dataRow{rowNumber: 7})
+			if err == nil || !strings.Contains(err.Error(), test.expected) {
+				t.Errorf("expected the error %q , got %v", test.expected, err)
+			}
+		})
+	}
+}
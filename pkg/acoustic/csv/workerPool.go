@@ -0,0 +1,59 @@
+package csv
+
+import (
+	"github.com/dekanayake/acoustic-content-sync/pkg/acoustic/author/api"
+	"github.com/dekanayake/acoustic-content-sync/pkg/env"
+	log "github.com/sirupsen/logrus"
+	"strconv"
+	"sync"
+)
+
+// workerCount resolves the number of concurrent workers for a content type.
+// The run level WorkerCount takes the precedence over the workers configured in the content type mapping
+func workerCount(configTypeMapping *ContentTypeMapping) int {
+	if workers := env.WorkerCount(); workers > 0 {
+		return workers
+	}
+	if configTypeMapping != nil && configTypeMapping.Workers > 0 {
+		return configTypeMapping.Workers
+	}
+	return 1
+}
+
+// processRecords runs the process function on the records using a bounded pool of workers
+// and returns once all the records are processed
+func processRecords(workers int, records <-chan api.AcousticDataRecord, process func(record api.AcousticDataRecord)) {
+	if workers < 1 {
+		workers = 1
+	}
+	log.Info("Processing records with workers :" + strconv.Itoa(workers))
+	var waitGroup sync.WaitGroup
+	waitGroup.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer waitGroup.Done()
+			for record := range records {
+				process(record)
+			}
+		}()
+	}
+	waitGroup.Wait()
+}
+
+// streamRecords runs the transform in the background and sends each transformed record to the returned channel,
+// so the workers start on the first record while the rest of the feed is still being read.
+// The returned function gives the error of the transform , it must be called only after the channel is drained
+func streamRecords(transform func(handle func(record api.AcousticDataRecord) error) error) (<-chan api.AcousticDataRecord, func() error) {
+	recordsChan := make(chan api.AcousticDataRecord)
+	var transformErr error
+	go func() {
+		defer close(recordsChan)
+		transformErr = transform(func(record api.AcousticDataRecord) error {
+			recordsChan <- record
+			return nil
+		})
+	}()
+	return recordsChan, func() error {
+		return transformErr
+	}
+}
@@ -0,0 +1,387 @@
+package csv
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"github.com/dekanayake/acoustic-content-sync/pkg/errors"
+	"io"
+	"math"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// xlsxDataFeed reads the rows of a sheet of a XLSX workbook one at a time. The first row of the sheet is the header row.
+// Cells formatted as dates are read as RFC3339 date times , all the other cells are read as the displayed raw value
+type xlsxDataFeed struct {
+	workbook      *zip.ReadCloser
+	sheet         io.ReadCloser
+	decoder       *xml.Decoder
+	sharedStrings []string
+	dateStyles    map[int]bool
+	date1904      bool
+	headers       []string
+	rowNumber     int
+	next          *dataRow
+	err           error
+}
+
+type xlsxWorkbook struct {
+	WorkbookPr struct {
+		Date1904 bool `xml:"date1904,attr"`
+	} `xml:"workbookPr"`
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		ID   string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxText struct {
+	Text string `xml:"t"`
+	Runs []struct {
+		Text string `xml:"t"`
+	} `xml:"r"`
+}
+
+type xlsxSharedStrings struct {
+	Items []xlsxText `xml:"si"`
+}
+
+type xlsxStyles struct {
+	NumFmts []struct {
+		ID         int    `xml:"numFmtId,attr"`
+		FormatCode string `xml:"formatCode,attr"`
+	} `xml:"numFmts>numFmt"`
+	CellXfs []struct {
+		NumFmtID int `xml:"numFmtId,attr"`
+	} `xml:"cellXfs>xf"`
+}
+
+type xlsxRow struct {
+	Number int        `xml:"r,attr"`
+	Cells  []xlsxCell `xml:"c"`
+}
+
+type xlsxCell struct {
+	Ref          string   `xml:"r,attr"`
+	Type         string   `xml:"t,attr"`
+	Style        int      `xml:"s,attr"`
+	Value        string   `xml:"v"`
+	InlineString xlsxText `xml:"is"`
+}
+
+var xlsxDateFormatRegx = regexp.MustCompile(`"[^"]*"|\[[^\]]*\]|\\.`)
+
+func LoadXLSX(xlsxFilePath string, sheetName string) (DataFeed, error) {
+	workbook, err := zip.OpenReader(xlsxFilePath)
+	if err != nil {
+		return nil, errors.ErrorWithStack(err)
+	}
+	dataFeed, err := loadXLSX(workbook, sheetName)
+	if err != nil {
+		workbook.Close()
+		return nil, err
+	}
+	return dataFeed, nil
+}
+
+func loadXLSX(workbook *zip.ReadCloser, sheetName string) (*xlsxDataFeed, error) {
+	workbookInfo := xlsxWorkbook{}
+	if err := decodeXLSXPart(workbook, "xl/workbook.xml", &workbookInfo); err != nil {
+		return nil, err
+	}
+	if len(workbookInfo.Sheets) == 0 {
+		return nil, errors.ErrorMessageWithStack("No sheets found in the XLSX feed")
+	}
+	sheetID := workbookInfo.Sheets[0].ID
+	if sheetName != "" {
+		sheetID = ""
+		sheetNames := make([]string, 0, len(workbookInfo.Sheets))
+		for _, sheet := range workbookInfo.Sheets {
+			sheetNames = append(sheetNames, sheet.Name)
+			if sheet.Name == sheetName {
+				sheetID = sheet.ID
+			}
+		}
+		if sheetID == "" {
+			return nil, errors.ErrorMessageWithStack("No sheet found with the name :" + sheetName + " , available sheets :" + strings.Join(sheetNames, ","))
+		}
+	}
+	relationships := xlsxRelationships{}
+	if err := decodeXLSXPart(workbook, "xl/_rels/workbook.xml.rels", &relationships); err != nil {
+		return nil, err
+	}
+	sheetPath := ""
+	for _, relationship := range relationships.Relationships {
+		if relationship.ID == sheetID {
+			if strings.HasPrefix(relationship.Target, "/") {
+				sheetPath = strings.TrimPrefix(relationship.Target, "/")
+			} else {
+				sheetPath = path.Join("xl", relationship.Target)
+			}
+		}
+	}
+	if sheetPath == "" {
+		return nil, errors.ErrorMessageWithStack("No worksheet found for the sheet :" + sheetName)
+	}
+
+	sharedStrings := xlsxSharedStrings{}
+	if err := decodeXLSXPart(workbook, "xl/sharedStrings.xml", &sharedStrings); err != nil && !isMissingXLSXPart(err) {
+		return nil, err
+	}
+	styles := xlsxStyles{}
+	if err := decodeXLSXPart(workbook, "xl/styles.xml", &styles); err != nil && !isMissingXLSXPart(err) {
+		return nil, err
+	}
+
+	sheet, err := openXLSXPart(workbook, sheetPath)
+	if err != nil {
+		return nil, err
+	}
+	dataFeed := &xlsxDataFeed{
+		workbook:      workbook,
+		sheet:         sheet,
+		decoder:       xml.NewDecoder(sheet),
+		sharedStrings: make([]string, 0, len(sharedStrings.Items)),
+		dateStyles:    xlsxDateStyles(styles),
+		date1904:      workbookInfo.WorkbookPr.Date1904,
+	}
+	for _, sharedString := range sharedStrings.Items {
+		dataFeed.sharedStrings = append(dataFeed.sharedStrings, sharedString.String())
+	}
+	headerRow, err := dataFeed.readRow()
+	if err == io.EOF {
+		sheet.Close()
+		return nil, errors.ErrorMessageWithStack("XLSX sheet is empty. ")
+	} else if err != nil {
+		sheet.Close()
+		return nil, err
+	}
+	dataFeed.headers = headerRow
+	dataFeed.readNext()
+	return dataFeed, nil
+}
+
+type missingXLSXPartError struct {
+	name string
+}
+
+func (e missingXLSXPartError) Error() string {
+	return "XLSX part not found :" + e.name
+}
+
+func isMissingXLSXPart(err error) bool {
+	_, ok := err.(missingXLSXPartError)
+	return ok
+}
+
+func openXLSXPart(workbook *zip.ReadCloser, name string) (io.ReadCloser, error) {
+	for _, file := range workbook.File {
+		if file.Name == name {
+			part, err := file.Open()
+			if err != nil {
+				return nil, errors.ErrorWithStack(err)
+			}
+			return part, nil
+		}
+	}
+	return nil, missingXLSXPartError{name: name}
+}
+
+func decodeXLSXPart(workbook *zip.ReadCloser, name string, value interface{}) error {
+	part, err := openXLSXPart(workbook, name)
+	if err != nil {
+		return err
+	}
+	defer part.Close()
+	if err := xml.NewDecoder(part).Decode(value); err != nil {
+		return errors.ErrorWithStack(err)
+	}
+	return nil
+}
+
+// xlsxDateStyles returns the cell styles with a date number format , a date is stored in the sheet as a number
+// and only the number format tells it is a date
+func xlsxDateStyles(styles xlsxStyles) map[int]bool {
+	dateFormats := make(map[int]bool)
+	for _, builtInDateFormat := range []int{14, 15, 16, 17, 18, 19, 20, 21, 22, 27, 28, 29, 30, 31, 32, 33, 34, 35, 36, 45, 46, 47, 50, 51, 52, 53, 54, 55, 56, 57, 58} {
+		dateFormats[builtInDateFormat] = true
+	}
+	for _, numFmt := range styles.NumFmts {
+		formatCode := strings.ToLower(xlsxDateFormatRegx.ReplaceAllString(numFmt.FormatCode, ""))
+		dateFormats[numFmt.ID] = strings.ContainsAny(formatCode, "ydh")
+	}
+	dateStyles := make(map[int]bool)
+	for index, cellXf := range styles.CellXfs {
+		if dateFormats[cellXf.NumFmtID] {
+			dateStyles[index] = true
+		}
+	}
+	return dateStyles
+}
+
+func (text xlsxText) String() string {
+	if len(text.Runs) == 0 {
+		return text.Text
+	}
+	var builder strings.Builder
+	builder.WriteString(text.Text)
+	for _, run := range text.Runs {
+		builder.WriteString(run.Text)
+	}
+	return builder.String()
+}
+
+// xlsxColumnIndex returns the zero based column index of a cell reference , ex: AB12 is 27
+func xlsxColumnIndex(ref string) int {
+	index := 0
+	for _, char := range ref {
+		if char < 'A' || char > 'Z' {
+			break
+		}
+		index = index*26 + int(char-'A'+1)
+	}
+	return index - 1
+}
+
+// readRow reads the next row of the sheet , the values are positioned by the column of the cell so the empty cells
+// not stored in the sheet are read as empty values
+func (dataFeed *xlsxDataFeed) readRow() ([]string, error) {
+	for {
+		token, err := dataFeed.decoder.Token()
+		if err == io.EOF {
+			return nil, io.EOF
+		} else if err != nil {
+			return nil, errors.ErrorWithStack(err)
+		}
+		startElement, ok := token.(xml.StartElement)
+		if !ok || startElement.Name.Local != "row" {
+			continue
+		}
+		row := xlsxRow{}
+		if err := dataFeed.decoder.DecodeElement(&row, &startElement); err != nil {
+			return nil, errors.ErrorWithStack(err)
+		}
+		// the row number is optional in the sheet , the rows are then counted
+		if row.Number > 0 {
+			dataFeed.rowNumber = row.Number
+		} else {
+			dataFeed.rowNumber += 1
+		}
+		values := make([]string, 0, len(row.Cells))
+		for position, cell := range row.Cells {
+			index := position
+			if cell.Ref != "" {
+				index = xlsxColumnIndex(cell.Ref)
+			}
+			for len(values) <= index {
+				values = append(values, "")
+			}
+			value, err := dataFeed.cellValue(cell)
+			if err != nil {
+				return nil, err
+			}
+			values[index] = value
+		}
+		return values, nil
+	}
+}
+
+func (dataFeed *xlsxDataFeed) cellValue(cell xlsxCell) (string, error) {
+	switch cell.Type {
+	case "s":
+		index, err := strconv.Atoi(cell.Value)
+		if err != nil || index < 0 || index >= len(dataFeed.sharedStrings) {
+			return "", errors.ErrorMessageWithStack("Invalid shared string of the cell :" + cell.Ref)
+		}
+		return dataFeed.sharedStrings[index], nil
+	case "inlineStr":
+		return cell.InlineString.String(), nil
+	case "b":
+		return strconv.FormatBool(cell.Value == "1"), nil
+	case "", "n":
+		if cell.Value != "" && dataFeed.dateStyles[cell.Style] {
+			serial, err := strconv.ParseFloat(cell.Value, 64)
+			if err == nil {
+				return dataFeed.serialToTime(serial).Format(time.RFC3339), nil
+			}
+		}
+		return cell.Value, nil
+	default:
+		return cell.Value, nil
+	}
+}
+
+func (dataFeed *xlsxDataFeed) serialToTime(serial float64) time.Time {
+	base := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+	if dataFeed.date1904 {
+		base = time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	days := math.Floor(serial)
+	seconds := math.Round((serial - days) * 24 * 60 * 60)
+	return base.AddDate(0, 0, int(days)).Add(time.Duration(seconds) * time.Second)
+}
+
+func (dataFeed *xlsxDataFeed) readNext() {
+	dataFeed.next = nil
+	for dataFeed.err == nil {
+		values, err := dataFeed.readRow()
+		if err == io.EOF {
+			return
+		} else if err != nil {
+			dataFeed.err = err
+			return
+		}
+		row := make(map[string]string, len(dataFeed.headers))
+		empty := true
+		for index, header := range dataFeed.headers {
+			value := ""
+			if index < len(values) {
+				value = values[index]
+			}
+			empty = empty && strings.TrimSpace(value) == ""
+			row[header] = value
+		}
+		// formatted but empty rows are stored in the sheet , they are not records of the feed
+		if empty {
+			continue
+		}
+		dataFeed.next = &dataRow{columns: row, rowNumber: dataFeed.rowNumber}
+		return
+	}
+}
+
+func (dataFeed *xlsxDataFeed) HasNext() bool {
+	return dataFeed.next != nil
+}
+
+func (dataFeed *xlsxDataFeed) Next() DataRow {
+	dataRow := dataFeed.next
+	dataFeed.readNext()
+	return dataRow
+}
+
+func (dataFeed *xlsxDataFeed) Headers() ([]string, error) {
+	return dataFeed.headers, nil
+}
+
+func (dataFeed *xlsxDataFeed) Err() error {
+	return dataFeed.err
+}
+
+func (dataFeed *xlsxDataFeed) Close() error {
+	dataFeed.sheet.Close()
+	if err := dataFeed.workbook.Close(); err != nil {
+		return errors.ErrorWithStack(err)
+	}
+	return nil
+}
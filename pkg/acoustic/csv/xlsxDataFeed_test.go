@@ -0,0 +1,130 @@
+package csv
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// the fixtures of testdata are small workbooks with the parts written by hand :
+// products.xlsx has the sheets Products and Prices , the worksheet of Prices is related with an absolute path. Products
+// has shared strings with rich text runs , inline strings , booleans , the built in date format 14 , the custom date
+// format 164 , the custom number formats 165 and 166 with a color and a quoted literal , an empty formatted row , a
+// skipped row number and a row with cells without references
+// date1904.xlsx is a workbook of the 1904 date system
+// empty.xlsx has a sheet without rows , invalidSharedString.xlsx has a cell of a shared string out of the range
+
+func readXLSXRows(t *testing.T, dataFeed DataFeed, columns []string) ([]map[string]string, []int) {
+	rows := make([]map[string]string, 0)
+	rowNumbers := make([]int, 0)
+	for dataFeed.HasNext() {
+		dataRow := dataFeed.Next()
+		row := make(map[string]string, len(columns))
+		for _, column := range columns {
+			value, err := dataRow.Get(column)
+			if err != nil {
+				t.Fatal(err)
+			}
+			row[column] = value
+		}
+		rows = append(rows, row)
+		rowNumbers = append(rowNumbers, dataRow.RowNumber())
+	}
+	if err := dataFeed.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return rows, rowNumbers
+}
+
+func TestLoadXLSX(t *testing.T) {
+	dataFeed, err := LoadXLSX("testdata/products.xlsx", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dataFeed.Close()
+	headers, _ := dataFeed.Headers()
+	expectedHeaders := []string{"sku", "name", "released", "price", "active"}
+	if !reflect.DeepEqual(headers, expectedHeaders) {
+		t.Fatalf("expected the headers %v , got %v", expectedHeaders, headers)
+	}
+	rows, rowNumbers := readXLSXRows(t, dataFeed, expectedHeaders)
+	expected := []map[string]string{
+		{"sku": "1001", "name": "Red Shoe", "released": "2023-03-15T00:00:00Z", "price": "12.5", "active": "true"},
+		{"sku": "1002", "name": "", "released": "2023-03-15T18:00:00Z", "price": "", "active": "false"},
+		{"sku": "1003", "name": "Blue Hat", "released": "7", "price": "", "active": ""},
+	}
+	if !reflect.DeepEqual(rows, expected) {
+		t.Errorf("expected the rows %v , got %v", expected, rows)
+	}
+	if expectedRowNumbers := []int{2, 5, 6}; !reflect.DeepEqual(rowNumbers, expectedRowNumbers) {
+		t.Errorf("expected the row numbers %v , got %v", expectedRowNumbers, rowNumbers)
+	}
+}
+
+func TestLoadXLSXSheetByName(t *testing.T) {
+	dataFeed, err := LoadXLSX("testdata/products.xlsx", "Prices")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dataFeed.Close()
+	rows, _ := readXLSXRows(t, dataFeed, []string{"sku", "price"})
+	if expected := []map[string]string{{"sku": "1001", "price": "9.99"}}; !reflect.DeepEqual(rows, expected) {
+		t.Errorf("expected the rows %v , got %v", expected, rows)
+	}
+}
+
+func TestLoadXLSXDate1904(t *testing.T) {
+	dataFeed, err := LoadXLSX("testdata/date1904.xlsx", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dataFeed.Close()
+	rows, _ := readXLSXRows(t, dataFeed, []string{"released"})
+	expected := []map[string]string{{"released": "1904-01-01T00:00:00Z"}, {"released": "2023-03-15T12:00:00Z"}}
+	if !reflect.DeepEqual(rows, expected) {
+		t.Errorf("expected the rows %v , got %v", expected, rows)
+	}
+}
+
+func TestLoadXLSXErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		sheet    string
+		expected string
+	}{
+		{"missing sheet", "testdata/products.xlsx", "Stock", "No sheet found with the name :Stock , available sheets :Products,Prices"},
+		{"empty sheet", "testdata/empty.xlsx", "", "XLSX sheet is empty"},
+		{"not a workbook", "xlsxDataFeed_test.go", "", "zip"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := LoadXLSX(test.path, test.sheet)
+			if err == nil || !strings.Contains(err.Error(), test.expected) {
+				t.Errorf("expected the error %q , got %v", test.expected, err)
+			}
+		})
+	}
+}
+
+func TestLoadXLSXInvalidSharedString(t *testing.T) {
+	dataFeed, err := LoadXLSX("testdata/invalidSharedString.xlsx", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dataFeed.Close()
+	if dataFeed.HasNext() {
+		t.Error("expected no rows after the invalid cell")
+	}
+	if err := dataFeed.Err(); err == nil || !strings.Contains(err.Error(), "Invalid shared string of the cell :A2") {
+		t.Errorf("expected the invalid shared string error , got %v", err)
+	}
+}
+
+func TestXLSXColumnIndex(t *testing.T) {
+	for ref, expected := range map[string]int{"A1": 0, "Z9": 25, "AA1": 26, "AB12": 27, "BA3": 52} {
+		if index := xlsxColumnIndex(ref); index != expected {
+			t.Errorf("expected the column index of %s to be %d , got %d", ref, expected, index)
+		}
+	}
+}
@@ -3,6 +3,9 @@ package env
 import (
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 func GetOrPanic(variable string) string {
@@ -85,3 +88,143 @@ func WriteUnParsedRecordsToCSV() bool {
 func WriteFailedRecordIDToCSV() bool {
 	return GetOrPanic("WriteFailedRecordIDToCSV") == "true"
 }
+
+func IsDryRunEnabled() bool {
+	return os.Getenv("DryRun") == "true"
+}
+
+func DryRunOutputLocation() string {
+	location := Get("DryRunOutputLocation")
+	if location == "" {
+		return "dry_run"
+	}
+	return location
+}
+
+func WorkerCount() int {
+	workerCount, err := strconv.Atoi(Get("WorkerCount"))
+	if err != nil {
+		return 0
+	}
+	return workerCount
+}
+
+func MaxRequestsPerSecond() float64 {
+	maxRequestsPerSecond, err := strconv.ParseFloat(Get("MaxRequestsPerSecond"), 64)
+	if err != nil {
+		return 0
+	}
+	return maxRequestsPerSecond
+}
+
+func IsResumeEnabled() bool {
+	return os.Getenv("ResumeRun") == "true"
+}
+
+func JournalLocation() string {
+	location := os.Getenv("JournalLocation")
+	if location == "" {
+		return "journal"
+	}
+	return location
+}
+
+func ReportLocation() string {
+	location := os.Getenv("ReportLocation")
+	if location == "" {
+		return "run_report.json"
+	}
+	return location
+}
+
+func JUnitReportLocation() string {
+	return os.Getenv("JUnitReportLocation")
+}
+
+func IsDeltaEnabled() bool {
+	return os.Getenv("DeltaRun") == "true"
+}
+
+func IsKeyIndexEnabled() bool {
+	return os.Getenv("KeyIndex") == "true"
+}
+
+// SearchWaitTimeout is how long the search is polled for a created content , the search is not polled when 0
+func SearchWaitTimeout() time.Duration {
+	timeout, err := time.ParseDuration(Get("SearchWaitTimeout"))
+	if err != nil {
+		return 0
+	}
+	return timeout
+}
+
+func SearchWaitInterval() time.Duration {
+	interval, err := time.ParseDuration(Get("SearchWaitInterval"))
+	if err != nil || interval <= 0 {
+		return time.Second
+	}
+	return interval
+}
+
+func SnapshotLocation() string {
+	location := os.Getenv("SnapshotLocation")
+	if location == "" {
+		return "snapshot"
+	}
+	return location
+}
+
+func DeltaDeleteMapping() string {
+	return os.Getenv("DeltaDeleteMapping")
+}
+
+func RecordInclude() string {
+	return os.Getenv("RecordInclude")
+}
+
+func RecordExclude() string {
+	return os.Getenv("RecordExclude")
+}
+
+func FromRow() int {
+	fromRow, err := strconv.Atoi(Get("FromRow"))
+	if err != nil {
+		return 0
+	}
+	return fromRow
+}
+
+func RecordLimit() int {
+	limit, err := strconv.Atoi(Get("RecordLimit"))
+	if err != nil {
+		return 0
+	}
+	return limit
+}
+
+func SampleRate() float64 {
+	sampleRate, err := strconv.ParseFloat(Get("SampleRate"), 64)
+	if err != nil {
+		return 0
+	}
+	return sampleRate
+}
+
+func SampleSeed() int64 {
+	sampleSeed, err := strconv.ParseInt(Get("SampleSeed"), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return sampleSeed
+}
+
+// RecordKeys are the record key values of the records processed by a run , separated by comma
+func RecordKeys() []string {
+	keys := make([]string, 0)
+	for _, key := range strings.Split(Get("RecordKeys"), ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
@@ -1,6 +1,9 @@
 package errors
 
-import "github.com/pkg/errors"
+import (
+	"github.com/pkg/errors"
+	"regexp"
+)
 
 type retryableError struct {
 	error
@@ -34,3 +37,32 @@ func isRetryableError(err error, level int) bool {
 func IsRetryableError(err error) bool {
 	return isRetryableError(err, 1)
 }
+
+type ErrorClassType string
+
+const (
+	RETRYABLE_ERROR ErrorClassType = "retryable"
+	CLIENT_ERROR    ErrorClassType = "client_error"
+	SERVER_ERROR    ErrorClassType = "server_error"
+	GENERIC_ERROR   ErrorClassType = "error"
+)
+
+var httpStatusRegx = regexp.MustCompile(`(^|[^0-9])([45])[0-9][0-9] [A-Z]`)
+
+// ErrorClass groups an error for the run reports , a client or server error when the error has the http status of the
+// acoustic api response ( ex: 400 Bad Request ) and retryable when the retries of a retryable error are exhausted
+func ErrorClass(err error) ErrorClassType {
+	if err == nil {
+		return ""
+	}
+	if IsRetryableError(err) {
+		return RETRYABLE_ERROR
+	}
+	if matches := httpStatusRegx.FindStringSubmatch(err.Error()); matches != nil {
+		if matches[2] == "4" {
+			return CLIENT_ERROR
+		}
+		return SERVER_ERROR
+	}
+	return GENERIC_ERROR
+}